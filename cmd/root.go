@@ -36,6 +36,8 @@ import (
 var (
 	cfgFile        string
 	databaseConfig hc.Database
+	databaseDriver string
+	databasePath   string
 	ipc            string
 	subCommand     string
 	logWithCommand log.Entry
@@ -86,6 +88,8 @@ func setViperConfigs() {
 		Password: viper.GetString("database.password"),
 	}
 	viper.Set("database.config", databaseConfig)
+	databaseDriver = viper.GetString("database.driver")
+	databasePath = viper.GetString("database.path")
 }
 
 func logLevel() error {
@@ -123,6 +127,8 @@ func init() {
 	rootCmd.PersistentFlags().String("database-hostname", "localhost", "database hostname")
 	rootCmd.PersistentFlags().String("database-user", "", "database user")
 	rootCmd.PersistentFlags().String("database-password", "", "database password")
+	rootCmd.PersistentFlags().String("database-driver", "postgres", "storage driver: postgres or sqlite3")
+	rootCmd.PersistentFlags().String("database-path", "", "database file path, for the sqlite3 driver")
 	rootCmd.PersistentFlags().String("client-rpcPath", "", "rpc path to Ethereum JSON-RPC endpoints")
 	rootCmd.PersistentFlags().String("client-levelDbPath", "", "location of levelDb chaindata")
 	rootCmd.PersistentFlags().String("filesystem-storageDiffsPath", "", "location of storage diffs csv file")
@@ -137,6 +143,8 @@ func init() {
 	viper.BindPFlag("database.hostname", rootCmd.PersistentFlags().Lookup("database-hostname"))
 	viper.BindPFlag("database.user", rootCmd.PersistentFlags().Lookup("database-user"))
 	viper.BindPFlag("database.password", rootCmd.PersistentFlags().Lookup("database-password"))
+	viper.BindPFlag("database.driver", rootCmd.PersistentFlags().Lookup("database-driver"))
+	viper.BindPFlag("database.path", rootCmd.PersistentFlags().Lookup("database-path"))
 	viper.BindPFlag("client.rpcPath", rootCmd.PersistentFlags().Lookup("client-rpcPath"))
 	viper.BindPFlag("client.levelDbPath", rootCmd.PersistentFlags().Lookup("client-levelDbPath"))
 	viper.BindPFlag("filesystem.storageDiffsPath", rootCmd.PersistentFlags().Lookup("filesystem-storageDiffsPath"))
@@ -159,10 +167,10 @@ func initConfig() {
 	}
 }
 
-func getClientAndNode() (*ethclient.Client, core.Node) {
+func getClientAndNode() (*rpc.Client, *ethclient.Client, core.Node) {
 	rawRPCClient, err := rpc.Dial(ipc)
 	if err != nil {
 		logWithCommand.Fatal(err)
 	}
-	return ethclient.NewClient(rawRPCClient), node.MakeNode()
+	return rawRPCClient, ethclient.NewClient(rawRPCClient), node.MakeNode()
 }
@@ -17,15 +17,23 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"time"
 
+	"github.com/ethereum/go-ethereum/ethclient"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 
+	"github.com/vulcanize/eth-header-sync/pkg/client"
+	"github.com/vulcanize/eth-header-sync/pkg/core"
+	"github.com/vulcanize/eth-header-sync/pkg/fetcher"
+	"github.com/vulcanize/eth-header-sync/pkg/history"
 	"github.com/vulcanize/eth-header-sync/pkg/postgres"
 
+	"github.com/vulcanize/eth-contract-watcher/pkg/abi"
 	"github.com/vulcanize/eth-contract-watcher/pkg/config"
+	"github.com/vulcanize/eth-contract-watcher/pkg/storage"
 	st "github.com/vulcanize/eth-contract-watcher/pkg/transformer"
 )
 
@@ -44,19 +52,47 @@ Requires a .toml config file:
     hostname = "localhost"
     port     = 5432
 
+The embedded sqlite3 driver can be used in place of Postgres,
+in which case headers are synced by the watcher itself:
+
+  [database]
+    driver = "sqlite3"
+    path   = "./watcher.db"
+
   [client]
     rpcPath  = "/Users/user/Library/Ethereum/geth.ipc"
 
+  [etherscan]
+    apiKey = "YourApiKeyToken"
+    requestsPerSecond = 5
+    retries = 3
+
   [contract]
     network  = ""
+    explorerURL = ""
+    multicall = "0x5BA1e12693Dc8F9c48aAD8770482f4739bEeD696"
+    decoupledMethods = true
+    concurrentMethods = true
+    abiProviders = [
+        "directory:/path/to/abis",
+        "artifacts:/path/to/project",
+        "database",
+        "sourcify",
+        "etherscan",
+        "standards"
+    ]
     addresses  = [
         "contractAddress1",
         "contractAddress2"
     ]
+    [contract.naming]
+        events  = "{alias}.{event}_events"
+        methods = "{alias}.{method}_methods"
     [contract.contractAddress1]
-        abi    = 'ABI for contract 1'
+        artifact = "Token"
         startingBlock = 982463
     [contract.contractAddress2]
+        alias  = "token"
         abi    = 'ABI for contract 2'
         events = [
             "event1",
@@ -76,6 +112,29 @@ Requires a .toml config file:
 		]
         startingBlock = 4448566
         piping = true
+        partitionSize = 1000000
+        changesOnly = true
+        touchedOnly = true
+        fullPollInterval = 10000
+        [contract.contractAddress2.argValues]
+            uint256 = ["1", "2"]
+        [contract.contractAddress2.argSources]
+            allowance = ["Approval.owner", "Approval.spender"]
+        [contract.contractAddress2.pipes]
+            owner = ["balanceOf"]
+        [contract.contractAddress2.staticArgs]
+            balanceOf = { csv = "./treasury.csv" }
+            tokenURI = { values = ["1", "2"] }
+            allowance = { sql = "SELECT owner, spender FROM approvals" }
+        [contract.contractAddress2.schedules]
+            totalSupply = { blocks = 100 }
+            owner = { seconds = 86400 }
+            getVotes = { at = [4450000, 4460000] }
+            decimals = { head = true }
+        [contract.contractAddress2.indexes]
+            event1 = ["arg3"]
+        [contract.contractAddress2.unindexed]
+            event2 = ["*"]
 `,
 	Run: func(cmd *cobra.Command, args []string) {
 		subCommand = cmd.CalledAs()
@@ -88,29 +147,108 @@ func watch() {
 	ticker := time.NewTicker(5 * time.Second)
 	defer ticker.Stop()
 
-	client, node := getClientAndNode()
-
-	db, err := postgres.NewDB(databaseConfig, node)
-	if err != nil {
-		logWithCommand.Fatal(err)
-	}
+	rawRPCClient, ethClient, node := getClientAndNode()
+	db := getStorage(node)
 
 	con := config.ContractConfig{}
 	con.PrepConfig()
-	transformer := st.NewTransformer(con, client, db, timeout)
+	con.ChainID = getChainID(ethClient, con.Network)
+	transformer := st.NewTransformer(con, ethClient, rawRPCClient, db, timeout)
 
 	if err := transformer.Init(); err != nil {
 		logWithCommand.Fatal(fmt.Sprintf("Failed to initialize transformer, err: %v ", err))
 	}
 
+	// Only the Postgres database is kept synced by the eth-header-sync service
+	var headerFetcher core.Fetcher
+	if db.Driver != storage.Postgres {
+		headerFetcher = fetcher.NewFetcher(ethClient, client.NewRPCClient(rawRPCClient, ipc), node)
+	}
+	headerRepository := storage.NewHeaderRepository(db)
+
+	// Methods polled concurrently with the events catch up on their own ticker
+	if con.ConcurrentMethods {
+		go pollMethods(transformer)
+	}
+
 	for range ticker.C {
-		err = transformer.Execute()
+		if headerFetcher != nil {
+			_, err := history.PopulateMissingHeaders(headerFetcher, headerRepository, firstStartingBlock(con))
+			if err != nil {
+				logWithCommand.Error("Error syncing headers: ", err)
+				continue
+			}
+		}
+		err := transformer.Execute()
 		if err != nil {
 			logWithCommand.Error("Execution error for transformer: ", transformer.GetConfig().Name, err)
 		}
 	}
 }
 
+func pollMethods(transformer *st.Transformer) {
+	ticker := time.NewTicker(5 * time.Second)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		err := transformer.PollMethods()
+		if err != nil {
+			logWithCommand.Error("Method polling error for transformer: ", transformer.GetConfig().Name, err)
+		}
+	}
+}
+
+// The chain id reported by the node picks the explorer and Sourcify matches abis are fetched from
+// Zero is returned if the node could not report one, to fall back to the configured network
+func getChainID(ethClient *ethclient.Client, network string) int64 {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	chainID, err := ethClient.ChainID(ctx)
+	if err != nil {
+		logWithCommand.Warnf("could not get the chain id from the node, using the network `%s`: %s", network, err.Error())
+		return 0
+	}
+	if network != "" && abi.ChainID(network) != chainID.Int64() {
+		logWithCommand.Warnf("node reports chain id %s rather than that of the network `%s`", chainID.String(), network)
+	}
+
+	return chainID.Int64()
+}
+
+func getStorage(node core.Node) *storage.DB {
+	switch databaseDriver {
+	case "", storage.Postgres.Name():
+		db, err := postgres.NewDB(databaseConfig, node)
+		if err != nil {
+			logWithCommand.Fatal(err)
+		}
+		return storage.NewPostgresDB(db)
+	case storage.SQLite.Name():
+		db, err := storage.NewSQLiteDB(databasePath, node)
+		if err != nil {
+			logWithCommand.Fatal(err)
+		}
+		return db
+	default:
+		logWithCommand.Fatalf("unrecognized database driver %s", databaseDriver)
+	}
+	return nil
+}
+
+// Headers are synced from the earliest configured starting block
+func firstStartingBlock(con config.ContractConfig) int64 {
+	first := int64(-1)
+	for _, start := range con.StartingBlocks {
+		if first < 0 || start < first {
+			first = start
+		}
+	}
+	if first < 0 {
+		return 0
+	}
+	return first
+}
+
 func init() {
 	rootCmd.AddCommand(watchCmd)
 }
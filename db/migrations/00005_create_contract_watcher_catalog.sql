@@ -0,0 +1,76 @@
+-- +goose Up
+CREATE SCHEMA contract_watcher;
+
+CREATE TABLE contract_watcher.contracts (
+  id              SERIAL PRIMARY KEY,
+  address         VARCHAR(66) NOT NULL UNIQUE,
+  name            VARCHAR,
+  alias           VARCHAR NOT NULL,
+  abi             JSONB NOT NULL,
+  starting_block  BIGINT NOT NULL,
+  event_schema    VARCHAR NOT NULL,
+  method_schema   VARCHAR NOT NULL,
+  config          JSONB,
+  updated_at      TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE TABLE contract_watcher.events (
+  id              SERIAL PRIMARY KEY,
+  contract_id     INTEGER NOT NULL REFERENCES contract_watcher.contracts (id) ON DELETE CASCADE,
+  name            VARCHAR NOT NULL,
+  identifier      VARCHAR NOT NULL,
+  signature       VARCHAR NOT NULL,
+  signature_hash  VARCHAR(66) NOT NULL,
+  anonymous       BOOLEAN NOT NULL,
+  table_schema    VARCHAR NOT NULL,
+  table_name      VARCHAR NOT NULL,
+  check_column    VARCHAR NOT NULL,
+  UNIQUE (contract_id, name)
+);
+
+CREATE TABLE contract_watcher.event_fields (
+  id              SERIAL PRIMARY KEY,
+  event_id        INTEGER NOT NULL REFERENCES contract_watcher.events (id) ON DELETE CASCADE,
+  position        INTEGER NOT NULL,
+  name            VARCHAR NOT NULL,
+  abi_type        VARCHAR NOT NULL,
+  pg_type         VARCHAR NOT NULL,
+  column_name     VARCHAR NOT NULL,
+  indexed         BOOLEAN NOT NULL,
+  UNIQUE (event_id, position)
+);
+
+CREATE TABLE contract_watcher.methods (
+  id              SERIAL PRIMARY KEY,
+  contract_id     INTEGER NOT NULL REFERENCES contract_watcher.contracts (id) ON DELETE CASCADE,
+  name            VARCHAR NOT NULL,
+  identifier      VARCHAR NOT NULL,
+  signature       VARCHAR NOT NULL,
+  selector        VARCHAR(10) NOT NULL,
+  return_type     VARCHAR NOT NULL,
+  return_pg_type  VARCHAR NOT NULL,
+  table_schema    VARCHAR NOT NULL,
+  table_name      VARCHAR NOT NULL,
+  check_column    VARCHAR NOT NULL,
+  UNIQUE (contract_id, name)
+);
+
+CREATE TABLE contract_watcher.method_args (
+  id              SERIAL PRIMARY KEY,
+  method_id       INTEGER NOT NULL REFERENCES contract_watcher.methods (id) ON DELETE CASCADE,
+  position        INTEGER NOT NULL,
+  name            VARCHAR NOT NULL,
+  abi_type        VARCHAR NOT NULL,
+  pg_type         VARCHAR NOT NULL,
+  column_name     VARCHAR NOT NULL,
+  UNIQUE (method_id, position)
+);
+
+COMMENT ON TABLE contract_watcher.contracts IS E'@name WatchedContract';
+COMMENT ON TABLE contract_watcher.events IS E'@name WatchedEvent';
+COMMENT ON TABLE contract_watcher.event_fields IS E'@name WatchedEventField';
+COMMENT ON TABLE contract_watcher.methods IS E'@name WatchedMethod';
+COMMENT ON TABLE contract_watcher.method_args IS E'@name WatchedMethodArg';
+
+-- +goose Down
+DROP SCHEMA contract_watcher CASCADE;
@@ -0,0 +1,16 @@
+-- +goose Up
+CREATE TABLE contract_watcher.method_outputs (
+  id              SERIAL PRIMARY KEY,
+  method_id       INTEGER NOT NULL REFERENCES contract_watcher.methods (id) ON DELETE CASCADE,
+  position        INTEGER NOT NULL,
+  name            VARCHAR NOT NULL,
+  abi_type        VARCHAR NOT NULL,
+  pg_type         VARCHAR NOT NULL,
+  column_name     VARCHAR NOT NULL,
+  UNIQUE (method_id, position)
+);
+
+COMMENT ON TABLE contract_watcher.method_outputs IS E'@name WatchedMethodOutput';
+
+-- +goose Down
+DROP TABLE contract_watcher.method_outputs;
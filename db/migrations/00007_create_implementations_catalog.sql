@@ -0,0 +1,14 @@
+-- +goose Up
+CREATE TABLE contract_watcher.implementations (
+  id              SERIAL PRIMARY KEY,
+  contract_id     INTEGER NOT NULL REFERENCES contract_watcher.contracts (id) ON DELETE CASCADE,
+  address         VARCHAR(66) NOT NULL,
+  block_number    BIGINT NOT NULL,
+  abi             JSONB NOT NULL,
+  UNIQUE (contract_id, block_number)
+);
+
+COMMENT ON TABLE contract_watcher.implementations IS E'@name ProxyImplementation';
+
+-- +goose Down
+DROP TABLE contract_watcher.implementations;
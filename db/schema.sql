@@ -16,10 +16,320 @@ SET xmloption = content;
 SET client_min_messages = warning;
 SET row_security = off;
 
+--
+-- Name: contract_watcher; Type: SCHEMA; Schema: -; Owner: -
+--
+
+CREATE SCHEMA contract_watcher;
+
+
 SET default_tablespace = '';
 
 SET default_table_access_method = heap;
 
+--
+-- Name: contracts; Type: TABLE; Schema: contract_watcher; Owner: -
+--
+
+CREATE TABLE contract_watcher.contracts (
+    id integer NOT NULL,
+    address character varying(66) NOT NULL,
+    name character varying,
+    alias character varying NOT NULL,
+    abi jsonb NOT NULL,
+    starting_block bigint NOT NULL,
+    event_schema character varying NOT NULL,
+    method_schema character varying NOT NULL,
+    config jsonb,
+    updated_at timestamp without time zone DEFAULT now() NOT NULL
+);
+
+
+--
+-- Name: TABLE contracts; Type: COMMENT; Schema: contract_watcher; Owner: -
+--
+
+COMMENT ON TABLE contract_watcher.contracts IS '@name WatchedContract';
+
+
+--
+-- Name: contracts_id_seq; Type: SEQUENCE; Schema: contract_watcher; Owner: -
+--
+
+CREATE SEQUENCE contract_watcher.contracts_id_seq
+    AS integer
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+
+--
+-- Name: contracts_id_seq; Type: SEQUENCE OWNED BY; Schema: contract_watcher; Owner: -
+--
+
+ALTER SEQUENCE contract_watcher.contracts_id_seq OWNED BY contract_watcher.contracts.id;
+
+
+--
+-- Name: event_fields; Type: TABLE; Schema: contract_watcher; Owner: -
+--
+
+CREATE TABLE contract_watcher.event_fields (
+    id integer NOT NULL,
+    event_id integer NOT NULL,
+    "position" integer NOT NULL,
+    name character varying NOT NULL,
+    abi_type character varying NOT NULL,
+    pg_type character varying NOT NULL,
+    column_name character varying NOT NULL,
+    indexed boolean NOT NULL
+);
+
+
+--
+-- Name: TABLE event_fields; Type: COMMENT; Schema: contract_watcher; Owner: -
+--
+
+COMMENT ON TABLE contract_watcher.event_fields IS '@name WatchedEventField';
+
+
+--
+-- Name: event_fields_id_seq; Type: SEQUENCE; Schema: contract_watcher; Owner: -
+--
+
+CREATE SEQUENCE contract_watcher.event_fields_id_seq
+    AS integer
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+
+--
+-- Name: event_fields_id_seq; Type: SEQUENCE OWNED BY; Schema: contract_watcher; Owner: -
+--
+
+ALTER SEQUENCE contract_watcher.event_fields_id_seq OWNED BY contract_watcher.event_fields.id;
+
+
+--
+-- Name: events; Type: TABLE; Schema: contract_watcher; Owner: -
+--
+
+CREATE TABLE contract_watcher.events (
+    id integer NOT NULL,
+    contract_id integer NOT NULL,
+    name character varying NOT NULL,
+    identifier character varying NOT NULL,
+    signature character varying NOT NULL,
+    signature_hash character varying(66) NOT NULL,
+    anonymous boolean NOT NULL,
+    table_schema character varying NOT NULL,
+    table_name character varying NOT NULL,
+    check_column character varying NOT NULL
+);
+
+
+--
+-- Name: TABLE events; Type: COMMENT; Schema: contract_watcher; Owner: -
+--
+
+COMMENT ON TABLE contract_watcher.events IS '@name WatchedEvent';
+
+
+--
+-- Name: events_id_seq; Type: SEQUENCE; Schema: contract_watcher; Owner: -
+--
+
+CREATE SEQUENCE contract_watcher.events_id_seq
+    AS integer
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+
+--
+-- Name: events_id_seq; Type: SEQUENCE OWNED BY; Schema: contract_watcher; Owner: -
+--
+
+ALTER SEQUENCE contract_watcher.events_id_seq OWNED BY contract_watcher.events.id;
+
+
+--
+-- Name: implementations; Type: TABLE; Schema: contract_watcher; Owner: -
+--
+
+CREATE TABLE contract_watcher.implementations (
+    id integer NOT NULL,
+    contract_id integer NOT NULL,
+    address character varying(66) NOT NULL,
+    block_number bigint NOT NULL,
+    abi jsonb NOT NULL
+);
+
+
+--
+-- Name: TABLE implementations; Type: COMMENT; Schema: contract_watcher; Owner: -
+--
+
+COMMENT ON TABLE contract_watcher.implementations IS '@name ProxyImplementation';
+
+
+--
+-- Name: implementations_id_seq; Type: SEQUENCE; Schema: contract_watcher; Owner: -
+--
+
+CREATE SEQUENCE contract_watcher.implementations_id_seq
+    AS integer
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+
+--
+-- Name: implementations_id_seq; Type: SEQUENCE OWNED BY; Schema: contract_watcher; Owner: -
+--
+
+ALTER SEQUENCE contract_watcher.implementations_id_seq OWNED BY contract_watcher.implementations.id;
+
+
+--
+-- Name: method_args; Type: TABLE; Schema: contract_watcher; Owner: -
+--
+
+CREATE TABLE contract_watcher.method_args (
+    id integer NOT NULL,
+    method_id integer NOT NULL,
+    "position" integer NOT NULL,
+    name character varying NOT NULL,
+    abi_type character varying NOT NULL,
+    pg_type character varying NOT NULL,
+    column_name character varying NOT NULL
+);
+
+
+--
+-- Name: TABLE method_args; Type: COMMENT; Schema: contract_watcher; Owner: -
+--
+
+COMMENT ON TABLE contract_watcher.method_args IS '@name WatchedMethodArg';
+
+
+--
+-- Name: method_args_id_seq; Type: SEQUENCE; Schema: contract_watcher; Owner: -
+--
+
+CREATE SEQUENCE contract_watcher.method_args_id_seq
+    AS integer
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+
+--
+-- Name: method_args_id_seq; Type: SEQUENCE OWNED BY; Schema: contract_watcher; Owner: -
+--
+
+ALTER SEQUENCE contract_watcher.method_args_id_seq OWNED BY contract_watcher.method_args.id;
+
+
+--
+-- Name: method_outputs; Type: TABLE; Schema: contract_watcher; Owner: -
+--
+
+CREATE TABLE contract_watcher.method_outputs (
+    id integer NOT NULL,
+    method_id integer NOT NULL,
+    "position" integer NOT NULL,
+    name character varying NOT NULL,
+    abi_type character varying NOT NULL,
+    pg_type character varying NOT NULL,
+    column_name character varying NOT NULL
+);
+
+
+--
+-- Name: TABLE method_outputs; Type: COMMENT; Schema: contract_watcher; Owner: -
+--
+
+COMMENT ON TABLE contract_watcher.method_outputs IS '@name WatchedMethodOutput';
+
+
+--
+-- Name: method_outputs_id_seq; Type: SEQUENCE; Schema: contract_watcher; Owner: -
+--
+
+CREATE SEQUENCE contract_watcher.method_outputs_id_seq
+    AS integer
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+
+--
+-- Name: method_outputs_id_seq; Type: SEQUENCE OWNED BY; Schema: contract_watcher; Owner: -
+--
+
+ALTER SEQUENCE contract_watcher.method_outputs_id_seq OWNED BY contract_watcher.method_outputs.id;
+
+
+--
+-- Name: methods; Type: TABLE; Schema: contract_watcher; Owner: -
+--
+
+CREATE TABLE contract_watcher.methods (
+    id integer NOT NULL,
+    contract_id integer NOT NULL,
+    name character varying NOT NULL,
+    identifier character varying NOT NULL,
+    signature character varying NOT NULL,
+    selector character varying(10) NOT NULL,
+    return_type character varying NOT NULL,
+    return_pg_type character varying NOT NULL,
+    table_schema character varying NOT NULL,
+    table_name character varying NOT NULL,
+    check_column character varying NOT NULL
+);
+
+
+--
+-- Name: TABLE methods; Type: COMMENT; Schema: contract_watcher; Owner: -
+--
+
+COMMENT ON TABLE contract_watcher.methods IS '@name WatchedMethod';
+
+
+--
+-- Name: methods_id_seq; Type: SEQUENCE; Schema: contract_watcher; Owner: -
+--
+
+CREATE SEQUENCE contract_watcher.methods_id_seq
+    AS integer
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+
+--
+-- Name: methods_id_seq; Type: SEQUENCE OWNED BY; Schema: contract_watcher; Owner: -
+--
+
+ALTER SEQUENCE contract_watcher.methods_id_seq OWNED BY contract_watcher.methods.id;
+
+
 --
 -- Name: checked_headers; Type: TABLE; Schema: public; Owner: -
 --
@@ -173,6 +483,55 @@ CREATE SEQUENCE public.nodes_id_seq
 ALTER SEQUENCE public.nodes_id_seq OWNED BY public.nodes.id;
 
 
+--
+-- Name: contracts id; Type: DEFAULT; Schema: contract_watcher; Owner: -
+--
+
+ALTER TABLE ONLY contract_watcher.contracts ALTER COLUMN id SET DEFAULT nextval('contract_watcher.contracts_id_seq'::regclass);
+
+
+--
+-- Name: event_fields id; Type: DEFAULT; Schema: contract_watcher; Owner: -
+--
+
+ALTER TABLE ONLY contract_watcher.event_fields ALTER COLUMN id SET DEFAULT nextval('contract_watcher.event_fields_id_seq'::regclass);
+
+
+--
+-- Name: events id; Type: DEFAULT; Schema: contract_watcher; Owner: -
+--
+
+ALTER TABLE ONLY contract_watcher.events ALTER COLUMN id SET DEFAULT nextval('contract_watcher.events_id_seq'::regclass);
+
+
+--
+-- Name: implementations id; Type: DEFAULT; Schema: contract_watcher; Owner: -
+--
+
+ALTER TABLE ONLY contract_watcher.implementations ALTER COLUMN id SET DEFAULT nextval('contract_watcher.implementations_id_seq'::regclass);
+
+
+--
+-- Name: method_args id; Type: DEFAULT; Schema: contract_watcher; Owner: -
+--
+
+ALTER TABLE ONLY contract_watcher.method_args ALTER COLUMN id SET DEFAULT nextval('contract_watcher.method_args_id_seq'::regclass);
+
+
+--
+-- Name: method_outputs id; Type: DEFAULT; Schema: contract_watcher; Owner: -
+--
+
+ALTER TABLE ONLY contract_watcher.method_outputs ALTER COLUMN id SET DEFAULT nextval('contract_watcher.method_outputs_id_seq'::regclass);
+
+
+--
+-- Name: methods id; Type: DEFAULT; Schema: contract_watcher; Owner: -
+--
+
+ALTER TABLE ONLY contract_watcher.methods ALTER COLUMN id SET DEFAULT nextval('contract_watcher.methods_id_seq'::regclass);
+
+
 --
 -- Name: checked_headers id; Type: DEFAULT; Schema: public; Owner: -
 --
@@ -201,6 +560,118 @@ ALTER TABLE ONLY public.headers ALTER COLUMN id SET DEFAULT nextval('public.head
 ALTER TABLE ONLY public.nodes ALTER COLUMN id SET DEFAULT nextval('public.nodes_id_seq'::regclass);
 
 
+--
+-- Name: contracts contracts_address_key; Type: CONSTRAINT; Schema: contract_watcher; Owner: -
+--
+
+ALTER TABLE ONLY contract_watcher.contracts
+    ADD CONSTRAINT contracts_address_key UNIQUE (address);
+
+
+--
+-- Name: contracts contracts_pkey; Type: CONSTRAINT; Schema: contract_watcher; Owner: -
+--
+
+ALTER TABLE ONLY contract_watcher.contracts
+    ADD CONSTRAINT contracts_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: event_fields event_fields_event_id_position_key; Type: CONSTRAINT; Schema: contract_watcher; Owner: -
+--
+
+ALTER TABLE ONLY contract_watcher.event_fields
+    ADD CONSTRAINT event_fields_event_id_position_key UNIQUE (event_id, "position");
+
+
+--
+-- Name: event_fields event_fields_pkey; Type: CONSTRAINT; Schema: contract_watcher; Owner: -
+--
+
+ALTER TABLE ONLY contract_watcher.event_fields
+    ADD CONSTRAINT event_fields_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: events events_contract_id_name_key; Type: CONSTRAINT; Schema: contract_watcher; Owner: -
+--
+
+ALTER TABLE ONLY contract_watcher.events
+    ADD CONSTRAINT events_contract_id_name_key UNIQUE (contract_id, name);
+
+
+--
+-- Name: events events_pkey; Type: CONSTRAINT; Schema: contract_watcher; Owner: -
+--
+
+ALTER TABLE ONLY contract_watcher.events
+    ADD CONSTRAINT events_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: implementations implementations_contract_id_block_number_key; Type: CONSTRAINT; Schema: contract_watcher; Owner: -
+--
+
+ALTER TABLE ONLY contract_watcher.implementations
+    ADD CONSTRAINT implementations_contract_id_block_number_key UNIQUE (contract_id, block_number);
+
+
+--
+-- Name: implementations implementations_pkey; Type: CONSTRAINT; Schema: contract_watcher; Owner: -
+--
+
+ALTER TABLE ONLY contract_watcher.implementations
+    ADD CONSTRAINT implementations_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: method_args method_args_method_id_position_key; Type: CONSTRAINT; Schema: contract_watcher; Owner: -
+--
+
+ALTER TABLE ONLY contract_watcher.method_args
+    ADD CONSTRAINT method_args_method_id_position_key UNIQUE (method_id, "position");
+
+
+--
+-- Name: method_outputs method_outputs_method_id_position_key; Type: CONSTRAINT; Schema: contract_watcher; Owner: -
+--
+
+ALTER TABLE ONLY contract_watcher.method_outputs
+    ADD CONSTRAINT method_outputs_method_id_position_key UNIQUE (method_id, "position");
+
+
+--
+-- Name: method_args method_args_pkey; Type: CONSTRAINT; Schema: contract_watcher; Owner: -
+--
+
+ALTER TABLE ONLY contract_watcher.method_args
+    ADD CONSTRAINT method_args_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: method_outputs method_outputs_pkey; Type: CONSTRAINT; Schema: contract_watcher; Owner: -
+--
+
+ALTER TABLE ONLY contract_watcher.method_outputs
+    ADD CONSTRAINT method_outputs_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: methods methods_contract_id_name_key; Type: CONSTRAINT; Schema: contract_watcher; Owner: -
+--
+
+ALTER TABLE ONLY contract_watcher.methods
+    ADD CONSTRAINT methods_contract_id_name_key UNIQUE (contract_id, name);
+
+
+--
+-- Name: methods methods_pkey; Type: CONSTRAINT; Schema: contract_watcher; Owner: -
+--
+
+ALTER TABLE ONLY contract_watcher.methods
+    ADD CONSTRAINT methods_pkey PRIMARY KEY (id);
+
+
 --
 -- Name: checked_headers checked_headers_header_id_key; Type: CONSTRAINT; Schema: public; Owner: -
 --
@@ -271,6 +742,54 @@ CREATE INDEX headers_block_number ON public.headers USING btree (block_number);
 CREATE INDEX headers_block_timestamp ON public.headers USING btree (block_timestamp);
 
 
+--
+-- Name: event_fields event_fields_event_id_fkey; Type: FK CONSTRAINT; Schema: contract_watcher; Owner: -
+--
+
+ALTER TABLE ONLY contract_watcher.event_fields
+    ADD CONSTRAINT event_fields_event_id_fkey FOREIGN KEY (event_id) REFERENCES contract_watcher.events(id) ON DELETE CASCADE;
+
+
+--
+-- Name: events events_contract_id_fkey; Type: FK CONSTRAINT; Schema: contract_watcher; Owner: -
+--
+
+ALTER TABLE ONLY contract_watcher.events
+    ADD CONSTRAINT events_contract_id_fkey FOREIGN KEY (contract_id) REFERENCES contract_watcher.contracts(id) ON DELETE CASCADE;
+
+
+--
+-- Name: implementations implementations_contract_id_fkey; Type: FK CONSTRAINT; Schema: contract_watcher; Owner: -
+--
+
+ALTER TABLE ONLY contract_watcher.implementations
+    ADD CONSTRAINT implementations_contract_id_fkey FOREIGN KEY (contract_id) REFERENCES contract_watcher.contracts(id) ON DELETE CASCADE;
+
+
+--
+-- Name: method_args method_args_method_id_fkey; Type: FK CONSTRAINT; Schema: contract_watcher; Owner: -
+--
+
+ALTER TABLE ONLY contract_watcher.method_args
+    ADD CONSTRAINT method_args_method_id_fkey FOREIGN KEY (method_id) REFERENCES contract_watcher.methods(id) ON DELETE CASCADE;
+
+
+--
+-- Name: method_outputs method_outputs_method_id_fkey; Type: FK CONSTRAINT; Schema: contract_watcher; Owner: -
+--
+
+ALTER TABLE ONLY contract_watcher.method_outputs
+    ADD CONSTRAINT method_outputs_method_id_fkey FOREIGN KEY (method_id) REFERENCES contract_watcher.methods(id) ON DELETE CASCADE;
+
+
+--
+-- Name: methods methods_contract_id_fkey; Type: FK CONSTRAINT; Schema: contract_watcher; Owner: -
+--
+
+ALTER TABLE ONLY contract_watcher.methods
+    ADD CONSTRAINT methods_contract_id_fkey FOREIGN KEY (contract_id) REFERENCES contract_watcher.contracts(id) ON DELETE CASCADE;
+
+
 --
 -- Name: checked_headers checked_headers_header_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
 --
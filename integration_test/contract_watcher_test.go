@@ -25,22 +25,21 @@ import (
 	. "github.com/onsi/gomega"
 
 	"github.com/vulcanize/eth-header-sync/pkg/core"
-	"github.com/vulcanize/eth-header-sync/pkg/postgres"
-	"github.com/vulcanize/eth-header-sync/pkg/repository"
 
 	"github.com/vulcanize/eth-contract-watcher/pkg/config"
 	"github.com/vulcanize/eth-contract-watcher/pkg/constants"
 	"github.com/vulcanize/eth-contract-watcher/pkg/helpers/test_helpers"
 	"github.com/vulcanize/eth-contract-watcher/pkg/helpers/test_helpers/mocks"
+	"github.com/vulcanize/eth-contract-watcher/pkg/storage"
 	"github.com/vulcanize/eth-contract-watcher/pkg/transformer"
 )
 
 var _ = Describe("contractWatcher headerSync transformer", func() {
-	var db *postgres.DB
+	var db *storage.DB
 	var err error
 	var client core.EthClient
 	var headerFetcher core.Fetcher
-	var headerRepository repository.HeaderRepository
+	var headerRepository storage.HeaderRepository
 	var headerID int64
 	var ensAddr = strings.ToLower(constants.EnsContractAddress)   // 0x314159265dd8dbb310642f98f50c066173c1259b
 	var tusdAddr = strings.ToLower(constants.TusdContractAddress) // 0x8dd5fbce2f6a956c3022ba3663759011dd51e73e
@@ -48,7 +47,7 @@ var _ = Describe("contractWatcher headerSync transformer", func() {
 	BeforeEach(func() {
 		db, client = test_helpers.SetupDBandClient()
 		headerFetcher = test_helpers.SetupHeaderFetcher()
-		headerRepository = repository.NewHeaderRepository(db)
+		headerRepository = storage.NewHeaderRepository(db)
 	})
 
 	AfterEach(func() {
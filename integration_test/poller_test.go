@@ -29,14 +29,14 @@ import (
 	"github.com/vulcanize/eth-contract-watcher/pkg/contract"
 	"github.com/vulcanize/eth-contract-watcher/pkg/helpers/test_helpers"
 	"github.com/vulcanize/eth-contract-watcher/pkg/poller"
+	"github.com/vulcanize/eth-contract-watcher/pkg/storage"
 	"github.com/vulcanize/eth-contract-watcher/pkg/types"
-	"github.com/vulcanize/eth-header-sync/pkg/postgres"
 )
 
 var _ = Describe("Poller", func() {
 	var contractPoller poller.Poller
 	var con *contract.Contract
-	var db *postgres.DB
+	var db *storage.DB
 	var client core.EthClient
 
 	AfterEach(func() {
@@ -17,67 +17,18 @@
 package abi
 
 import (
-	"encoding/json"
 	"errors"
-	"fmt"
 	"io/ioutil"
-	"net/http"
 	"strings"
-	"time"
 
 	"github.com/ethereum/go-ethereum/accounts/abi"
 )
 
 var (
-	ErrInvalidAbiFile   = errors.New("invalid abi")
-	ErrMissingAbiFile   = errors.New("missing abi")
-	ErrAPIRequestFailed = errors.New("etherscan api request failed")
+	ErrInvalidAbiFile = errors.New("invalid abi")
+	ErrMissingAbiFile = errors.New("missing abi")
 )
 
-type Response struct {
-	Status  string
-	Message string
-	Result  string
-}
-
-type EtherScanAPI struct {
-	client *http.Client
-	url    string
-}
-
-func NewEtherScanClient(url string) *EtherScanAPI {
-	return &EtherScanAPI{
-		client: &http.Client{Timeout: 10 * time.Second},
-		url:    url,
-	}
-}
-
-func GenURL(network string) string {
-	switch network {
-	case "ropsten":
-		return "https://ropsten.etherscan.io"
-	case "kovan":
-		return "https://kovan.etherscan.io"
-	case "rinkeby":
-		return "https://rinkeby.etherscan.io"
-	default:
-		return "https://api.etherscan.io"
-	}
-}
-
-//https://api.etherscan.io/api?module=contract&action=getabi&address=%s
-func (e *EtherScanAPI) GetAbi(contractHash string) (string, error) {
-	target := new(Response)
-	request := fmt.Sprintf("%s/api?module=contract&action=getabi&address=%s", e.url, contractHash)
-	r, err := e.client.Get(request)
-	if err != nil {
-		return "", ErrAPIRequestFailed
-	}
-	defer r.Body.Close()
-	err = json.NewDecoder(r.Body).Decode(&target)
-	return target.Result, err
-}
-
 func ParseAbiFile(abiFilePath string) (abi.ABI, error) {
 	abiString, err := ReadAbiFile(abiFilePath)
 	if err != nil {
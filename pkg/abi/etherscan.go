@@ -0,0 +1,196 @@
+// VulcanizeDB
+// Copyright © 2019 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package abi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	ErrAPIRequestFailed = errors.New("etherscan api request failed")
+	ErrRateLimited      = errors.New("etherscan rate limit reached")
+	// ErrContractNotVerified is returned for contracts without verified source, which etherscan has no abi for
+	ErrContractNotVerified = fmt.Errorf("%w: contract source code not verified on etherscan", ErrAbiNotFound)
+)
+
+// Defaults for the EtherScanOptions left unset
+const (
+	defaultRequestsPerSecond        = 5   // Etherscan's limit for api keys on its free plan
+	defaultKeylessRequestsPerSecond = 0.2 // Etherscan's limit for requests made without an api key
+	defaultRetries                  = 3
+	defaultBackoff                  = time.Second
+)
+
+type Response struct {
+	Status  string
+	Message string
+	Result  string
+}
+
+// EtherScanError is an error status returned by etherscan's api, such as an invalid api key
+type EtherScanError struct {
+	Message string
+	Result  string
+}
+
+func (e *EtherScanError) Error() string {
+	return fmt.Sprintf("etherscan error: %s: %s", e.Message, e.Result)
+}
+
+// EtherScanOptions configure the etherscan client
+type EtherScanOptions struct {
+	// Api key sent with each request, required for all but the most limited use of the api
+	APIKey string
+	// Requests the client makes per second at most; zero uses etherscan's limit for the api key, or for no key
+	RequestsPerSecond float64
+	// Times a rate limited or failed request is retried; zero uses the default of three, a negative number disables retries
+	Retries int
+	// Delay before the first retry, doubled for each retry after it; zero uses the default of a second
+	Backoff time.Duration
+}
+
+type EtherScanAPI struct {
+	client  *http.Client
+	url     string
+	apiKey  string
+	limiter *rateLimiter
+	retries int
+	backoff time.Duration
+}
+
+// NewEtherScanClient returns a client of etherscan's api at the url, using the default options
+func NewEtherScanClient(url string) *EtherScanAPI {
+	return NewEtherScanClientWithOptions(url, EtherScanOptions{})
+}
+
+// NewEtherScanClientWithOptions returns a client of etherscan's api at the url
+func NewEtherScanClientWithOptions(url string, options EtherScanOptions) *EtherScanAPI {
+	requestsPerSecond := options.RequestsPerSecond
+	if requestsPerSecond <= 0 {
+		requestsPerSecond = defaultKeylessRequestsPerSecond
+		if options.APIKey != "" {
+			requestsPerSecond = defaultRequestsPerSecond
+		}
+	}
+	retries := options.Retries
+	if retries == 0 {
+		retries = defaultRetries
+	}
+	backoff := options.Backoff
+	if backoff <= 0 {
+		backoff = defaultBackoff
+	}
+
+	return &EtherScanAPI{
+		client:  &http.Client{Timeout: 10 * time.Second},
+		url:     url,
+		apiKey:  options.APIKey,
+		limiter: &rateLimiter{interval: time.Duration(float64(time.Second) / requestsPerSecond)},
+		retries: retries,
+		backoff: backoff,
+	}
+}
+
+// GetAbi fetches the abi of a verified contract, retrying with backoff when rate limited or the request fails
+// https://api.etherscan.io/api?module=contract&action=getabi&address=%s&apikey=%s
+func (e *EtherScanAPI) GetAbi(contractHash string) (string, error) {
+	backoff := e.backoff
+	for attempt := 0; ; attempt++ {
+		abiStr, err := e.getAbi(contractHash)
+		if err == nil || !retryable(err) || attempt >= e.retries {
+			return abiStr, err
+		}
+		log.Debugf("retrying etherscan request for the abi of %s in %s: %s", contractHash, backoff, err.Error())
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+func (e *EtherScanAPI) getAbi(contractHash string) (string, error) {
+	params := url.Values{}
+	params.Set("module", "contract")
+	params.Set("action", "getabi")
+	params.Set("address", contractHash)
+	if e.apiKey != "" {
+		params.Set("apikey", e.apiKey)
+	}
+	e.limiter.wait()
+	r, err := e.client.Get(e.url + "/api?" + params.Encode())
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", ErrAPIRequestFailed, err.Error())
+	}
+	defer r.Body.Close()
+	switch {
+	case r.StatusCode == http.StatusTooManyRequests:
+		return "", ErrRateLimited
+	case r.StatusCode >= http.StatusInternalServerError:
+		return "", fmt.Errorf("%w: %s", ErrAPIRequestFailed, r.Status)
+	case r.StatusCode != http.StatusOK:
+		return "", &EtherScanError{Message: r.Status}
+	}
+	target := new(Response)
+	err = json.NewDecoder(r.Body).Decode(target)
+	if err != nil {
+		return "", fmt.Errorf("error decoding etherscan response: %s", err.Error())
+	}
+	if target.Status != "1" {
+		result := strings.ToLower(target.Result)
+		switch {
+		case strings.Contains(result, "rate limit"):
+			return "", ErrRateLimited
+		case strings.Contains(result, "not verified"):
+			return "", ErrContractNotVerified
+		}
+		return "", &EtherScanError{Message: target.Message, Result: target.Result}
+	}
+
+	return target.Result, nil
+}
+
+// Requests which were rate limited or failed in transit may succeed when retried
+func retryable(err error) bool {
+	return errors.Is(err, ErrRateLimited) || errors.Is(err, ErrAPIRequestFailed)
+}
+
+// rateLimiter spaces out the requests made through it by at least its interval
+type rateLimiter struct {
+	mutex    sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+// wait blocks until the next request may be made
+func (l *rateLimiter) wait() {
+	l.mutex.Lock()
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	delay := l.next.Sub(now)
+	l.next = l.next.Add(l.interval)
+	l.mutex.Unlock()
+	time.Sleep(delay)
+}
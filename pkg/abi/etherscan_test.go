@@ -0,0 +1,130 @@
+// VulcanizeDB
+// Copyright © 2019 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package abi_test
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+
+	a "github.com/vulcanize/eth-contract-watcher/pkg/abi"
+)
+
+var _ = Describe("Etherscan client", func() {
+	const (
+		address  = "0xd26114cd6EE289AccF82350c8d8487fedB8A0C07"
+		tokenAbi = `[{"constant":true,"inputs":[],"name":"totalSupply","outputs":[{"name":"","type":"uint256"}],"payable":false,"type":"function"}]`
+		query    = "module=contract&action=getabi&address=" + address + "&apikey=key"
+	)
+	var (
+		server *ghttp.Server
+		client *a.EtherScanAPI
+	)
+
+	respond := func(status, message, result string) http.HandlerFunc {
+		return ghttp.CombineHandlers(
+			ghttp.VerifyRequest("GET", "/api", query),
+			ghttp.RespondWithJSONEncoded(http.StatusOK, a.Response{Status: status, Message: message, Result: result}),
+		)
+	}
+
+	BeforeEach(func() {
+		server = ghttp.NewServer()
+		client = a.NewEtherScanClientWithOptions(server.URL(), a.EtherScanOptions{
+			APIKey:            "key",
+			RequestsPerSecond: 1000,
+			Retries:           2,
+			Backoff:           time.Millisecond,
+		})
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("sends the api key and returns the abi", func() {
+		server.AppendHandlers(respond("1", "OK", tokenAbi))
+
+		abiStr, err := client.GetAbi(address)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(abiStr).To(Equal(tokenAbi))
+	})
+
+	It("returns a not found error for contracts without verified source", func() {
+		server.AppendHandlers(respond("0", "NOTOK", "Contract source code not verified"))
+
+		_, err := client.GetAbi(address)
+
+		Expect(err).To(Equal(a.ErrContractNotVerified))
+		Expect(errors.Is(err, a.ErrAbiNotFound)).To(BeTrue())
+		Expect(server.ReceivedRequests()).To(HaveLen(1))
+	})
+
+	It("retries rate limited and failed requests", func() {
+		server.AppendHandlers(
+			respond("0", "NOTOK", "Max rate limit reached"),
+			ghttp.RespondWith(http.StatusServiceUnavailable, ""),
+			respond("1", "OK", tokenAbi),
+		)
+
+		abiStr, err := client.GetAbi(address)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(abiStr).To(Equal(tokenAbi))
+		Expect(server.ReceivedRequests()).To(HaveLen(3))
+	})
+
+	It("gives up once its retries are exhausted", func() {
+		server.AppendHandlers(
+			ghttp.RespondWith(http.StatusTooManyRequests, ""),
+			respond("0", "NOTOK", "Max rate limit reached"),
+			respond("0", "NOTOK", "Max rate limit reached"),
+		)
+
+		_, err := client.GetAbi(address)
+
+		Expect(err).To(Equal(a.ErrRateLimited))
+		Expect(server.ReceivedRequests()).To(HaveLen(3))
+	})
+
+	It("returns other error statuses without retrying", func() {
+		server.AppendHandlers(respond("0", "NOTOK", "Invalid API Key"))
+
+		_, err := client.GetAbi(address)
+
+		Expect(err).To(Equal(&a.EtherScanError{Message: "NOTOK", Result: "Invalid API Key"}))
+		Expect(server.ReceivedRequests()).To(HaveLen(1))
+	})
+
+	It("limits the rate of its requests", func() {
+		client = a.NewEtherScanClientWithOptions(server.URL(), a.EtherScanOptions{APIKey: "key", RequestsPerSecond: 20})
+		server.AppendHandlers(respond("1", "OK", tokenAbi), respond("1", "OK", tokenAbi), respond("1", "OK", tokenAbi))
+
+		start := time.Now()
+		for i := 0; i < 3; i++ {
+			_, err := client.GetAbi(address)
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		Expect(time.Since(start)).To(BeNumerically(">=", 100*time.Millisecond))
+	})
+})
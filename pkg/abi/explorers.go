@@ -0,0 +1,75 @@
+// VulcanizeDB
+// Copyright © 2019 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package abi
+
+import "strings"
+
+// Explorers maps chain ids to the url of an explorer serving an Etherscan compatible api for the chain
+// Entries can be added for chains it is missing, or a contract's `explorerURL` given instead
+var Explorers = map[int64]string{
+	1:        "https://api.etherscan.io",
+	3:        "https://ropsten.etherscan.io",
+	4:        "https://rinkeby.etherscan.io",
+	5:        "https://api-goerli.etherscan.io",
+	10:       "https://api-optimistic.etherscan.io",
+	42:       "https://kovan.etherscan.io",
+	56:       "https://api.bscscan.com",
+	100:      "https://api.gnosisscan.io",
+	137:      "https://api.polygonscan.com",
+	8453:     "https://api.basescan.org",
+	17000:    "https://api-holesky.etherscan.io",
+	42161:    "https://api.arbiscan.io",
+	11155111: "https://api-sepolia.etherscan.io",
+}
+
+// Chain ids of the networks which can be named in the config
+var networks = map[string]int64{
+	"mainnet":  1,
+	"ropsten":  3,
+	"rinkeby":  4,
+	"goerli":   5,
+	"optimism": 10,
+	"kovan":    42,
+	"bsc":      56,
+	"gnosis":   100,
+	"polygon":  137,
+	"base":     8453,
+	"holesky":  17000,
+	"arbitrum": 42161,
+	"sepolia":  11155111,
+}
+
+// ChainID returns the id of the named network; an empty or unknown name is mainnet
+func ChainID(network string) int64 {
+	if chainID, ok := networks[strings.ToLower(network)]; ok {
+		return chainID
+	}
+
+	return 1
+}
+
+// ExplorerURL returns the url of the explorer for the chain, and whether the chain has one
+func ExplorerURL(chainID int64) (string, bool) {
+	url, ok := Explorers[chainID]
+	return url, ok
+}
+
+// GenURL returns the url of the explorer for the named network
+func GenURL(network string) string {
+	url, _ := ExplorerURL(ChainID(network))
+	return url
+}
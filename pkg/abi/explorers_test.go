@@ -0,0 +1,51 @@
+// VulcanizeDB
+// Copyright © 2019 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package abi_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	a "github.com/vulcanize/eth-contract-watcher/pkg/abi"
+)
+
+var _ = Describe("Explorers", func() {
+	It("resolves explorers by chain id", func() {
+		url, ok := a.ExplorerURL(11155111)
+		Expect(ok).To(BeTrue())
+		Expect(url).To(Equal("https://api-sepolia.etherscan.io"))
+
+		_, ok = a.ExplorerURL(31337)
+		Expect(ok).To(BeFalse())
+	})
+
+	It("resolves the chain ids of named networks, defaulting to mainnet", func() {
+		Expect(a.ChainID("Goerli")).To(Equal(int64(5)))
+		Expect(a.ChainID("arbitrum")).To(Equal(int64(42161)))
+		Expect(a.ChainID("")).To(Equal(int64(1)))
+		Expect(a.GenURL("sepolia")).To(Equal("https://api-sepolia.etherscan.io"))
+	})
+
+	It("can have explorers added for other chains", func() {
+		a.Explorers[31337] = "http://localhost:4000"
+		defer delete(a.Explorers, 31337)
+
+		url, ok := a.ExplorerURL(31337)
+		Expect(ok).To(BeTrue())
+		Expect(url).To(Equal("http://localhost:4000"))
+	})
+})
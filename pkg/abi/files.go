@@ -0,0 +1,160 @@
+// VulcanizeDB
+// Copyright © 2019 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package abi
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	log "github.com/sirupsen/logrus"
+)
+
+type directoryProvider struct {
+	dir string
+}
+
+// NewDirectoryProvider returns a provider reading abis from `<address>.json` files in the directory
+// Files are named by the lowercase or checksummed address, and hold either the abi itself or an artifact containing it
+func NewDirectoryProvider(dir string) AbiProvider {
+	return &directoryProvider{dir: dir}
+}
+
+// GetAbi reads the abi from the contract's file
+func (p *directoryProvider) GetAbi(contractAddr string) (string, error) {
+	address := common.HexToAddress(contractAddr)
+	for _, name := range []string{strings.ToLower(address.Hex()), address.Hex(), contractAddr} {
+		data, err := ioutil.ReadFile(filepath.Join(p.dir, name+".json"))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		return ReadAbiJSON(data)
+	}
+
+	return "", ErrAbiNotFound
+}
+
+type artifactProvider struct {
+	dir   string
+	names map[string]string // Contract addresses to the names of their artifacts
+
+	once      sync.Once
+	scanErr   error
+	byAddress map[string]string // Abis of the artifacts which record where they are deployed, by lowercase address
+	byName    map[string]string // Abis of the artifacts, by lowercase contract name
+}
+
+// NewArtifactProvider returns a provider reading abis from the Hardhat, Truffle or Foundry build artifacts,
+// or hardhat-deploy deployments, in the directory and its subdirectories
+// An artifact is matched to a contract by the addresses recorded in it, or by name through the given map of
+// contract addresses to artifact names, since build artifacts do not record where they are deployed
+func NewArtifactProvider(dir string, names map[string]string) AbiProvider {
+	lowered := make(map[string]string, len(names))
+	for addr, name := range names {
+		lowered[strings.ToLower(addr)] = strings.ToLower(name)
+	}
+
+	return &artifactProvider{dir: dir, names: lowered}
+}
+
+// GetAbi returns the abi of the contract's artifact
+func (p *artifactProvider) GetAbi(contractAddr string) (string, error) {
+	p.once.Do(func() { p.scanErr = p.scan() })
+	if p.scanErr != nil {
+		return "", p.scanErr
+	}
+	addr := strings.ToLower(contractAddr)
+	if abiStr, ok := p.byAddress[addr]; ok {
+		return abiStr, nil
+	}
+	if name, ok := p.names[addr]; ok {
+		if abiStr, ok := p.byName[name]; ok {
+			return abiStr, nil
+		}
+	}
+
+	return "", ErrAbiNotFound
+}
+
+// Indexes every artifact in the directory
+func (p *artifactProvider) scan() error {
+	p.byAddress = make(map[string]string)
+	p.byName = make(map[string]string)
+	return filepath.Walk(p.dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		// Hardhat keeps the full compiler input and output under build-info, alongside debug files for each artifact
+		if info.IsDir() {
+			if info.Name() == "build-info" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if filepath.Ext(path) != ".json" || strings.HasSuffix(path, ".dbg.json") {
+			return nil
+		}
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		art, ok := readArtifact(data)
+		if !ok {
+			log.Debugf("skipping %s: not a contract artifact", path)
+			return nil
+		}
+		abiStr := string(artifactAbi(art))
+		// Foundry artifacts are named only by their file
+		name := art.ContractName
+		if name == "" {
+			name = strings.TrimSuffix(info.Name(), ".json")
+		}
+		p.byName[strings.ToLower(name)] = abiStr
+		if art.Address != "" {
+			p.byAddress[strings.ToLower(art.Address)] = abiStr
+		}
+		for _, network := range art.Networks {
+			if network.Address != "" {
+				p.byAddress[strings.ToLower(network.Address)] = abiStr
+			}
+		}
+		return nil
+	})
+}
+
+func readArtifact(data []byte) (artifact, bool) {
+	var art artifact
+	if err := json.Unmarshal(data, &art); err != nil {
+		return art, false
+	}
+	abiJSON := artifactAbi(art)
+	if len(abiJSON) == 0 {
+		return art, false
+	}
+	if _, err := ParseAbi(string(abiJSON)); err != nil {
+		return art, false
+	}
+
+	return art, true
+}
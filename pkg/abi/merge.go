@@ -0,0 +1,86 @@
+// VulcanizeDB
+// Copyright © 2019 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package abi
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+)
+
+// The parts of an abi entry which identify it
+type abiEntry struct {
+	Type   string                   `json:"type"`
+	Name   string                   `json:"name"`
+	Inputs []abi.ArgumentMarshaling `json:"inputs"`
+}
+
+// MergeAbis combines the entries of the abis, such as those of a proxy and its implementations,
+// keeping only the first of any entries with the same signature
+func MergeAbis(abis ...string) (string, error) {
+	merged := make([]json.RawMessage, 0)
+	seen := make(map[string]bool)
+	for _, abiStr := range abis {
+		if _, err := ParseAbi(abiStr); err != nil {
+			return "", err
+		}
+		var raws []json.RawMessage
+		if err := json.Unmarshal([]byte(abiStr), &raws); err != nil {
+			return "", ErrInvalidAbiFile
+		}
+		for _, raw := range raws {
+			var entry abiEntry
+			if err := json.Unmarshal(raw, &entry); err != nil {
+				return "", ErrInvalidAbiFile
+			}
+			key, err := entry.signature()
+			if err != nil {
+				return "", ErrInvalidAbiFile
+			}
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			merged = append(merged, raw)
+		}
+	}
+	data, err := json.Marshal(merged)
+	if err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
+
+// Entries are identified by their type, name and input types, e.g. `function:balanceOf(address)`
+func (entry abiEntry) signature() (string, error) {
+	inputTypes := make([]string, len(entry.Inputs))
+	for i, input := range entry.Inputs {
+		t, err := abi.NewType(input.Type, input.InternalType, input.Components)
+		if err != nil {
+			return "", err
+		}
+		inputTypes[i] = t.String()
+	}
+	entryType := entry.Type
+	if entryType == "" {
+		entryType = "function"
+	}
+
+	return entryType + ":" + entry.Name + "(" + strings.Join(inputTypes, ",") + ")", nil
+}
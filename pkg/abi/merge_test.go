@@ -0,0 +1,55 @@
+// VulcanizeDB
+// Copyright © 2019 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package abi_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	a "github.com/vulcanize/eth-contract-watcher/pkg/abi"
+)
+
+var _ = Describe("Merging abis", func() {
+	const (
+		proxyAbi = `[{"anonymous":false,"inputs":[{"indexed":true,"name":"implementation","type":"address"}],"name":"Upgraded","type":"event"},` +
+			`{"inputs":[],"name":"implementation","outputs":[{"name":"","type":"address"}],"stateMutability":"view","type":"function"}]`
+		implAbi = `[{"anonymous":false,"inputs":[{"indexed":true,"name":"implementation","type":"address"}],"name":"Upgraded","type":"event"},` +
+			`{"inputs":[{"name":"account","type":"address"}],"name":"balanceOf","outputs":[{"name":"","type":"uint256"}],"stateMutability":"view","type":"function"}]`
+		upgradedAbi = `[{"inputs":[{"name":"account","type":"address"}],"name":"balanceOf","outputs":[{"name":"balance","type":"uint256"}],"stateMutability":"view","type":"function"},` +
+			`{"inputs":[{"name":"account","type":"address"},{"name":"id","type":"uint256"}],"name":"balanceOf","outputs":[{"name":"","type":"uint256"}],"stateMutability":"view","type":"function"}]`
+	)
+
+	It("combines the entries of each abi, keeping the first of those with the same signature", func() {
+		merged, err := a.MergeAbis(proxyAbi, implAbi, upgradedAbi)
+		Expect(err).NotTo(HaveOccurred())
+
+		parsed, err := a.ParseAbi(merged)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(parsed.Events).To(HaveLen(1))
+		Expect(parsed.Methods).To(HaveLen(3))
+		Expect(parsed.Methods["implementation"].Sig()).To(Equal("implementation()"))
+		Expect(parsed.Methods["balanceOf"].Sig()).To(Equal("balanceOf(address)"))
+		Expect(parsed.Methods["balanceOf"].Outputs[0].Name).To(BeEmpty())
+		Expect(parsed.Methods["balanceOf0"].Sig()).To(Equal("balanceOf(address,uint256)"))
+	})
+
+	It("fails on invalid abis", func() {
+		_, err := a.MergeAbis(proxyAbi, "not an abi")
+
+		Expect(err).To(Equal(a.ErrInvalidAbiFile))
+	})
+})
@@ -0,0 +1,112 @@
+// VulcanizeDB
+// Copyright © 2019 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package abi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	log "github.com/sirupsen/logrus"
+)
+
+// ErrAbiNotFound is returned by a provider which has no abi for the contract
+var ErrAbiNotFound = errors.New("abi not found")
+
+// AbiProvider supplies contract abis
+type AbiProvider interface {
+	GetAbi(contractAddr string) (string, error)
+}
+
+// ProviderChain tries each of its providers in order, returning the first abi found
+// A provider failing for any reason other than not having the abi is logged and the next one tried
+type ProviderChain []AbiProvider
+
+// GetAbi returns the abi from the first provider in the chain which has it
+func (chain ProviderChain) GetAbi(contractAddr string) (string, error) {
+	failures := make([]string, 0, len(chain))
+	for _, provider := range chain {
+		abiStr, err := provider.GetAbi(contractAddr)
+		if err == nil {
+			return abiStr, nil
+		}
+		if !errors.Is(err, ErrAbiNotFound) {
+			log.Warnf("error fetching abi for %s from %T: %s", contractAddr, provider, err.Error())
+			failures = append(failures, err.Error())
+		}
+	}
+	if len(failures) > 0 {
+		return "", fmt.Errorf("error fetching abi for %s: %s", contractAddr, strings.Join(failures, "; "))
+	}
+
+	return "", ErrAbiNotFound
+}
+
+// MapProvider supplies abis from a map of contract addresses, such as the abis built into the watcher
+type MapProvider map[common.Address]string
+
+// GetAbi returns the abi mapped to the address
+func (abis MapProvider) GetAbi(contractAddr string) (string, error) {
+	if abiStr, ok := abis[common.HexToAddress(contractAddr)]; ok {
+		return abiStr, nil
+	}
+
+	return "", ErrAbiNotFound
+}
+
+// artifact is the part of a Hardhat, Truffle or Foundry build artifact, hardhat-deploy deployment,
+// or solc metadata file which holds the contract's abi, name and deployed addresses
+type artifact struct {
+	ContractName string          `json:"contractName"`
+	Abi          json.RawMessage `json:"abi"`
+	Address      string          `json:"address"`
+	Networks     map[string]struct {
+		Address string `json:"address"`
+	} `json:"networks"`
+	Output struct {
+		Abi json.RawMessage `json:"abi"`
+	} `json:"output"`
+}
+
+// ReadAbiJSON returns the abi held in json, which is either the abi itself or an artifact or metadata file containing it
+func ReadAbiJSON(data []byte) (string, error) {
+	data = bytes.TrimSpace(data)
+	abiStr := string(data)
+	if !bytes.HasPrefix(data, []byte("[")) {
+		var art artifact
+		if err := json.Unmarshal(data, &art); err != nil {
+			return "", ErrInvalidAbiFile
+		}
+		abiStr = string(artifactAbi(art))
+	}
+	if _, err := ParseAbi(abiStr); err != nil {
+		return "", err
+	}
+
+	return abiStr, nil
+}
+
+func artifactAbi(art artifact) json.RawMessage {
+	if len(art.Abi) > 0 {
+		return art.Abi
+	}
+
+	return art.Output.Abi
+}
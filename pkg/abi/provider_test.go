@@ -0,0 +1,127 @@
+// VulcanizeDB
+// Copyright © 2019 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package abi_test
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+
+	a "github.com/vulcanize/eth-contract-watcher/pkg/abi"
+)
+
+type failingProvider struct{}
+
+func (failingProvider) GetAbi(contractAddr string) (string, error) {
+	return "", errors.New("unavailable")
+}
+
+var _ = Describe("ABI providers", func() {
+	const (
+		address  = "0xd26114cd6EE289AccF82350c8d8487fedB8A0C07"
+		tokenAbi = `[{"constant":true,"inputs":[],"name":"totalSupply","outputs":[{"name":"","type":"uint256"}],"payable":false,"type":"function"}]`
+	)
+	var dir string
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "abis")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	writeFile := func(name, content string) {
+		path := filepath.Join(dir, name)
+		Expect(os.MkdirAll(filepath.Dir(path), 0755)).To(Succeed())
+		Expect(ioutil.WriteFile(path, []byte(content), 0644)).To(Succeed())
+	}
+
+	It("reads abis from files named by address", func() {
+		writeFile("0xd26114cd6ee289accf82350c8d8487fedb8a0c07.json", tokenAbi)
+		provider := a.NewDirectoryProvider(dir)
+
+		abiStr, err := provider.GetAbi(address)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(abiStr).To(Equal(tokenAbi))
+		_, err = provider.GetAbi("0x0000000000000000000000000000000000000001")
+		Expect(err).To(Equal(a.ErrAbiNotFound))
+	})
+
+	It("reads abis from build artifacts by deployed address or configured name", func() {
+		// Truffle records the addresses a contract is deployed at, while Foundry artifacts are named only by their file
+		writeFile("build/contracts/Token.json", fmt.Sprintf(`{"contractName":"Token","abi":%s,"networks":{"1":{"address":%q}}}`, tokenAbi, address))
+		writeFile("out/Vault.sol/Vault.json", fmt.Sprintf(`{"abi":%s,"bytecode":{"object":"0x"}}`, tokenAbi))
+		writeFile("out/Vault.sol/Vault.dbg.json", `{"buildInfo":"../build-info/1.json"}`)
+		provider := a.NewArtifactProvider(dir, map[string]string{"0x0000000000000000000000000000000000000001": "vault"})
+
+		abiStr, err := provider.GetAbi(address)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(abiStr).To(Equal(tokenAbi))
+		abiStr, err = provider.GetAbi("0x0000000000000000000000000000000000000001")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(abiStr).To(Equal(tokenAbi))
+		_, err = provider.GetAbi("0x0000000000000000000000000000000000000002")
+		Expect(err).To(Equal(a.ErrAbiNotFound))
+	})
+
+	It("fetches abis from the metadata of contracts verified on sourcify", func() {
+		server := ghttp.NewServer()
+		defer server.Close()
+		server.AppendHandlers(
+			ghttp.CombineHandlers(
+				ghttp.VerifyRequest("GET", "/repository/contracts/full_match/1/"+address+"/metadata.json"),
+				ghttp.RespondWith(http.StatusNotFound, ""),
+			),
+			ghttp.CombineHandlers(
+				ghttp.VerifyRequest("GET", "/repository/contracts/partial_match/1/"+address+"/metadata.json"),
+				ghttp.RespondWith(http.StatusOK, fmt.Sprintf(`{"compiler":{"version":"0.8.4"},"output":{"abi":%s}}`, tokenAbi)),
+			),
+		)
+		provider := a.NewSourcifyProvider(server.URL(), 1)
+
+		abiStr, err := provider.GetAbi(address)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(abiStr).To(Equal(tokenAbi))
+	})
+
+	It("returns the abi from the first provider in the chain which has it", func() {
+		writeFile(address+".json", tokenAbi)
+		chain := a.ProviderChain{a.MapProvider{}, failingProvider{}, a.NewDirectoryProvider(dir)}
+
+		abiStr, err := chain.GetAbi(address)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(abiStr).To(Equal(tokenAbi))
+
+		_, err = chain.GetAbi("0x0000000000000000000000000000000000000001")
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("unavailable"))
+		_, err = a.ProviderChain{a.MapProvider{}}.GetAbi(address)
+		Expect(err).To(Equal(a.ErrAbiNotFound))
+	})
+})
@@ -0,0 +1,79 @@
+// VulcanizeDB
+// Copyright © 2019 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package abi
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// DefaultSourcifyURL is the repository of contracts verified by Sourcify
+const DefaultSourcifyURL = "https://repo.sourcify.dev"
+
+type sourcifyProvider struct {
+	client  *http.Client
+	url     string
+	chainID int64
+}
+
+// NewSourcifyProvider returns a provider fetching abis from the metadata of contracts verified on the chain,
+// from a Sourcify compatible repository
+func NewSourcifyProvider(url string, chainID int64) AbiProvider {
+	if url == "" {
+		url = DefaultSourcifyURL
+	}
+
+	return &sourcifyProvider{
+		client:  &http.Client{Timeout: 10 * time.Second},
+		url:     url,
+		chainID: chainID,
+	}
+}
+
+// GetAbi returns the abi from the contract's metadata, preferring a full match to a partial one
+func (p *sourcifyProvider) GetAbi(contractAddr string) (string, error) {
+	for _, match := range []string{"full_match", "partial_match"} {
+		request := fmt.Sprintf("%s/repository/contracts/%s/%d/%s/metadata.json", p.url, match, p.chainID, common.HexToAddress(contractAddr).Hex())
+		r, err := p.client.Get(request)
+		if err != nil {
+			return "", fmt.Errorf("sourcify request failed: %s", err.Error())
+		}
+		data, err := ioutil.ReadAll(r.Body)
+		r.Body.Close()
+		if err != nil {
+			return "", fmt.Errorf("error reading sourcify response: %s", err.Error())
+		}
+		if r.StatusCode == http.StatusNotFound {
+			continue
+		}
+		if r.StatusCode != http.StatusOK {
+			return "", fmt.Errorf("sourcify request failed: %s", r.Status)
+		}
+		var metadata artifact
+		if err := json.Unmarshal(data, &metadata); err != nil || len(metadata.Output.Abi) == 0 {
+			return "", fmt.Errorf("invalid sourcify metadata for %s", contractAddr)
+		}
+		return string(metadata.Output.Abi), nil
+	}
+
+	return "", ErrAbiNotFound
+}
@@ -0,0 +1,88 @@
+// VulcanizeDB
+// Copyright © 2019 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package config
+
+import (
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+
+	a "github.com/vulcanize/eth-contract-watcher/pkg/abi"
+)
+
+// The kinds of abi provider which can be chained, in the order used when none are configured
+const (
+	BuiltinAbis   = "builtin"   // The abis built into the watcher
+	DirectoryAbis = "directory" // `<address>.json` files in a directory
+	ArtifactAbis  = "artifacts" // Hardhat, Truffle or Foundry build artifacts in a directory
+	SourcifyAbis  = "sourcify"  // A Sourcify compatible repository of verified contracts
+	EtherscanAbis = "etherscan" // Etherscan's api for the network
+	DatabaseAbis  = "database"  // The abis recorded in the catalog for contracts watched before
+	StandardAbis  = "standards" // The abis of the standards, such as ERC-20 or ERC-721, the contract is detected to implement
+)
+
+// AbiProvider is a configured source of contract abis
+type AbiProvider struct {
+	Kind     string
+	Location string // Directory of the directory and artifacts providers, or url of the sourcify repository
+}
+
+// Reads the ordered `abiProviders` list, in which each provider is given as `kind` or `kind:location`
+func getAbiProviders() []AbiProvider {
+	configured := viper.GetStringSlice("contract.abiProviders")
+	if len(configured) == 0 {
+		return []AbiProvider{{Kind: BuiltinAbis}, {Kind: EtherscanAbis}, {Kind: StandardAbis}}
+	}
+	providers := make([]AbiProvider, 0, len(configured))
+	for _, str := range configured {
+		parts := strings.SplitN(str, ":", 2)
+		provider := AbiProvider{Kind: strings.ToLower(strings.TrimSpace(parts[0]))}
+		if len(parts) == 2 {
+			provider.Location = strings.TrimSpace(parts[1])
+		}
+		switch provider.Kind {
+		case DirectoryAbis, ArtifactAbis:
+			if provider.Location == "" {
+				log.Fatalf("contract `abiProviders` %s provider requires a directory, as `%s:<path>`", provider.Kind, provider.Kind)
+			}
+		case BuiltinAbis, SourcifyAbis, EtherscanAbis, DatabaseAbis, StandardAbis:
+		default:
+			log.Fatalf("contract `abiProviders` has unknown provider %s", str)
+		}
+		providers = append(providers, provider)
+	}
+
+	return providers
+}
+
+// Reads the `etherscan` section configuring the etherscan provider's api key, rate limit and retries
+// The api key can also be given in the ETHERSCAN_APIKEY environment variable
+func getEtherscanOptions() a.EtherScanOptions {
+	options := a.EtherScanOptions{
+		APIKey:            viper.GetString("etherscan.apiKey"),
+		RequestsPerSecond: viper.GetFloat64("etherscan.requestsPerSecond"),
+		Retries:           viper.GetInt("etherscan.retries"),
+		Backoff:           time.Duration(viper.GetFloat64("etherscan.backoff") * float64(time.Second)),
+	}
+	if options.RequestsPerSecond < 0 {
+		log.Fatal("etherscan `requestsPerSecond` must not be negative")
+	}
+
+	return options
+}
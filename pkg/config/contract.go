@@ -19,9 +19,13 @@ package config
 import (
 	"strings"
 
+	gethAbi "github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
 	a "github.com/vulcanize/eth-contract-watcher/pkg/abi"
+	"github.com/vulcanize/eth-contract-watcher/pkg/naming"
+	"github.com/vulcanize/eth-contract-watcher/pkg/types"
 )
 
 // Config struct for generic contract transformer
@@ -60,11 +64,84 @@ type ContractConfig struct {
 	// Otherwise any argument of the right type seen emitted from events at that contract will be used in method polling
 	MethodArgs map[string][]string
 
+	// Map of contract address to a map of abi types to values to poll methods with
+	// These are used for arguments of that type in addition to any values emitted from events
+	ArgValues map[string]map[string][]string
+
+	// Map of contract address to a map of method names to the source of sets of argument values to poll them with
+	// These are polled with in addition to any values emitted from events, and regardless of the `methodArgs` filter
+	StaticArgs map[string]map[string]StaticArgSource
+
+	// Map of contract address to a map of method names to the event fields, as `Event.field`, each of their arguments is taken from
+	// Methods configured this way are only polled with the tuples of values emitted together in the same log
+	ArgSources map[string]map[string][]string
+
 	// Map of contract address to their starting block
 	StartingBlocks map[string]int64
 
 	// Map of contract address to whether or not to pipe method polling results forward into subsequent method calls
 	Piping map[string]bool
+
+	// Map of contract address to the lowercase names of methods mapped to the methods their results are piped to
+	// When set these pipes are followed in place of piping every result to every method
+	Pipes map[string]types.Pipes
+
+	// Map of contract address to a human-readable alias used in place of the address in generated schema and table names
+	Aliases map[string]string
+
+	// Map of contract address to a map of event names to fields to index in addition to the defaults
+	// By default fields declared indexed in the abi and address fields are indexed
+	Indexes map[string]map[string][]string
+
+	// Map of contract address to a map of event names to fields not to index; "*" suppresses every default index
+	Unindexed map[string]map[string][]string
+
+	// Map of contract address to the number of blocks held by each partition of its event and method tables
+	// Tables are only partitioned if this is set; it can not be applied to tables which already exist
+	PartitionSizes map[string]int64
+
+	// Map of contract address to whether or not to store only changes to its polled method values
+	// Each change is stored with the range of blocks it is valid for, in place of a row for every block
+	ChangesOnly map[string]bool
+
+	// Map of contract address to a map of method names to when they are polled
+	// Methods without a schedule are polled at every block
+	Schedules map[string]map[string]types.Schedule
+
+	// Map of contract address to whether or not to poll methods only with the argument values emitted at each block
+	// Values emitted at earlier blocks are only polled with again on full polls
+	TouchedOnly map[string]bool
+
+	// Map of contract address to the number of blocks between full polls with every collected argument value in TouchedOnly mode
+	// Zero, the default, never polls with every value
+	FullPollIntervals map[string]int64
+
+	// Address of a Multicall2 contract used to aggregate method calls at each block into a single eth_call
+	// Without one, or at blocks before it was deployed, calls are sent as JSON-RPC batch requests
+	Multicall string
+
+	// Ordered sources of the abis of contracts not given one in the config
+	AbiProviders []AbiProvider
+	// Map of contract address to the name of the build artifact holding its abi, for the artifacts provider
+	Artifacts map[string]string
+	// Api key, rate limit and retries of the etherscan provider
+	Etherscan a.EtherScanOptions
+	// Url of the Etherscan compatible explorer used by the etherscan provider, in place of the one for the chain
+	ExplorerURL string
+	// Id of the chain reported by the connected node, used to look up its explorer and Sourcify matches
+	// Zero falls back to the id of the configured network
+	ChainID int64
+
+	// Poll methods as a stage of their own, at the headers already checked for events, rather than along with the events
+	// This lets methods added to a contract backfill without the events being reprocessed
+	DecoupledMethods bool
+	// Run the decoupled method stage in its own goroutine, concurrently with the events; implies DecoupledMethods
+	ConcurrentMethods bool
+
+	// Templates used to name the generated event and method tables, in the form `<schema>.<table>`
+	// Empty templates default to `{mode}_{address}.{event}_event` and `{mode}_{address}.{method}_method`
+	EventTableTemplate  string
+	MethodTableTemplate string
 }
 
 func (contractConfig *ContractConfig) PrepConfig() {
@@ -76,8 +153,48 @@ func (contractConfig *ContractConfig) PrepConfig() {
 	contractConfig.Events = make(map[string][]string, len(addrs))
 	contractConfig.MethodArgs = make(map[string][]string, len(addrs))
 	contractConfig.EventArgs = make(map[string][]string, len(addrs))
+	contractConfig.ArgValues = make(map[string]map[string][]string, len(addrs))
+	contractConfig.ArgSources = make(map[string]map[string][]string, len(addrs))
+	contractConfig.StaticArgs = make(map[string]map[string]StaticArgSource, len(addrs))
 	contractConfig.StartingBlocks = make(map[string]int64, len(addrs))
 	contractConfig.Piping = make(map[string]bool, len(addrs))
+	contractConfig.Pipes = make(map[string]types.Pipes, len(addrs))
+	contractConfig.Artifacts = make(map[string]string, len(addrs))
+	contractConfig.Aliases = make(map[string]string, len(addrs))
+	contractConfig.Indexes = make(map[string]map[string][]string, len(addrs))
+	contractConfig.Unindexed = make(map[string]map[string][]string, len(addrs))
+	contractConfig.PartitionSizes = make(map[string]int64, len(addrs))
+	contractConfig.ChangesOnly = make(map[string]bool, len(addrs))
+	contractConfig.Schedules = make(map[string]map[string]types.Schedule, len(addrs))
+	contractConfig.TouchedOnly = make(map[string]bool, len(addrs))
+	contractConfig.FullPollIntervals = make(map[string]int64, len(addrs))
+
+	// Get and check multicall contract address
+	contractConfig.Multicall = viper.GetString("contract.multicall")
+	if contractConfig.Multicall != "" && !common.IsHexAddress(contractConfig.Multicall) {
+		log.Fatal("contract `multicall` not a valid address: ", contractConfig.Multicall)
+	}
+
+	contractConfig.AbiProviders = getAbiProviders()
+	contractConfig.Etherscan = getEtherscanOptions()
+	contractConfig.ExplorerURL = strings.TrimSuffix(viper.GetString("contract.explorerURL"), "/")
+	contractConfig.ConcurrentMethods = viper.GetBool("contract.concurrentMethods")
+	contractConfig.DecoupledMethods = viper.GetBool("contract.decoupledMethods") || contractConfig.ConcurrentMethods
+
+	// Get and check naming templates
+	contractConfig.EventTableTemplate = viper.GetString("contract.naming.events")
+	if contractConfig.EventTableTemplate != "" {
+		if err := naming.ValidateTemplate(contractConfig.EventTableTemplate, naming.EventPlaceholder); err != nil {
+			log.Fatal("contract `naming.events` template invalid: ", err)
+		}
+	}
+	contractConfig.MethodTableTemplate = viper.GetString("contract.naming.methods")
+	if contractConfig.MethodTableTemplate != "" {
+		if err := naming.ValidateTemplate(contractConfig.MethodTableTemplate, naming.MethodPlaceholder); err != nil {
+			log.Fatal("contract `naming.methods` template invalid: ", err)
+		}
+	}
+
 	// De-dupe addresses
 	for _, addr := range addrs {
 		contractConfig.Addresses[strings.ToLower(addr)] = true
@@ -91,7 +208,7 @@ func (contractConfig *ContractConfig) PrepConfig() {
 		var abi string
 		abiInterface, abiOK := transformer["abi"]
 		if !abiOK {
-			log.Warnf("contract %s not configured with an ABI, will attempt to fetch it from Etherscan\r\n", addr)
+			log.Warnf("contract %s not configured with an ABI, will attempt to fetch it from the ABI providers\r\n", addr)
 		} else {
 			abi, abiOK = abiInterface.(string)
 			if !abiOK {
@@ -105,6 +222,15 @@ func (contractConfig *ContractConfig) PrepConfig() {
 		}
 		contractConfig.Abis[strings.ToLower(addr)] = abi
 
+		// Get and check the name of the artifact holding the abi
+		if artifactInterface, artifactOK := transformer["artifact"]; artifactOK {
+			artifact, artifactOK := artifactInterface.(string)
+			if !artifactOK {
+				log.Fatal(addr, "transformer `artifact` not of type string")
+			}
+			contractConfig.Artifacts[strings.ToLower(addr)] = artifact
+		}
+
 		// Get and check events
 		events := make([]string, 0)
 		eventsInterface, eventsOK := transformer["events"]
@@ -189,6 +315,35 @@ func (contractConfig *ContractConfig) PrepConfig() {
 		}
 		contractConfig.MethodArgs[strings.ToLower(addr)] = methodArgs
 
+		// Get and check argValues
+		argValues := getStringSliceMap(addr, "argvalues", transformer)
+		for abiType, values := range argValues {
+			t, err := gethAbi.NewType(abiType, "", nil)
+			if err != nil || !types.PollableArg(t) {
+				log.Fatalf("%s transformer `argValues` type %s can not be used as a method argument\r\n", addr, abiType)
+			}
+			for _, value := range values {
+				if _, err := types.ParseArg(t, value); err != nil {
+					log.Fatalf("%s transformer `argValues` invalid: %s\r\n", addr, err.Error())
+				}
+			}
+		}
+		contractConfig.ArgValues[strings.ToLower(addr)] = argValues
+
+		// Get and check argSources; they are checked against the abi when the contract is initialized
+		argSources := getStringSliceMap(addr, "argsources", transformer)
+		for method, sources := range argSources {
+			for _, source := range sources {
+				if parts := strings.Split(source, "."); len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+					log.Fatalf("%s transformer `argSources` for %s must be of the form `Event.field`, got %s\r\n", addr, method, source)
+				}
+			}
+		}
+		contractConfig.ArgSources[strings.ToLower(addr)] = argSources
+
+		// Get staticArgs; their values are checked against the abi when the contract is initialized
+		contractConfig.StaticArgs[strings.ToLower(addr)] = getStaticArgs(addr, transformer)
+
 		// Get and check startingBlock
 		startInterface, startOK := transformer["startingblock"]
 		if !startOK {
@@ -214,5 +369,103 @@ func (contractConfig *ContractConfig) PrepConfig() {
 			}
 		}
 		contractConfig.Piping[strings.ToLower(addr)] = piping
+
+		// Get and check pipes
+		contractConfig.Pipes[strings.ToLower(addr)] = getPipes(addr, transformer)
+
+		// Get and check alias
+		aliasInterface, aliasOK := transformer["alias"]
+		if aliasOK {
+			alias, aliasOK := aliasInterface.(string)
+			if !aliasOK {
+				log.Fatal(addr, "transformer `alias` not of type string\r\n")
+			}
+			alias = strings.ToLower(alias)
+			if err := naming.ValidateAlias(alias); err != nil {
+				log.Fatal(addr, " transformer `alias` invalid: ", err)
+			}
+			for otherAddr, otherAlias := range contractConfig.Aliases {
+				if otherAlias == alias && otherAddr != strings.ToLower(addr) {
+					log.Fatalf("contracts %s and %s are configured with the same alias %s\r\n", otherAddr, addr, alias)
+				}
+			}
+			contractConfig.Aliases[strings.ToLower(addr)] = alias
+		}
+
+		// Get and check partitionSize
+		partitionInterface, partitionOK := transformer["partitionsize"]
+		if partitionOK {
+			partitionSize, partitionOK := partitionInterface.(int64)
+			if !partitionOK || partitionSize < 0 {
+				log.Fatal(addr, "transformer `partitionSize` not a non-negative int\r\n")
+			}
+			contractConfig.PartitionSizes[strings.ToLower(addr)] = partitionSize
+		}
+
+		// Get and check changesOnly
+		changesInterface, changesOK := transformer["changesonly"]
+		if changesOK {
+			changesOnly, changesOK := changesInterface.(bool)
+			if !changesOK {
+				log.Fatal(addr, "transformer `changesOnly` not of type bool\r\n")
+			}
+			contractConfig.ChangesOnly[strings.ToLower(addr)] = changesOnly
+		}
+
+		// Get and check schedules
+		contractConfig.Schedules[strings.ToLower(addr)] = getSchedules(addr, transformer)
+
+		// Get and check touchedOnly and fullPollInterval
+		touchedInterface, touchedOK := transformer["touchedonly"]
+		if touchedOK {
+			touchedOnly, touchedOK := touchedInterface.(bool)
+			if !touchedOK {
+				log.Fatal(addr, "transformer `touchedOnly` not of type bool\r\n")
+			}
+			contractConfig.TouchedOnly[strings.ToLower(addr)] = touchedOnly
+		}
+		intervalInterface, intervalOK := transformer["fullpollinterval"]
+		if intervalOK {
+			interval, intervalOK := intervalInterface.(int64)
+			if !intervalOK || interval < 0 {
+				log.Fatal(addr, "transformer `fullPollInterval` not a non-negative int\r\n")
+			}
+			contractConfig.FullPollIntervals[strings.ToLower(addr)] = interval
+		}
+
+		// Get and check index overrides
+		contractConfig.Indexes[strings.ToLower(addr)] = getStringSliceMap(addr, "indexes", transformer)
+		contractConfig.Unindexed[strings.ToLower(addr)] = getStringSliceMap(addr, "unindexed", transformer)
+	}
+}
+
+// Reads a table mapping names, such as event names, to lists of strings, such as event fields
+// Names are lowercased, as the config keys are case-insensitive
+func getStringSliceMap(addr, key string, transformer map[string]interface{}) map[string][]string {
+	stringSlices := make(map[string][]string)
+	mapInterface, mapOK := transformer[key]
+	if !mapOK {
+		return stringSlices
+	}
+	mapI, mapOK := mapInterface.(map[string]interface{})
+	if !mapOK {
+		log.Fatalf("%s transformer `%s` not of type map[string][]string\r\n", addr, key)
+	}
+	for name, valuesInterface := range mapI {
+		valuesI, valuesOK := valuesInterface.([]interface{})
+		if !valuesOK {
+			log.Fatalf("%s transformer `%s` not of type map[string][]string\r\n", addr, key)
+		}
+		values := make([]string, 0, len(valuesI))
+		for _, strI := range valuesI {
+			str, strOK := strI.(string)
+			if !strOK {
+				log.Fatalf("%s transformer `%s` not of type map[string][]string\r\n", addr, key)
+			}
+			values = append(values, str)
+		}
+		stringSlices[strings.ToLower(name)] = values
 	}
+
+	return stringSlices
 }
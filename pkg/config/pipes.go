@@ -0,0 +1,57 @@
+// VulcanizeDB
+// Copyright © 2019 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package config
+
+import (
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/vulcanize/eth-contract-watcher/pkg/types"
+)
+
+// Reads the `pipes` table, which maps method names to the methods their returned values are piped to as arguments
+// Pipes which form a cycle, such as a method piped to itself, can not be polled in order and are rejected
+func getPipes(addr string, transformer map[string]interface{}) types.Pipes {
+	pipes := make(types.Pipes)
+	mapInterface, mapOK := transformer["pipes"]
+	if !mapOK {
+		return pipes
+	}
+	methods, mapOK := mapInterface.(map[string]interface{})
+	if !mapOK {
+		log.Fatalf("%s transformer `pipes` not a table of methods\r\n", addr)
+	}
+	for method, targetsInterface := range methods {
+		targets, targetsOK := targetsInterface.([]interface{})
+		if !targetsOK || len(targets) == 0 {
+			log.Fatalf("%s transformer `pipes` for %s not a list of methods\r\n", addr, method)
+		}
+		for _, targetInterface := range targets {
+			target, targetOK := targetInterface.(string)
+			if !targetOK {
+				log.Fatalf("%s transformer `pipes` for %s not a list of methods\r\n", addr, method)
+			}
+			pipes[strings.ToLower(method)] = append(pipes[strings.ToLower(method)], strings.ToLower(target))
+		}
+	}
+	if cycle := pipes.Cycle(); cycle != nil {
+		log.Fatalf("%s transformer `pipes` form a cycle: %s\r\n", addr, strings.Join(cycle, " -> "))
+	}
+
+	return pipes
+}
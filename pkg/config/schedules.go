@@ -0,0 +1,83 @@
+// VulcanizeDB
+// Copyright © 2019 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package config
+
+import (
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/vulcanize/eth-contract-watcher/pkg/types"
+)
+
+// Reads the `schedules` table, which maps method names to one of
+// `blocks`, to poll every this many blocks, `seconds`, to poll every this many seconds of block time,
+// `at`, a list of the blocks to poll at, or `head`, to poll only at the most recent header
+func getSchedules(addr string, transformer map[string]interface{}) map[string]types.Schedule {
+	schedules := make(map[string]types.Schedule)
+	mapInterface, mapOK := transformer["schedules"]
+	if !mapOK {
+		return schedules
+	}
+	methods, mapOK := mapInterface.(map[string]interface{})
+	if !mapOK {
+		log.Fatalf("%s transformer `schedules` not a table of methods\r\n", addr)
+	}
+	for method, scheduleInterface := range methods {
+		scheduleI, scheduleOK := scheduleInterface.(map[string]interface{})
+		if !scheduleOK || len(scheduleI) != 1 {
+			log.Fatalf("%s transformer `schedules` for %s must set one of `blocks`, `seconds`, `at`, or `head`\r\n", addr, method)
+		}
+		var schedule types.Schedule
+		for kind, value := range scheduleI {
+			switch kind {
+			case "blocks":
+				schedule.Every = getPositiveInt(addr, method, kind, value)
+			case "seconds":
+				schedule.Seconds = getPositiveInt(addr, method, kind, value)
+			case "at":
+				blocks, blocksOK := value.([]interface{})
+				if !blocksOK || len(blocks) == 0 {
+					log.Fatalf("%s transformer `schedules` at for %s not a list of blocks\r\n", addr, method)
+				}
+				for _, block := range blocks {
+					schedule.At = append(schedule.At, getPositiveInt(addr, method, kind, block))
+				}
+			case "head":
+				head, headOK := value.(bool)
+				if !headOK || !head {
+					log.Fatalf("%s transformer `schedules` head for %s must be true\r\n", addr, method)
+				}
+				schedule.Head = head
+			default:
+				log.Fatalf("%s transformer `schedules` for %s must set one of `blocks`, `seconds`, `at`, or `head`\r\n", addr, method)
+			}
+		}
+		schedules[strings.ToLower(method)] = schedule
+	}
+
+	return schedules
+}
+
+func getPositiveInt(addr, method, kind string, value interface{}) int64 {
+	n, nOK := value.(int64)
+	if !nOK || n <= 0 {
+		log.Fatalf("%s transformer `schedules` %s for %s not a positive int\r\n", addr, kind, method)
+	}
+
+	return n
+}
@@ -0,0 +1,124 @@
+// VulcanizeDB
+// Copyright © 2019 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package config
+
+import (
+	"encoding/csv"
+	"os"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// StaticArgSource is where the sets of argument values a method is polled with, in addition to emitted values, come from
+// Rows holds sets given inline or read from a CSV file; Query is a SQL query run against the database, one set per row
+type StaticArgSource struct {
+	Rows  [][]string
+	Query string
+}
+
+// Reads the `staticArgs` table, which maps method names to one of
+// `values`, a list of argument values or of lists of argument values,
+// `csv`, the path to a CSV file holding one set of argument values per line, or
+// `sql`, a query returning one set of argument values per row
+func getStaticArgs(addr string, transformer map[string]interface{}) map[string]StaticArgSource {
+	staticArgs := make(map[string]StaticArgSource)
+	mapInterface, mapOK := transformer["staticargs"]
+	if !mapOK {
+		return staticArgs
+	}
+	methods, mapOK := mapInterface.(map[string]interface{})
+	if !mapOK {
+		log.Fatalf("%s transformer `staticArgs` not a table of methods\r\n", addr)
+	}
+	for method, sourceInterface := range methods {
+		source, sourceOK := sourceInterface.(map[string]interface{})
+		if !sourceOK || len(source) != 1 {
+			log.Fatalf("%s transformer `staticArgs` for %s must set one of `values`, `csv`, or `sql`\r\n", addr, method)
+		}
+		var argSource StaticArgSource
+		for kind, value := range source {
+			switch kind {
+			case "values":
+				argSource.Rows = getArgRows(addr, method, value)
+			case "csv":
+				path, pathOK := value.(string)
+				if !pathOK {
+					log.Fatalf("%s transformer `staticArgs` csv for %s not of type string\r\n", addr, method)
+				}
+				argSource.Rows = readArgsCSV(addr, method, path)
+			case "sql":
+				query, queryOK := value.(string)
+				if !queryOK || query == "" {
+					log.Fatalf("%s transformer `staticArgs` sql for %s not a non-empty string\r\n", addr, method)
+				}
+				argSource.Query = query
+			default:
+				log.Fatalf("%s transformer `staticArgs` for %s must set one of `values`, `csv`, or `sql`\r\n", addr, method)
+			}
+		}
+		staticArgs[strings.ToLower(method)] = argSource
+	}
+
+	return staticArgs
+}
+
+// Inline values are given as a list of values for single argument methods, or a list of lists of values
+func getArgRows(addr, method string, value interface{}) [][]string {
+	valuesI, valuesOK := value.([]interface{})
+	if !valuesOK {
+		log.Fatalf("%s transformer `staticArgs` values for %s not a list\r\n", addr, method)
+	}
+	rows := make([][]string, 0, len(valuesI))
+	for _, rowI := range valuesI {
+		switch row := rowI.(type) {
+		case string:
+			rows = append(rows, []string{row})
+		case []interface{}:
+			strs := make([]string, 0, len(row))
+			for _, strI := range row {
+				str, strOK := strI.(string)
+				if !strOK {
+					log.Fatalf("%s transformer `staticArgs` values for %s not of type string\r\n", addr, method)
+				}
+				strs = append(strs, str)
+			}
+			rows = append(rows, strs)
+		default:
+			log.Fatalf("%s transformer `staticArgs` values for %s not of type string\r\n", addr, method)
+		}
+	}
+
+	return rows
+}
+
+func readArgsCSV(addr, method, path string) [][]string {
+	file, err := os.Open(path)
+	if err != nil {
+		log.Fatalf("%s transformer `staticArgs` csv for %s could not be opened: %s\r\n", addr, method, err.Error())
+	}
+	defer file.Close()
+	reader := csv.NewReader(file)
+	reader.TrimLeadingSpace = true
+	reader.Comment = '#'
+	rows, err := reader.ReadAll()
+	if err != nil {
+		log.Fatalf("%s transformer `staticArgs` csv for %s could not be read: %s\r\n", addr, method, err.Error())
+	}
+
+	return rows
+}
@@ -18,6 +18,10 @@ package contract
 
 import (
 	"errors"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
 
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/common"
@@ -30,41 +34,108 @@ import (
 
 // Contract object to hold our contract data
 type Contract struct {
-	Name           string                       // Name of the contract
-	Address        string                       // Address of the contract
-	Network        string                       // Network on which the contract is deployed; default empty "" is Ethereum mainnet
-	StartingBlock  int64                        // Starting block of the contract
-	Abi            string                       // Abi string
-	ParsedAbi      abi.ABI                      // Parsed abi
-	Events         map[string]types.Event       // List of events to watch
-	Methods        []types.Method               // List of methods to poll
-	Filters        map[string]filters.LogFilter // Map of event filters to their event names; used only for full sync watcher
-	FilterArgs     map[string]bool              // User-input list of values to filter event logs for
-	MethodArgs     map[string]bool              // User-input list of values to limit method polling to
-	EmittedAddrs   map[interface{}]bool         // List of all unique addresses collected from converted event logs
-	EmittedHashes  map[interface{}]bool         // List of all unique hashes collected from converted event logs
-	CreateAddrList bool                         // Whether or not to persist address list to postgres
-	CreateHashList bool                         // Whether or not to persist hash list to postgres
-	Piping         bool                         // Whether or not to pipe method results forward as arguments to subsequent methods
+	Name           string                                // Name of the contract
+	Address        string                                // Address of the contract
+	Network        string                                // Network on which the contract is deployed; default empty "" is Ethereum mainnet
+	StartingBlock  int64                                 // Starting block of the contract
+	Abi            string                                // Abi string
+	ParsedAbi      abi.ABI                               // Parsed abi
+	Events         map[string]types.Event                // List of events to watch
+	Methods        []types.Method                        // List of methods to poll
+	Filters        map[string]filters.LogFilter          // Map of event filters to their event names; used only for full sync watcher
+	FilterArgs     map[string]bool                       // User-input list of values to filter event logs for
+	MethodArgs     map[string]bool                       // User-input list of values to limit method polling to
+	EmittedAddrs   map[interface{}]bool                  // List of all unique addresses collected from converted event logs
+	EmittedHashes  map[interface{}]bool                  // List of all unique hashes collected from converted event logs
+	EmittedValues  map[string]map[string]bool            // Unique values of other method argument types collected from converted event logs, keyed by abi type
+	StaticArgs     map[string][]interface{}              // User-input values to poll methods with in addition to emitted values, keyed by abi type
+	CreateAddrList bool                                  // Whether or not to persist address list to postgres
+	CreateHashList bool                                  // Whether or not to persist hash list to postgres
+	Piping         bool                                  // Whether or not to pipe method results forward as arguments to subsequent methods
+	Pipes          types.Pipes                           // Method names to the methods their results are piped to; followed in place of Piping when set
+	PipedValues    map[string]map[string]map[string]bool // Method names to the stringified values piped to them, keyed by abi type
+	TouchedOnly    bool                                  // Whether or not to poll methods only with the argument values emitted at the block being polled
+	FullPollEvery  int64                                 // Number of blocks between polls with every collected argument value when TouchedOnly; 0 never re-polls them all
+	Touched        map[TouchedArg]bool                   // Stringified argument values emitted at the block currently being processed, with their abi types
+	StaticArgSets  map[string][][]interface{}            // Method names to sets of argument values configured to poll them with, in addition to emitted values
+	ArgSources     map[string][]ArgSource                // Method names to the event fields their arguments are taken from together, out of the same log
+	EmittedTuples  map[string]map[string][]string        // Method names to the tuples of stringified argument values emitted together, keyed by their joined values
+	Proxy          *Proxy                                // Implementations delegated to, when the contract is a proxy; Abi is then merged from its own and theirs
 }
 
+// TouchedArg is a stringified argument value emitted at a block, with the abi type it was emitted as
+// A value emitted as several types at the same block, such as an address and a uint256 with the same text, is touched as each
+type TouchedArg struct {
+	Type  string
+	Value string
+}
+
+// ArgSource is the event field a method argument is taken from
+type ArgSource struct {
+	Event string
+	Field string
+}
+
+// Tuples of correlated arguments are recorded amongst the touched and known values with this type prefix on the method name
+// Values piped to a method are recorded with this type prefix on their abi type, and the method name prefixed to the value
+const (
+	tuplePrefix = "tuple:"
+	pipePrefix  = "pipe:"
+)
+
 // Init initializes a contract object
-// If we will be calling methods that use addr, hash, or byte arrays
-// as arguments then we initialize maps to hold these types of values
+// For each type of argument taken by the methods we will be calling
+// we initialize a map to hold the values of that type emitted from events
 func (c Contract) Init() *Contract {
+	c.Touched = map[TouchedArg]bool{}
+	c.InitArgs()
+
+	return &c
+}
+
+// InitArgs initializes the maps holding the argument values of any methods which do not have them yet,
+// keeping the values already collected for the rest
+func (c *Contract) InitArgs() {
 	for _, method := range c.Methods {
+		if _, ok := c.ArgSources[method.Name]; ok {
+			if c.EmittedTuples == nil {
+				c.EmittedTuples = map[string]map[string][]string{}
+			}
+			if c.EmittedTuples[method.Name] == nil {
+				c.EmittedTuples[method.Name] = map[string][]string{}
+			}
+			continue
+		}
 		for _, arg := range method.Args {
-			switch arg.Type.T {
-			case abi.AddressTy:
-				c.EmittedAddrs = map[interface{}]bool{}
-			case abi.HashTy, abi.BytesTy, abi.FixedBytesTy:
-				c.EmittedHashes = map[interface{}]bool{}
+			switch {
+			case arg.Type.T == abi.AddressTy:
+				if c.EmittedAddrs == nil {
+					c.EmittedAddrs = map[interface{}]bool{}
+				}
+			case isHash(arg.Type):
+				if c.EmittedHashes == nil {
+					c.EmittedHashes = map[interface{}]bool{}
+				}
 			default:
+				if c.EmittedValues == nil {
+					c.EmittedValues = map[string]map[string]bool{}
+				}
+				if c.EmittedValues[arg.Type.String()] == nil {
+					c.EmittedValues[arg.Type.String()] = map[string]bool{}
+				}
+			}
+		}
+	}
+	for _, targets := range c.Pipes {
+		for _, target := range targets {
+			if c.PipedValues == nil {
+				c.PipedValues = map[string]map[string]map[string]bool{}
+			}
+			if c.PipedValues[target] == nil {
+				c.PipedValues[target] = map[string]map[string]bool{}
 			}
 		}
 	}
-
-	return &c
 }
 
 // GenerateFilters uses contract info to generate event filters - full sync contract watcher only
@@ -139,6 +210,7 @@ func (c *Contract) AddEmittedAddr(addresses ...interface{}) {
 	for _, addr := range addresses {
 		if c.WantedMethodArg(addr) && c.Methods != nil {
 			c.EmittedAddrs[addr] = true
+			c.Touched[TouchedArg{Type: "address", Value: StringifyArg(addr)}] = true
 		}
 	}
 }
@@ -148,8 +220,220 @@ func (c *Contract) AddEmittedHash(hashes ...interface{}) {
 	for _, hash := range hashes {
 		if c.WantedMethodArg(hash) && c.Methods != nil {
 			c.EmittedHashes[hash] = true
+			c.Touched[TouchedArg{Type: "bytes32", Value: StringifyArg(hash)}] = true
+		}
+	}
+}
+
+// AddEmittedValue adds event emitted values of the given abi type to our list if it passes filter and method polling is on
+func (c *Contract) AddEmittedValue(abiType string, values ...string) {
+	emitted, ok := c.EmittedValues[abiType]
+	if !ok {
+		return
+	}
+	for _, value := range values {
+		if c.WantedMethodArg(value) && c.Methods != nil {
+			emitted[value] = true
+			c.Touched[TouchedArg{Type: abiType, Value: value}] = true
+		}
+	}
+}
+
+// AddEmittedTuple adds a tuple of argument values emitted together in a log for the method, if each passes the filter
+func (c *Contract) AddEmittedTuple(method string, values ...string) {
+	emitted, ok := c.EmittedTuples[method]
+	if !ok || len(values) != len(c.ArgSources[method]) {
+		return
+	}
+	for _, value := range values {
+		if !c.WantedMethodArg(value) {
+			return
+		}
+	}
+	key := strings.Join(values, ",")
+	emitted[key] = values
+	c.Touched[TouchedArg{Type: tuplePrefix + method, Value: key}] = true
+}
+
+// AddPipedValue adds a value returned by a method to those piped to the given method, as arguments of the abi type
+func (c *Contract) AddPipedValue(method, abiType, value string) {
+	piped, ok := c.PipedValues[method]
+	if !ok || !c.WantedMethodArg(value) {
+		return
+	}
+	if piped[abiType] == nil {
+		piped[abiType] = map[string]bool{}
+	}
+	piped[abiType][value] = true
+	c.Touched[TouchedArg{Type: pipePrefix + abiType, Value: method + ":" + value}] = true
+}
+
+// ResetTouched forgets the argument values emitted at the previous block, ready to process the next
+func (c *Contract) ResetTouched() {
+	c.Touched = map[TouchedArg]bool{}
+}
+
+// AddKnownArg adds a previously collected argument value of the given abi type, such as one persisted before a restart
+func (c *Contract) AddKnownArg(abiType, value string) {
+	switch {
+	case strings.HasPrefix(abiType, tuplePrefix):
+		c.AddEmittedTuple(strings.TrimPrefix(abiType, tuplePrefix), strings.Split(value, ",")...)
+	case strings.HasPrefix(abiType, pipePrefix):
+		if i := strings.Index(value, ":"); i > 0 {
+			c.AddPipedValue(value[:i], strings.TrimPrefix(abiType, pipePrefix), value[i+1:])
+		}
+	case abiType == "address" && c.EmittedAddrs != nil:
+		c.AddEmittedAddr(common.HexToAddress(value))
+	case abiType == "bytes32" && c.EmittedHashes != nil:
+		c.AddEmittedHash(common.HexToHash(value))
+	default:
+		c.AddEmittedValue(abiType, value)
+	}
+}
+
+// PollsAllArgsAt returns true if methods are to be polled with every collected argument value at the given block,
+// rather than only those emitted at that block
+func (c *Contract) PollsAllArgsAt(blockNumber int64) bool {
+	if !c.TouchedOnly {
+		return true
+	}
+	return c.FullPollEvery > 0 && (blockNumber-c.StartingBlock)%c.FullPollEvery == 0
+}
+
+// ArgValues returns the values available to poll a method argument with at the given block:
+// those of the argument's type emitted from events, followed by any configured for its type
+// In TouchedOnly mode, outside of full polls, only the values emitted at the block are returned
+func (c *Contract) ArgValues(arg types.Field, blockNumber int64) ([]interface{}, error) {
+	all := c.PollsAllArgsAt(blockNumber)
+	argType := touchedType(arg.Type)
+	wanted := func(value interface{}) bool {
+		return all || c.Touched[TouchedArg{Type: argType, Value: StringifyArg(value)}]
+	}
+	var values []interface{}
+	switch {
+	case arg.Type.T == abi.AddressTy:
+		for addr := range c.EmittedAddrs {
+			if wanted(addr) {
+				values = append(values, addr)
+			}
+		}
+	case isHash(arg.Type):
+		for hash := range c.EmittedHashes {
+			if wanted(hash) {
+				values = append(values, hash)
+			}
+		}
+	default:
+		for str := range c.EmittedValues[arg.Type.String()] {
+			if !wanted(str) {
+				continue
+			}
+			value, err := types.ParseArg(arg.Type, str)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, value)
+		}
+	}
+
+	static := c.StaticArgs[arg.Type.String()]
+	if len(static) == 0 {
+		return values, nil
+	}
+	seen := make(map[string]bool, len(values))
+	for _, value := range values {
+		seen[StringifyArg(value)] = true
+	}
+	for _, value := range static {
+		if !seen[StringifyArg(value)] && wanted(value) {
+			seen[StringifyArg(value)] = true
+			values = append(values, value)
+		}
+	}
+
+	return values, nil
+}
+
+// PipedArgValues returns the values piped to the method which can be used as the given argument at the block
+// In TouchedOnly mode, outside of full polls, only the values piped at the block are returned
+func (c *Contract) PipedArgValues(method types.Method, arg types.Field, blockNumber int64) ([]interface{}, error) {
+	all := c.PollsAllArgsAt(blockNumber)
+	var values []interface{}
+	for str := range c.PipedValues[method.Name][arg.Type.String()] {
+		if !all && !c.Touched[TouchedArg{Type: pipePrefix + arg.Type.String(), Value: method.Name + ":" + str}] {
+			continue
+		}
+		value, err := types.ParseArg(arg.Type, str)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+
+	return values, nil
+}
+
+// ArgTuples returns the tuples of argument values to poll a method with at the given block,
+// for methods whose arguments are taken together from the same log; ok is false for other methods
+// In TouchedOnly mode, outside of full polls, only the tuples emitted at the block are returned
+func (c *Contract) ArgTuples(method types.Method, blockNumber int64) (tuples [][]interface{}, ok bool, err error) {
+	emitted, ok := c.EmittedTuples[method.Name]
+	if !ok {
+		return nil, false, nil
+	}
+	all := c.PollsAllArgsAt(blockNumber)
+	for key, values := range emitted {
+		if !all && !c.Touched[TouchedArg{Type: tuplePrefix + method.Name, Value: key}] {
+			continue
+		}
+		tuple := make([]interface{}, len(values))
+		for i, value := range values {
+			tuple[i], err = types.ParseArg(method.Args[i].Type, value)
+			if err != nil {
+				return nil, true, err
+			}
+		}
+		tuples = append(tuples, tuple)
+	}
+
+	return tuples, true, nil
+}
+
+// StaticArgSetsAt returns the configured sets of argument values to poll a method with at the given block
+// In TouchedOnly mode, outside of full polls, only the sets whose values were all emitted at the block are returned
+func (c *Contract) StaticArgSetsAt(method types.Method, blockNumber int64) [][]interface{} {
+	if c.PollsAllArgsAt(blockNumber) {
+		return c.StaticArgSets[method.Name]
+	}
+	var sets [][]interface{}
+	for _, set := range c.StaticArgSets[method.Name] {
+		touched := len(set) == len(method.Args)
+		for i := 0; touched && i < len(set); i++ {
+			touched = c.Touched[TouchedArg{Type: touchedType(method.Args[i].Type), Value: StringifyArg(set[i])}]
+		}
+		if touched {
+			sets = append(sets, set)
 		}
 	}
+
+	return sets
+}
+
+// The abi type values of the type are touched as; addresses and hashes are collected apart from the other types
+func touchedType(t abi.Type) string {
+	switch {
+	case t.T == abi.AddressTy:
+		return "address"
+	case isHash(t):
+		return "bytes32"
+	default:
+		return t.String()
+	}
+}
+
+// Hashes tend to not be explicitly labeled, so bytes32 are assumed to be hashes
+func isHash(t abi.Type) bool {
+	return t.T == abi.HashTy || (t.T == abi.FixedBytesTy && t.Size == common.HashLength)
 }
 
 // StringifyArg resolves a method argument type to string type
@@ -166,6 +450,25 @@ func StringifyArg(arg interface{}) (str string) {
 	case []byte:
 		a := arg.([]byte)
 		str = hexutil.Encode(a)
+	case *big.Int:
+		a := arg.(*big.Int)
+		str = a.String()
+	case bool:
+		a := arg.(bool)
+		str = strconv.FormatBool(a)
+	default:
+		// Remaining argument types are the smaller integers and fixed size byte arrays
+		v := reflect.ValueOf(arg)
+		switch v.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			str = strconv.FormatInt(v.Int(), 10)
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			str = strconv.FormatUint(v.Uint(), 10)
+		case reflect.Array:
+			b := make([]byte, v.Len())
+			reflect.Copy(reflect.ValueOf(b), v)
+			str = hexutil.Encode(b)
+		}
 	}
 
 	return
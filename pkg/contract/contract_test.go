@@ -17,6 +17,10 @@
 package contract_test
 
 import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
@@ -233,4 +237,163 @@ var _ = Describe("Contract", func() {
 			Expect(b).To(Equal(false))
 		})
 	})
+	Describe("ArgValues", func() {
+		var tokenID types.Field
+
+		BeforeEach(func() {
+			uint256, err := abi.NewType("uint256", "", nil)
+			Expect(err).ToNot(HaveOccurred())
+			tokenID = types.Field{Argument: abi.Argument{Name: "tokenId", Type: uint256}}
+			info = contract.Contract{
+				MethodArgs: map[string]bool{},
+				Methods:    []types.Method{{Name: "ownerOf", Args: []types.Field{tokenID}}},
+			}.Init()
+		})
+
+		It("Returns emitted values of the argument's type", func() {
+			info.AddEmittedValue("uint256", "1", "2", "1")
+			info.AddEmittedValue("bool", "true")
+
+			values, err := info.ArgValues(tokenID, 6194634)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(values).To(ConsistOf(big.NewInt(1), big.NewInt(2)))
+		})
+
+		It("Keeps the values collected when methods are added", func() {
+			info.AddEmittedValue("uint256", "1")
+			smallUint, err := abi.NewType("uint8", "", nil)
+			Expect(err).ToNot(HaveOccurred())
+			tier := types.Field{Argument: abi.Argument{Name: "tier", Type: smallUint}}
+			info.Methods = append(info.Methods, types.Method{Name: "tierOf", Args: []types.Field{tier}})
+			info.InitArgs()
+			info.AddEmittedValue("uint8", "2")
+
+			values, err := info.ArgValues(tokenID, 6194634)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(values).To(ConsistOf(big.NewInt(1)))
+			values, err = info.ArgValues(tier, 6194634)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(values).To(ConsistOf(uint8(2)))
+		})
+
+		It("Adds configured values for the argument's type that have not been emitted", func() {
+			info.AddEmittedValue("uint256", "1")
+			info.StaticArgs = map[string][]interface{}{"uint256": {big.NewInt(1), big.NewInt(3)}}
+
+			values, err := info.ArgValues(tokenID, 6194634)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(values).To(ConsistOf(big.NewInt(1), big.NewInt(3)))
+		})
+
+		It("Returns the configured sets of arguments for the method", func() {
+			info.StaticArgSets = map[string][][]interface{}{"ownerOf": {{big.NewInt(1)}, {big.NewInt(2)}}}
+
+			Expect(info.StaticArgSetsAt(info.Methods[0], 6194634)).To(Equal([][]interface{}{{big.NewInt(1)}, {big.NewInt(2)}}))
+			info.TouchedOnly = true
+			info.AddEmittedValue("uint256", "2")
+			Expect(info.StaticArgSetsAt(info.Methods[0], 6194634)).To(Equal([][]interface{}{{big.NewInt(2)}}))
+		})
+
+		It("Returns only the values emitted at the block in touchedOnly mode", func() {
+			info.TouchedOnly = true
+			info.AddEmittedValue("uint256", "1")
+			info.ResetTouched()
+			info.AddEmittedValue("uint256", "2")
+
+			values, err := info.ArgValues(tokenID, 6194634)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(values).To(ConsistOf(big.NewInt(2)))
+		})
+
+		It("Touches a value emitted as two types at the block as each", func() {
+			smallUint, err := abi.NewType("uint8", "", nil)
+			Expect(err).ToNot(HaveOccurred())
+			tier := types.Field{Argument: abi.Argument{Name: "tier", Type: smallUint}}
+			info.Methods = append(info.Methods, types.Method{Name: "tierOf", Args: []types.Field{tier}})
+			info.InitArgs()
+			info.TouchedOnly = true
+			info.AddEmittedValue("uint256", "1")
+			info.AddEmittedValue("uint8", "1")
+
+			Expect(info.Touched).To(Equal(map[contract.TouchedArg]bool{{Type: "uint256", Value: "1"}: true, {Type: "uint8", Value: "1"}: true}))
+			values, err := info.ArgValues(tokenID, 6194634)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(values).To(ConsistOf(big.NewInt(1)))
+			values, err = info.ArgValues(tier, 6194634)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(values).To(ConsistOf(uint8(1)))
+		})
+
+		It("Returns every emitted value on full polls in touchedOnly mode", func() {
+			info.TouchedOnly = true
+			info.StartingBlock = 6194600
+			info.FullPollEvery = 10
+			info.AddEmittedValue("uint256", "1")
+			info.ResetTouched()
+
+			values, err := info.ArgValues(tokenID, 6194630)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(values).To(ConsistOf(big.NewInt(1)))
+			values, err = info.ArgValues(tokenID, 6194631)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(values).To(BeEmpty())
+		})
+
+		It("Returns values piped only to the method they are piped to", func() {
+			info = contract.Contract{
+				MethodArgs: map[string]bool{},
+				Methods:    []types.Method{{Name: "tokenOf"}, {Name: "ownerOf", Args: []types.Field{tokenID}}},
+				Pipes:      types.Pipes{"tokenOf": {"ownerOf"}},
+			}.Init()
+			info.AddPipedValue("ownerOf", "uint256", "7")
+			info.AddPipedValue("tokenOf", "uint256", "8")
+
+			values, err := info.PipedArgValues(info.Methods[1], tokenID, 6194634)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(values).To(ConsistOf(big.NewInt(7)))
+			Expect(info.Touched).To(Equal(map[contract.TouchedArg]bool{{Type: "pipe:uint256", Value: "ownerOf:7"}: true}))
+
+			// Piped values are restored along with the other collected values
+			restored := contract.Contract{MethodArgs: map[string]bool{}, Methods: info.Methods, Pipes: info.Pipes}.Init()
+			restored.AddKnownArg("pipe:uint256", "ownerOf:7")
+			Expect(restored.PipedValues).To(Equal(info.PipedValues))
+		})
+	})
+
+	Describe("ArgTuples", func() {
+		var allowance types.Method
+		owner := "0x8dd5fbCe2F6a956C3022bA3663759011Dd51e73E"
+		spender := "0xfE9e8709d3215310075d67E3ed32A380CCf451C8"
+
+		BeforeEach(func() {
+			address, err := abi.NewType("address", "", nil)
+			Expect(err).ToNot(HaveOccurred())
+			allowance = types.Method{Name: "allowance", Args: []types.Field{
+				{Argument: abi.Argument{Name: "owner", Type: address}},
+				{Argument: abi.Argument{Name: "spender", Type: address}},
+			}}
+			info = contract.Contract{
+				MethodArgs: map[string]bool{},
+				Methods:    []types.Method{allowance},
+				ArgSources: map[string][]contract.ArgSource{"allowance": {{Event: "Approval", Field: "owner"}, {Event: "Approval", Field: "spender"}}},
+			}.Init()
+		})
+
+		It("Returns only the tuples emitted together", func() {
+			info.AddEmittedTuple("allowance", owner, spender)
+			info.AddEmittedTuple("allowance", owner, spender)
+
+			tuples, ok, err := info.ArgTuples(allowance, 6194634)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ok).To(BeTrue())
+			Expect(tuples).To(Equal([][]interface{}{{common.HexToAddress(owner), common.HexToAddress(spender)}}))
+			Expect(info.EmittedAddrs).To(BeNil())
+		})
+
+		It("Is not used for methods without argument sources", func() {
+			_, ok, err := info.ArgTuples(types.Method{Name: "balanceOf"}, 6194634)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ok).To(BeFalse())
+		})
+	})
 })
@@ -0,0 +1,168 @@
+// VulcanizeDB
+// Copyright © 2019 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package contract
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/vulcanize/eth-contract-watcher/pkg/types"
+)
+
+// Implementation is a contract a proxy delegates to, from the block it was upgraded to it at
+type Implementation struct {
+	Address   string  `db:"address"`
+	Block     int64   `db:"block_number"`
+	Abi       string  `db:"abi"`
+	ParsedAbi abi.ABI `db:"-"`
+}
+
+// Proxy holds the implementations a proxy contract has delegated to
+// It is shared by the copies of the contract made to poll its methods, and so guards them with a mutex
+type Proxy struct {
+	mutex           sync.RWMutex
+	abiStr          string           // The proxy's own abi, without any of its implementations' merged in
+	abi             abi.ABI          // The proxy's own parsed abi, whose methods are callable whatever the implementation
+	implementations []Implementation // Ascending by block
+	version         int              // Incremented on each upgrade, so that the copies can tell when to take up the new implementation
+}
+
+// NewProxy returns the Proxy of a contract with the given abi of its own and known implementations
+func NewProxy(proxyAbi string, proxyParsed abi.ABI, implementations ...Implementation) *Proxy {
+	p := &Proxy{abiStr: proxyAbi, abi: proxyParsed}
+	for _, impl := range implementations {
+		p.Upgrade(impl)
+	}
+
+	return p
+}
+
+// Upgrade records the proxy delegating to the implementation from its block on
+// False is returned if the proxy was already known to delegate to it at that block
+func (p *Proxy) Upgrade(impl Implementation) bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if current, ok := p.implementationAt(impl.Block); ok && sameAddress(current.Address, impl.Address) && current.Block <= impl.Block {
+		return false
+	}
+	i := sort.Search(len(p.implementations), func(i int) bool { return p.implementations[i].Block >= impl.Block })
+	p.version++
+	if i < len(p.implementations) && p.implementations[i].Block == impl.Block {
+		p.implementations[i] = impl
+		return true
+	}
+	p.implementations = append(p.implementations, Implementation{})
+	copy(p.implementations[i+1:], p.implementations[i:])
+	p.implementations[i] = impl
+
+	return true
+}
+
+// Abi returns the proxy's own abi
+func (p *Proxy) Abi() string {
+	return p.abiStr
+}
+
+// Version returns the number of upgrades recorded for the proxy
+func (p *Proxy) Version() int {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	return p.version
+}
+
+// ImplementationAt returns the implementation delegated to at the block
+// Blocks before the earliest known upgrade are taken to delegate to the earliest known implementation
+func (p *Proxy) ImplementationAt(block int64) (Implementation, bool) {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	return p.implementationAt(block)
+}
+
+func (p *Proxy) implementationAt(block int64) (Implementation, bool) {
+	if len(p.implementations) == 0 {
+		return Implementation{}, false
+	}
+	i := sort.Search(len(p.implementations), func(i int) bool { return p.implementations[i].Block > block })
+	if i == 0 {
+		return p.implementations[0], true
+	}
+
+	return p.implementations[i-1], true
+}
+
+// Implementations returns the known implementations, ascending by the block they were upgraded to at
+func (p *Proxy) Implementations() []Implementation {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+	implementations := make([]Implementation, len(p.implementations))
+	copy(implementations, p.implementations)
+
+	return implementations
+}
+
+// HasMethodAt returns whether a method with the signature, e.g. balanceOf(address), can be called at the block,
+// as a method of the proxy itself or of the implementation it delegates to
+func (p *Proxy) HasMethodAt(signature string, block int64) bool {
+	if hasMethod(p.abi, signature) {
+		return true
+	}
+	impl, ok := p.ImplementationAt(block)
+
+	return !ok || hasMethod(impl.ParsedAbi, signature)
+}
+
+func hasMethod(parsed abi.ABI, signature string) bool {
+	for _, m := range parsed.Methods {
+		if m.Sig() == signature {
+			return true
+		}
+	}
+
+	return false
+}
+
+func sameAddress(a, b string) bool {
+	return common.HexToAddress(a) == common.HexToAddress(b)
+}
+
+// OwnAbi returns the contract's own abi; that of a proxy is without the abis of its implementations merged in
+func (c *Contract) OwnAbi() string {
+	if c.Proxy == nil {
+		return c.Abi
+	}
+
+	return c.Proxy.Abi()
+}
+
+// PolledAt returns whether the method is to be polled at the block
+// The methods of a proxy are only polled at the blocks where it or its implementation has them
+func (c *Contract) PolledAt(m types.Method, block int64) bool {
+	if c.Proxy == nil {
+		return true
+	}
+	parsed, ok := c.ParsedAbi.Methods[m.Name]
+	if !ok {
+		return true
+	}
+
+	return c.Proxy.HasMethodAt(parsed.Sig(), block)
+}
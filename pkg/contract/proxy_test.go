@@ -0,0 +1,78 @@
+// VulcanizeDB
+// Copyright © 2019 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package contract_test
+
+import (
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/vulcanize/eth-contract-watcher/pkg/contract"
+	"github.com/vulcanize/eth-contract-watcher/pkg/types"
+)
+
+var _ = Describe("Proxy", func() {
+	const (
+		proxyAbi = `[{"inputs":[],"name":"admin","outputs":[{"name":"","type":"address"}],"stateMutability":"view","type":"function"}]`
+		v1Abi    = `[{"inputs":[],"name":"totalSupply","outputs":[{"name":"","type":"uint256"}],"stateMutability":"view","type":"function"}]`
+		v2Abi    = `[{"inputs":[],"name":"totalSupply","outputs":[{"name":"","type":"uint256"}],"stateMutability":"view","type":"function"},` +
+			`{"inputs":[],"name":"paused","outputs":[{"name":"","type":"bool"}],"stateMutability":"view","type":"function"}]`
+		v1 = "0x0000000000000000000000000000000000000001"
+		v2 = "0x0000000000000000000000000000000000000002"
+	)
+
+	parse := func(abiStr string) abi.ABI {
+		parsed, err := abi.JSON(strings.NewReader(abiStr))
+		Expect(err).NotTo(HaveOccurred())
+		return parsed
+	}
+
+	It("switches implementation at the blocks the proxy is upgraded at", func() {
+		proxy := contract.NewProxy(proxyAbi, parse(proxyAbi), contract.Implementation{Address: v1, Block: 100, ParsedAbi: parse(v1Abi)})
+
+		Expect(proxy.Upgrade(contract.Implementation{Address: v2, Block: 200, ParsedAbi: parse(v2Abi)})).To(BeTrue())
+		Expect(proxy.Upgrade(contract.Implementation{Address: v2, Block: 250, ParsedAbi: parse(v2Abi)})).To(BeFalse())
+
+		impl, ok := proxy.ImplementationAt(50)
+		Expect(ok).To(BeTrue())
+		Expect(impl.Address).To(Equal(v1))
+		impl, _ = proxy.ImplementationAt(199)
+		Expect(impl.Address).To(Equal(v1))
+		impl, _ = proxy.ImplementationAt(200)
+		Expect(impl.Address).To(Equal(v2))
+		Expect(proxy.Implementations()).To(HaveLen(2))
+		Expect(proxy.Version()).To(Equal(2))
+	})
+
+	It("polls the methods of a proxy only where it or its implementation has them", func() {
+		merged := parse(`[` + proxyAbi[1:len(proxyAbi)-1] + `,` + v2Abi[1:])
+		con := contract.Contract{
+			ParsedAbi: merged,
+			Proxy:     contract.NewProxy(proxyAbi, parse(proxyAbi), contract.Implementation{Address: v1, Block: 100, ParsedAbi: parse(v1Abi)}),
+		}
+		con.Proxy.Upgrade(contract.Implementation{Address: v2, Block: 200, ParsedAbi: parse(v2Abi)})
+		paused := types.NewMethod(merged.Methods["paused"])
+
+		Expect(con.PolledAt(types.NewMethod(merged.Methods["admin"]), 150)).To(BeTrue())
+		Expect(con.PolledAt(types.NewMethod(merged.Methods["totalSupply"]), 150)).To(BeTrue())
+		Expect(con.PolledAt(paused, 150)).To(BeFalse())
+		Expect(con.PolledAt(paused, 200)).To(BeTrue())
+		Expect((&contract.Contract{ParsedAbi: merged}).PolledAt(paused, 150)).To(BeTrue())
+	})
+})
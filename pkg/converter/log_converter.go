@@ -94,6 +94,8 @@ func (c *Converter) Convert(logs []gethTypes.Log, event types.Event, headerID in
 			case uint8:
 				u := input.(uint8)
 				strValues[fieldName] = strconv.Itoa(int(u))
+			case int8, int16, int32, int64, uint16, uint32, uint64:
+				strValues[fieldName] = fmt.Sprint(input)
 			case [32]uint8:
 				raw := input.([32]uint8)
 				converted := convertUintSliceToHash(raw)
@@ -126,6 +128,8 @@ func (c *Converter) Convert(logs []gethTypes.Log, event types.Event, headerID in
 			if c.ContractInfo.EmittedHashes != nil {
 				c.ContractInfo.AddEmittedHash(seenHashes...)
 			}
+			c.addEmittedValues(event, strValues)
+			c.addEmittedTuples(event, strValues)
 		}
 	}
 
@@ -178,6 +182,8 @@ func (c *Converter) ConvertBatch(logs []gethTypes.Log, events map[string]types.E
 					case uint8:
 						u := input.(uint8)
 						strValues[fieldName] = strconv.Itoa(int(u))
+					case int8, int16, int32, int64, uint16, uint32, uint64:
+						strValues[fieldName] = fmt.Sprint(input)
 					case [32]uint8:
 						raw := input.([32]uint8)
 						converted := convertUintSliceToHash(raw)
@@ -210,6 +216,9 @@ func (c *Converter) ConvertBatch(logs []gethTypes.Log, events map[string]types.E
 					if c.ContractInfo.EmittedHashes != nil {
 						c.ContractInfo.AddEmittedHash(seenHashes...)
 					}
+					c.addEmittedValues(event, strValues)
+					c.addEmittedTuples(event, strValues)
+			c.addEmittedTuples(event, strValues)
 				}
 			}
 		}
@@ -218,6 +227,35 @@ func (c *Converter) ConvertBatch(logs []gethTypes.Log, events map[string]types.E
 	return eventsToLogs, nil
 }
 
+// Caches the emitted values of the other types taken as method arguments, if their caching is turned on
+func (c *Converter) addEmittedValues(event types.Event, values map[string]string) {
+	if c.ContractInfo.EmittedValues == nil {
+		return
+	}
+	for _, field := range event.Fields {
+		if value, ok := values[field.Name]; ok {
+			c.ContractInfo.AddEmittedValue(field.Type.String(), value)
+		}
+	}
+}
+
+// Caches the tuples of values taken together from this event's logs as the arguments of a method
+func (c *Converter) addEmittedTuples(event types.Event, values map[string]string) {
+	for method, sources := range c.ContractInfo.ArgSources {
+		tuple := make([]string, 0, len(sources))
+		for _, source := range sources {
+			value, ok := values[source.Field]
+			if source.Event != event.Name || !ok {
+				break
+			}
+			tuple = append(tuple, value)
+		}
+		if len(tuple) == len(sources) {
+			c.ContractInfo.AddEmittedTuple(method, tuple...)
+		}
+	}
+}
+
 func convertUintSliceToHash(raw [32]uint8) common.Hash {
 	var asBytes []byte
 	for _, u := range raw {
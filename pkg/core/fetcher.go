@@ -17,11 +17,43 @@
 package core
 
 import (
+	"context"
+
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
 )
 
 type Fetcher interface {
 	FetchContractData(abiJSON string, address string, method string, methodArgs []interface{}, result interface{}, blockNumber int64) error
+	FetchContractDataBatch(abiJSON string, address string, calls []ContractCall, blockNumber int64) error
 	FetchEthLogsWithCustomQuery(query ethereum.FilterQuery) ([]types.Log, error)
 }
+
+// ContractCall is a single method call made as part of a batch
+// Result is unpacked into as it is by FetchContractData, and Err is set if this call alone failed
+// Err is a *RevertError if the call reverted
+type ContractCall struct {
+	Method string
+	Args   []interface{}
+	Result interface{}
+	Err    error
+}
+
+// RevertError is set on a contract call which reverted, as opposed to one which could not be made
+type RevertError struct {
+	Reason string // Decoded from the revert data, if it could be
+	Data   []byte // Raw revert data
+}
+
+func (e *RevertError) Error() string {
+	if e.Reason == "" {
+		return "execution reverted"
+	}
+	return "execution reverted: " + e.Reason
+}
+
+// BatchCaller sends several JSON-RPC requests at once, as *rpc.Client does
+type BatchCaller interface {
+	BatchCallContext(ctx context.Context, b []rpc.BatchElem) error
+}
@@ -0,0 +1,58 @@
+// VulcanizeDB
+// Copyright © 2019 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package fakes
+
+import (
+	"github.com/vulcanize/eth-contract-watcher/pkg/contract"
+	"github.com/vulcanize/eth-contract-watcher/pkg/repository"
+)
+
+type MockArgRepository struct {
+	KnownArgs     []repository.KnownArg
+	QueriedArgs   [][]string
+	PersistedArgs map[int64]map[contract.TouchedArg]bool
+}
+
+func (repository *MockArgRepository) CreateArgTable(contractAddr string) error {
+	return nil
+}
+
+func (repository *MockArgRepository) PersistArgs(contractAddr string, headerID, blockNumber int64, args map[contract.TouchedArg]bool) error {
+	if repository.PersistedArgs == nil {
+		repository.PersistedArgs = map[int64]map[contract.TouchedArg]bool{}
+	}
+	repository.PersistedArgs[headerID] = args
+	return nil
+}
+
+func (repository *MockArgRepository) LoadArgs(contractAddr string) ([]repository.KnownArg, error) {
+	return repository.KnownArgs, nil
+}
+
+func (repository *MockArgRepository) LoadArgsBetween(contractAddr string, fromBlock, toBlock int64) ([]repository.KnownArg, error) {
+	args := repository.KnownArgs[:0:0]
+	for _, arg := range repository.KnownArgs {
+		if arg.BlockNumber > fromBlock && arg.BlockNumber <= toBlock {
+			args = append(args, arg)
+		}
+	}
+	return args, nil
+}
+
+func (repository *MockArgRepository) QueryArgs(query string) ([][]string, error) {
+	return repository.QueriedArgs, nil
+}
@@ -0,0 +1,73 @@
+// VulcanizeDB
+// Copyright © 2019 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package fakes
+
+import (
+	"context"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// MockBatchCaller answers each eth_call in a batch with the next of its return values or errors
+type MockBatchCaller struct {
+	PassedBatches [][]rpc.BatchElem
+	ReturnBytes   [][]byte
+	ReturnErrs    []error
+	BatchErr      error
+}
+
+func NewMockBatchCaller() *MockBatchCaller {
+	return &MockBatchCaller{}
+}
+
+func (caller *MockBatchCaller) BatchCallContext(ctx context.Context, b []rpc.BatchElem) error {
+	caller.PassedBatches = append(caller.PassedBatches, b)
+	if caller.BatchErr != nil {
+		return caller.BatchErr
+	}
+	for i := range b {
+		if len(caller.ReturnErrs) > 0 {
+			b[i].Error = caller.ReturnErrs[0]
+			caller.ReturnErrs = caller.ReturnErrs[1:]
+		}
+		if len(caller.ReturnBytes) > 0 {
+			*b[i].Result.(*hexutil.Bytes) = caller.ReturnBytes[0]
+			caller.ReturnBytes = caller.ReturnBytes[1:]
+		}
+	}
+	return nil
+}
+
+// RPCError is a JSON-RPC error response, as the node returns for an eth_call which reverts
+type RPCError struct {
+	Code    int
+	Message string
+	Data    interface{}
+}
+
+func (err *RPCError) Error() string {
+	return err.Message
+}
+
+func (err *RPCError) ErrorCode() int {
+	return err.Code
+}
+
+func (err *RPCError) ErrorData() interface{} {
+	return err.Data
+}
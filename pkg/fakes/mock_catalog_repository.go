@@ -0,0 +1,42 @@
+// VulcanizeDB
+// Copyright © 2019 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package fakes
+
+import (
+	"github.com/vulcanize/eth-contract-watcher/pkg/contract"
+)
+
+type MockCatalogRepository struct {
+	RecordedContracts       []contract.Contract
+	RecordContractErr       error
+	RecordedImplementations []contract.Implementation
+	Implementations         []contract.Implementation
+}
+
+func (repository *MockCatalogRepository) RecordContract(con contract.Contract) error {
+	repository.RecordedContracts = append(repository.RecordedContracts, con)
+	return repository.RecordContractErr
+}
+
+func (repository *MockCatalogRepository) RecordImplementation(contractAddr string, impl contract.Implementation) error {
+	repository.RecordedImplementations = append(repository.RecordedImplementations, impl)
+	return nil
+}
+
+func (repository *MockCatalogRepository) LoadImplementations(contractAddr string) ([]contract.Implementation, error) {
+	return repository.Implementations, nil
+}
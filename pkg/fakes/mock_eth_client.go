@@ -0,0 +1,51 @@
+// VulcanizeDB
+// Copyright © 2019 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package fakes
+
+import (
+	"context"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/core/types"
+	. "github.com/onsi/gomega"
+
+	"github.com/vulcanize/eth-header-sync/pkg/fakes"
+)
+
+// MockEthClient is the eth-header-sync mock eth client, additionally recording
+// whether the context passed to FilterLogs carried a deadline
+type MockEthClient struct {
+	*fakes.MockEthClient
+	filterLogsPassedQuery    ethereum.FilterQuery
+	filterLogsPassedDeadline bool
+}
+
+func NewMockEthClient() *MockEthClient {
+	return &MockEthClient{MockEthClient: fakes.NewMockEthClient()}
+}
+
+func (client *MockEthClient) FilterLogs(ctx context.Context, q ethereum.FilterQuery) ([]types.Log, error) {
+	_, client.filterLogsPassedDeadline = ctx.Deadline()
+	client.filterLogsPassedQuery = q
+	return client.MockEthClient.FilterLogs(ctx, q)
+}
+
+// AssertFilterLogsCalledWith checks that FilterLogs was passed the query, with a context which times out
+func (client *MockEthClient) AssertFilterLogsCalledWith(q ethereum.FilterQuery) {
+	Expect(client.filterLogsPassedDeadline).To(BeTrue())
+	Expect(client.filterLogsPassedQuery).To(Equal(q))
+}
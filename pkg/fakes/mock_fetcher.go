@@ -24,6 +24,8 @@ import (
 	. "github.com/onsi/gomega"
 
 	"github.com/vulcanize/eth-header-sync/pkg/core"
+
+	cwCore "github.com/vulcanize/eth-contract-watcher/pkg/core"
 )
 
 type MockFetcher struct {
@@ -66,6 +68,15 @@ func (fethcer *MockFetcher) FetchContractData(abiJSON string, address string, me
 	return fethcer.fetchContractDataErr
 }
 
+func (fethcer *MockFetcher) FetchContractDataBatch(abiJSON string, address string, calls []cwCore.ContractCall, blockNumber int64) error {
+	for _, call := range calls {
+		if err := fethcer.FetchContractData(abiJSON, address, call.Method, call.Args, call.Result, blockNumber); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (fethcer *MockFetcher) GetEthLogsWithCustomQuery(query ethereum.FilterQuery) ([]types.Log, error) {
 	fethcer.logQuery = query
 	return fethcer.logQueryReturnLogs, fethcer.logQueryErr
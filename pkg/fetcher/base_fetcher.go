@@ -21,6 +21,7 @@ import (
 	"time"
 
 	"github.com/ethereum/go-ethereum"
+	gethAbi "github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/sirupsen/logrus"
@@ -29,11 +30,18 @@ import (
 	"github.com/vulcanize/eth-header-sync/pkg/core"
 
 	"github.com/vulcanize/eth-contract-watcher/pkg/abi"
+	cwCore "github.com/vulcanize/eth-contract-watcher/pkg/core"
 )
 
 type Fetcher struct {
 	ethClient core.EthClient
 	timeout   time.Duration
+
+	// Used to batch contract calls, see FetchContractDataBatch
+	rpcClient cwCore.BatchCaller
+	multicall common.Address
+	// Highest block at which the multicall contract was found not to be deployed
+	noMulticallAt int64
 }
 
 func NewFetcher(ethClient core.EthClient, timeout time.Duration) *Fetcher {
@@ -43,6 +51,17 @@ func NewFetcher(ethClient core.EthClient, timeout time.Duration) *Fetcher {
 	}
 }
 
+// NewBatchFetcher returns a Fetcher which batches contract calls through the multicall contract at the given address,
+// or through JSON-RPC batch requests with the rpc client; either may be left empty
+func NewBatchFetcher(ethClient core.EthClient, rpcClient cwCore.BatchCaller, multicall string, timeout time.Duration) *Fetcher {
+	f := NewFetcher(ethClient, timeout)
+	f.rpcClient = rpcClient
+	if multicall != "" {
+		f.multicall = common.HexToAddress(multicall)
+	}
+	return f
+}
+
 func (f *Fetcher) FetchEthLogsWithCustomQuery(query ethereum.FilterQuery) ([]types.Log, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), f.timeout)
 	defer cancel()
@@ -59,26 +78,42 @@ func (f *Fetcher) FetchContractData(abiJSON string, address string, method strin
 	if err != nil {
 		return err
 	}
-	var input []byte
-	if methodArgs != nil {
-		input, err = parsed.Pack(method, methodArgs...)
-	} else {
-		input, err = parsed.Pack(method)
+	input, err := pack(parsed, method, methodArgs)
+	if err != nil {
+		return err
 	}
+	output, err := f.callContract(address, input, blockArg(blockNumber))
 	if err != nil {
 		return err
 	}
-	var bn *big.Int
-	if blockNumber > 0 {
-		bn = big.NewInt(blockNumber)
+	return unpack(parsed, method, output, result)
+}
+
+func pack(parsed gethAbi.ABI, method string, methodArgs []interface{}) ([]byte, error) {
+	if methodArgs != nil {
+		return parsed.Pack(method, methodArgs...)
 	}
-	output, err := f.callContract(address, input, bn)
-	if err != nil {
+	return parsed.Pack(method)
+}
+
+func unpack(parsed gethAbi.ABI, method string, output []byte, result interface{}) error {
+	var err error
+	// A slice of interfaces receives each of the method's return values, with any tuples as structs
+	if values, ok := result.(*[]interface{}); ok {
+		*values, err = parsed.Methods[method].Outputs.UnpackValues(output)
 		return err
 	}
 	return parsed.Unpack(result, method, output)
 }
 
+// Contracts are called at the latest block if no block number is given
+func blockArg(blockNumber int64) *big.Int {
+	if blockNumber > 0 {
+		return big.NewInt(blockNumber)
+	}
+	return nil
+}
+
 func (f *Fetcher) callContract(contractHash string, input []byte, blockNumber *big.Int) ([]byte, error) {
 	to := common.HexToAddress(contractHash)
 	msg := ethereum.CallMsg{To: &to, Data: input}
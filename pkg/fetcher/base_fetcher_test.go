@@ -17,8 +17,8 @@
 package fetcher_test
 
 import (
-	"context"
 	"math/big"
+	"time"
 
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
@@ -28,18 +28,19 @@ import (
 
 	"github.com/vulcanize/eth-header-sync/pkg/fakes"
 
+	cwFakes "github.com/vulcanize/eth-contract-watcher/pkg/fakes"
 	f "github.com/vulcanize/eth-contract-watcher/pkg/fetcher"
 )
 
 var _ = Describe("Geth fetcher", func() {
 	var (
-		mockClient *fakes.MockEthClient
+		mockClient *cwFakes.MockEthClient
 		fetcher    *f.Fetcher
 	)
 
 	BeforeEach(func() {
-		mockClient = fakes.NewMockEthClient()
-		fetcher = f.NewFetcher(mockClient)
+		mockClient = cwFakes.NewMockEthClient()
+		fetcher = f.NewFetcher(mockClient, time.Second)
 	})
 
 	Describe("fetching logs with a custom FilterQuery", func() {
@@ -59,7 +60,7 @@ var _ = Describe("Geth fetcher", func() {
 			_, err := fetcher.FetchEthLogsWithCustomQuery(query)
 
 			Expect(err).NotTo(HaveOccurred())
-			mockClient.AssertFilterLogsCalledWith(context.Background(), query)
+			mockClient.AssertFilterLogsCalledWith(query)
 		})
 
 		It("returns err if ethClient returns err", func() {
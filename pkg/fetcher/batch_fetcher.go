@@ -0,0 +1,217 @@
+// VulcanizeDB
+// Copyright © 2019 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package fetcher
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/vulcanize/eth-contract-watcher/pkg/abi"
+	"github.com/vulcanize/eth-contract-watcher/pkg/constants"
+	"github.com/vulcanize/eth-contract-watcher/pkg/core"
+)
+
+// Limits on the number of calls aggregated into one multicall or one JSON-RPC batch request
+const (
+	multicallBatchSize = 500
+	rpcBatchSize       = 100
+)
+
+var multicallAbi, _ = abi.ParseAbi(constants.MulticallABI)
+
+// multicallCall and multicallResult match the Call and Result structs of the Multicall2 contract
+type multicallCall struct {
+	Target   common.Address
+	CallData []byte
+}
+
+type multicallResult struct {
+	Success    bool
+	ReturnData []byte
+}
+
+// FetchContractDataBatch makes each of the calls to the contract at the given block
+// Calls are aggregated through the multicall contract if it is configured and deployed at the block,
+// otherwise they are sent as a JSON-RPC batch request if an rpc client is available, or else one at a time
+// A call which reverts has a *core.RevertError set on it, with the reason decoded where possible
+// An error is returned if the batch as a whole fails or any call fails for a reason other than reverting,
+// so that transient failures are retried rather than recorded
+func (f *Fetcher) FetchContractDataBatch(abiJSON string, address string, calls []core.ContractCall, blockNumber int64) error {
+	parsed, err := abi.ParseAbi(abiJSON)
+	if err != nil {
+		return err
+	}
+	inputs := make([][]byte, len(calls))
+	pending := make([]int, 0, len(calls))
+	for i := range calls {
+		inputs[i], calls[i].Err = pack(parsed, calls[i].Method, calls[i].Args)
+		if calls[i].Err == nil {
+			pending = append(pending, i)
+		}
+	}
+	outputs := make([][]byte, len(calls))
+	to := common.HexToAddress(address)
+	viaMulticall := f.useMulticall(blockNumber)
+	for start := 0; start < len(pending); {
+		var end int
+		switch {
+		case viaMulticall:
+			end = min(start+multicallBatchSize, len(pending))
+			viaMulticall, err = f.multicallAt(abiJSON, to, pending[start:end], inputs, outputs, calls, blockNumber)
+			if err == nil && !viaMulticall {
+				// Try again without multicall
+				continue
+			}
+		case f.rpcClient != nil:
+			end = min(start+rpcBatchSize, len(pending))
+			err = f.batchCallAt(abiJSON, to, pending[start:end], inputs, outputs, calls, blockNumber)
+		default:
+			end = start + 1
+			i := pending[start]
+			outputs[i], err = f.callContract(address, inputs[i], blockArg(blockNumber))
+			if revert := revertFromError(abiJSON, err); revert != nil {
+				calls[i].Err, err = revert, nil
+			}
+		}
+		if err != nil {
+			return err
+		}
+		start = end
+	}
+	for _, i := range pending {
+		if calls[i].Err == nil {
+			calls[i].Err = unpack(parsed, calls[i].Method, outputs[i], calls[i].Result)
+		}
+	}
+
+	return nil
+}
+
+func (f *Fetcher) useMulticall(blockNumber int64) bool {
+	if f.multicall == (common.Address{}) {
+		return false
+	}
+	// Blocks before the multicall contract was deployed can't use it; the latest block (0) always tries
+	return blockNumber <= 0 || blockNumber > f.noMulticallAt
+}
+
+// Makes the calls at the given indexes through the multicall contract, returning false if it is not deployed at the block
+func (f *Fetcher) multicallAt(abiJSON string, to common.Address, indexes []int, inputs, outputs [][]byte, calls []core.ContractCall, blockNumber int64) (bool, error) {
+	aggregated := make([]multicallCall, len(indexes))
+	for j, i := range indexes {
+		aggregated[j] = multicallCall{Target: to, CallData: inputs[i]}
+	}
+	input, err := multicallAbi.Pack("tryAggregate", false, aggregated)
+	if err != nil {
+		return false, fmt.Errorf("error packing multicall: %s", err.Error())
+	}
+	msg := ethereum.CallMsg{To: &f.multicall, Data: input}
+	ctx, cancel := context.WithTimeout(context.Background(), f.timeout)
+	defer cancel()
+	output, err := f.ethClient.CallContract(ctx, msg, blockArg(blockNumber))
+	if err != nil {
+		return false, fmt.Errorf("error calling multicall contract: %s", err.Error())
+	}
+	// Calling an address without code returns nothing
+	if len(output) == 0 {
+		if blockNumber > f.noMulticallAt {
+			f.noMulticallAt = blockNumber
+		}
+		return false, nil
+	}
+	results, err := unpackMulticall(output)
+	if err != nil {
+		return false, err
+	}
+	if len(results) != len(indexes) {
+		return false, fmt.Errorf("error unpacking multicall result: expected %d results, got %d", len(indexes), len(results))
+	}
+	for j, i := range indexes {
+		if !results[j].Success {
+			// Only reverts are caught by tryAggregate
+			calls[i].Err = newRevertError(abiJSON, results[j].ReturnData)
+			continue
+		}
+		outputs[i] = results[j].ReturnData
+	}
+
+	return true, nil
+}
+
+// The unpacked results are anonymous structs, so their fields are read by name
+func unpackMulticall(output []byte) ([]multicallResult, error) {
+	values, err := multicallAbi.Methods["tryAggregate"].Outputs.UnpackValues(output)
+	if err != nil {
+		return nil, fmt.Errorf("error unpacking multicall result: %s", err.Error())
+	}
+	v := reflect.ValueOf(values[0])
+	results := make([]multicallResult, v.Len())
+	for i := range results {
+		results[i].Success = v.Index(i).FieldByName("Success").Bool()
+		results[i].ReturnData = v.Index(i).FieldByName("ReturnData").Bytes()
+	}
+
+	return results, nil
+}
+
+// Makes the calls at the given indexes as a single JSON-RPC batch of eth_call requests
+func (f *Fetcher) batchCallAt(abiJSON string, to common.Address, indexes []int, inputs, outputs [][]byte, calls []core.ContractCall, blockNumber int64) error {
+	block := blockTag(blockNumber)
+	batch := make([]rpc.BatchElem, len(indexes))
+	results := make([]hexutil.Bytes, len(indexes))
+	for j, i := range indexes {
+		batch[j] = rpc.BatchElem{
+			Method: "eth_call",
+			Args: []interface{}{
+				map[string]interface{}{"to": to, "data": hexutil.Bytes(inputs[i])},
+				block,
+			},
+			Result: &results[j],
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), f.timeout)
+	defer cancel()
+	if err := f.rpcClient.BatchCallContext(ctx, batch); err != nil {
+		return fmt.Errorf("error making batch eth_call request: %s", err.Error())
+	}
+	for j, i := range indexes {
+		if batch[j].Error != nil {
+			revert := revertFromError(abiJSON, batch[j].Error)
+			if revert == nil {
+				return fmt.Errorf("error making batch eth_call request: %s", batch[j].Error.Error())
+			}
+			calls[i].Err = revert
+			continue
+		}
+		outputs[i] = results[j]
+	}
+
+	return nil
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
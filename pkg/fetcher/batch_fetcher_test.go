@@ -0,0 +1,162 @@
+// VulcanizeDB
+// Copyright © 2019 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package fetcher_test
+
+import (
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/vulcanize/eth-header-sync/pkg/fakes"
+
+	"github.com/vulcanize/eth-contract-watcher/pkg/abi"
+	"github.com/vulcanize/eth-contract-watcher/pkg/constants"
+	"github.com/vulcanize/eth-contract-watcher/pkg/core"
+	cwFakes "github.com/vulcanize/eth-contract-watcher/pkg/fakes"
+	f "github.com/vulcanize/eth-contract-watcher/pkg/fetcher"
+)
+
+var _ = Describe("Batch fetcher", func() {
+	const (
+		tokenAbi  = `[{"constant":true,"inputs":[{"name":"_owner","type":"address"}],"name":"balanceOf","outputs":[{"name":"balance","type":"uint256"}],"payable":false,"type":"function"}]`
+		token     = "0x8dd5fbCe2F6a956C3022bA3663759011Dd51e73E"
+		multicall = "0x5BA1e12693Dc8F9c48aAD8770482f4739bEeD696"
+	)
+	var (
+		mockClient *fakes.MockEthClient
+		rpcClient  *cwFakes.MockBatchCaller
+		calls      []core.ContractCall
+		results    []*big.Int
+	)
+
+	uint256 := func(i int64) []byte {
+		return common.LeftPadBytes(big.NewInt(i).Bytes(), 32)
+	}
+
+	BeforeEach(func() {
+		mockClient = fakes.NewMockEthClient()
+		rpcClient = cwFakes.NewMockBatchCaller()
+		results = make([]*big.Int, 2)
+		calls = make([]core.ContractCall, 2)
+		for i := range calls {
+			calls[i] = core.ContractCall{
+				Method: "balanceOf",
+				Args:   []interface{}{common.BigToAddress(big.NewInt(int64(i + 1)))},
+				Result: &results[i],
+			}
+		}
+	})
+
+	It("aggregates calls through the multicall contract", func() {
+		parsed, err := abi.ParseAbi(constants.MulticallABI)
+		Expect(err).NotTo(HaveOccurred())
+		output, err := parsed.Methods["tryAggregate"].Outputs.Pack([]struct {
+			Success    bool
+			ReturnData []byte
+		}{{true, uint256(100)}, {false, []byte{}}})
+		Expect(err).NotTo(HaveOccurred())
+		mockClient.SetCallContractReturnBytes(output)
+		fetcher := f.NewBatchFetcher(mockClient, rpcClient, multicall, time.Second)
+
+		err = fetcher.FetchContractDataBatch(tokenAbi, token, calls, 6194634)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(rpcClient.PassedBatches).To(BeEmpty())
+		Expect(calls[0].Err).NotTo(HaveOccurred())
+		Expect(results[0].String()).To(Equal("100"))
+		_, reverted := calls[1].Err.(*core.RevertError)
+		Expect(reverted).To(BeTrue())
+	})
+
+	It("falls back to a JSON-RPC batch where the multicall contract isn't deployed", func() {
+		rpcClient.ReturnBytes = [][]byte{uint256(100), uint256(200)}
+		fetcher := f.NewBatchFetcher(mockClient, rpcClient, multicall, time.Second)
+
+		err := fetcher.FetchContractDataBatch(tokenAbi, token, calls, 6194634)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(rpcClient.PassedBatches).To(HaveLen(1))
+		Expect(rpcClient.PassedBatches[0]).To(HaveLen(2))
+		Expect(rpcClient.PassedBatches[0][0].Method).To(Equal("eth_call"))
+		Expect(rpcClient.PassedBatches[0][0].Args[1]).To(Equal(hexutil.EncodeUint64(6194634)))
+		Expect(results[0].String()).To(Equal("100"))
+		Expect(results[1].String()).To(Equal("200"))
+	})
+
+	It("sets a revert error on each call that reverts within a batch", func() {
+		reason, err := hexutil.Decode("0x08c379a0" +
+			"0000000000000000000000000000000000000000000000000000000000000020" +
+			"000000000000000000000000000000000000000000000000000000000000000b" +
+			"6e6f7420616c6c6f776564000000000000000000000000000000000000000000")
+		Expect(err).NotTo(HaveOccurred())
+		rpcClient.ReturnErrs = []error{&cwFakes.RPCError{Code: 3, Message: "execution reverted: not allowed", Data: hexutil.Encode(reason)}, nil}
+		rpcClient.ReturnBytes = [][]byte{nil, uint256(200)}
+		fetcher := f.NewBatchFetcher(mockClient, rpcClient, "", time.Second)
+
+		err = fetcher.FetchContractDataBatch(tokenAbi, token, calls, 6194634)
+
+		Expect(err).NotTo(HaveOccurred())
+		revert, ok := calls[0].Err.(*core.RevertError)
+		Expect(ok).To(BeTrue())
+		Expect(revert.Reason).To(Equal("not allowed"))
+		Expect(revert.Data).To(Equal(reason))
+		Expect(calls[1].Err).NotTo(HaveOccurred())
+		Expect(results[1].String()).To(Equal("200"))
+	})
+
+	It("decodes panics and custom errors from revert data", func() {
+		customAbi := tokenAbi[:len(tokenAbi)-1] +
+			`,{"type":"error","name":"InsufficientBalance","inputs":[{"name":"available","type":"uint256"},{"name":"required","type":"uint256"}]}]`
+		custom := append(crypto.Keccak256([]byte("InsufficientBalance(uint256,uint256)"))[:4], append(uint256(1), uint256(2)...)...)
+		panicked := append(crypto.Keccak256([]byte("Panic(uint256)"))[:4], uint256(0x11)...)
+		rpcClient.ReturnErrs = []error{
+			&cwFakes.RPCError{Code: 3, Message: "execution reverted", Data: hexutil.Encode(custom)},
+			&cwFakes.RPCError{Code: 3, Message: "execution reverted", Data: hexutil.Encode(panicked)},
+		}
+		fetcher := f.NewBatchFetcher(mockClient, rpcClient, "", time.Second)
+
+		err := fetcher.FetchContractDataBatch(customAbi, token, calls, 6194634)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(calls[0].Err.(*core.RevertError).Reason).To(Equal("InsufficientBalance(1, 2)"))
+		Expect(calls[1].Err.(*core.RevertError).Reason).To(Equal("Panic(0x11): arithmetic underflow or overflow"))
+	})
+
+	It("returns an error if a call fails for a reason other than reverting", func() {
+		rpcClient.ReturnErrs = []error{&cwFakes.RPCError{Code: -32000, Message: "header not found"}, nil}
+		fetcher := f.NewBatchFetcher(mockClient, rpcClient, "", time.Second)
+
+		err := fetcher.FetchContractDataBatch(tokenAbi, token, calls, 6194634)
+
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("header not found"))
+	})
+
+	It("returns an error if the whole batch fails", func() {
+		rpcClient.BatchErr = fakes.FakeError
+		fetcher := f.NewBatchFetcher(mockClient, rpcClient, "", time.Second)
+
+		err := fetcher.FetchContractDataBatch(tokenAbi, token, calls, 6194634)
+
+		Expect(err).To(HaveOccurred())
+	})
+})
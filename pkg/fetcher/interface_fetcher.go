@@ -18,75 +18,190 @@ package fetcher
 
 import (
 	"fmt"
+	"reflect"
+	"strings"
 
+	gethAbi "github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/sirupsen/logrus"
+
+	"github.com/vulcanize/eth-contract-watcher/pkg/abi"
 	"github.com/vulcanize/eth-contract-watcher/pkg/constants"
+	"github.com/vulcanize/eth-contract-watcher/pkg/core"
 )
 
 // InterfaceFetcher is used to derive the interface of a contract
 type InterfaceFetcher interface {
-	FetchABI(resolverAddr string, blockNumber int64) (string, error)
+	FetchABI(contractAddr string, blockNumber int64) (string, error)
+	DetectStandards(contractAddr string, blockNumber int64) ([]constants.Standard, error)
+}
+
+// FetchABI is used to construct a custom ABI from the fragments of the standards the contract implements
+// An error wrapping abi.ErrAbiNotFound is returned if it implements none of them
+func (f *Fetcher) FetchABI(contractAddr string, blockNumber int64) (string, error) {
+	standards, err := f.DetectStandards(contractAddr, blockNumber)
+	if err != nil {
+		return "", err
+	}
+
+	return mergeStandards(standards)
 }
 
-// FetchABI is used to construct a custom ABI based on the results from calling supportsInterface
-func (f *Fetcher) FetchABI(resolverAddr string, blockNumber int64) (string, error) {
-	a := constants.SupportsInterfaceABI
-	args := make([]interface{}, 1)
-	args[0] = constants.MetaSig.Bytes()
-	supports, err := f.getSupportsInterface(a, resolverAddr, blockNumber, args)
+// DetectStandards returns the registered standards which the contract implements at the block, in registry order
+// Standards with an interface id are checked with supportsInterface if the contract implements ERC-165,
+// and those without by calling their probes
+func (f *Fetcher) DetectStandards(contractAddr string, blockNumber int64) ([]constants.Standard, error) {
+	supported, err := f.supportedInterfaces(contractAddr, blockNumber)
 	if err != nil {
-		return "", fmt.Errorf("call to getSupportsInterface failed: %v", err)
+		return nil, fmt.Errorf("call to supportsInterface failed: %s", err.Error())
 	}
-	if !supports {
-		return "", fmt.Errorf("contract does not support interface")
+	detected := make([]constants.Standard, 0)
+	for _, standard := range constants.Standards {
+		if standard.InterfaceID != "" {
+			if supported[interfaceID(standard.InterfaceID)] {
+				detected = append(detected, standard)
+			}
+			continue
+		}
+		implements, err := f.probe(standard, contractAddr, blockNumber)
+		if err != nil {
+			return nil, fmt.Errorf("error probing %s for %s: %s", contractAddr, standard.Name, err.Error())
+		}
+		if implements {
+			detected = append(detected, standard)
+		}
 	}
 
-	abiStr := `[`
-	args[0] = constants.AddrChangeSig.Bytes()
-	supports, err = f.getSupportsInterface(a, resolverAddr, blockNumber, args)
-	if err == nil && supports {
-		abiStr += constants.AddrChangeInterface + ","
+	return detected, nil
+}
+
+// Returns the registered interface ids the contract supports, none if it does not implement ERC-165
+// ERC-165 requires supportsInterface to be true for its own id and false for 0xffffffff
+func (f *Fetcher) supportedInterfaces(contractAddr string, blockNumber int64) (map[[4]byte]bool, error) {
+	supported := make(map[[4]byte]bool)
+	ids := [][4]byte{interfaceID(constants.ERC165InterfaceID), interfaceID(constants.InvalidInterfaceID)}
+	results, err := f.supportsInterfaces(contractAddr, blockNumber, ids)
+	if err != nil {
+		return nil, err
 	}
-	args[0] = constants.NameChangeSig.Bytes()
-	supports, err = f.getSupportsInterface(a, resolverAddr, blockNumber, args)
-	if err == nil && supports {
-		abiStr += constants.NameChangeInterface + ","
+	if !results[0] || results[1] {
+		return supported, nil
 	}
-	args[0] = constants.ContentChangeSig.Bytes()
-	supports, err = f.getSupportsInterface(a, resolverAddr, blockNumber, args)
-	if err == nil && supports {
-		abiStr += constants.ContentChangeInterface + ","
+	ids = ids[:0]
+	for _, standard := range constants.Standards {
+		if standard.InterfaceID != "" {
+			ids = append(ids, interfaceID(standard.InterfaceID))
+		}
+	}
+	results, err = f.supportsInterfaces(contractAddr, blockNumber, ids)
+	if err != nil {
+		return nil, err
 	}
-	args[0] = constants.AbiChangeSig.Bytes()
-	supports, err = f.getSupportsInterface(a, resolverAddr, blockNumber, args)
-	if err == nil && supports {
-		abiStr += constants.AbiChangeInterface + ","
+	for i, id := range ids {
+		supported[id] = results[i]
 	}
-	args[0] = constants.PubkeyChangeSig.Bytes()
-	supports, err = f.getSupportsInterface(a, resolverAddr, blockNumber, args)
-	if err == nil && supports {
-		abiStr += constants.PubkeyChangeInterface + ","
+
+	return supported, nil
+}
+
+// Calls supportsInterface for each of the ids in one batch, a call which reverts counting as unsupported
+func (f *Fetcher) supportsInterfaces(contractAddr string, blockNumber int64, ids [][4]byte) ([]bool, error) {
+	results := make([]bool, len(ids))
+	calls := make([]core.ContractCall, len(ids))
+	for i, id := range ids {
+		calls[i] = core.ContractCall{Method: "supportsInterface", Args: []interface{}{id}, Result: &results[i]}
+	}
+	if err := f.FetchContractDataBatch(constants.SupportsInterfaceABI, contractAddr, calls, blockNumber); err != nil {
+		return nil, err
+	}
+	for i := range calls {
+		results[i] = results[i] && calls[i].Err == nil
+	}
+
+	return results, nil
+}
+
+// Calls each of the standard's probes with zero-valued arguments, the contract implementing it if none fail
+func (f *Fetcher) probe(standard constants.Standard, contractAddr string, blockNumber int64) (bool, error) {
+	parsed, err := abi.ParseAbi(standard.Abi)
+	if err != nil {
+		return false, err
 	}
-	args[0] = constants.ContentHashChangeSig.Bytes()
-	supports, err = f.getSupportsInterface(a, resolverAddr, blockNumber, args)
-	if err == nil && supports {
-		abiStr += constants.ContenthashChangeInterface + ","
+	calls := make([]core.ContractCall, len(standard.Probes))
+	for i, name := range standard.Probes {
+		method, ok := parsed.Methods[name]
+		if !ok {
+			return false, fmt.Errorf("probe %s is not in the abi", name)
+		}
+		calls[i] = core.ContractCall{Method: name, Args: zeroArgs(method.Inputs), Result: new(interface{})}
 	}
-	args[0] = constants.MultihashChangeSig.Bytes()
-	supports, err = f.getSupportsInterface(a, resolverAddr, blockNumber, args)
-	if err == nil && supports {
-		abiStr += constants.MultihashChangeInterface + ","
+	if err := f.FetchContractDataBatch(standard.Abi, contractAddr, calls, blockNumber); err != nil {
+		return false, err
 	}
-	args[0] = constants.TextChangeSig.Bytes()
-	supports, err = f.getSupportsInterface(a, resolverAddr, blockNumber, args)
-	if err == nil && supports {
-		abiStr += constants.TextChangeInterface + ","
+	for _, call := range calls {
+		if call.Err != nil {
+			return false, nil
+		}
 	}
-	abiStr = abiStr[:len(abiStr)-1] + `]`
 
-	return abiStr, nil
+	return true, nil
 }
 
-// Use this method to check whether or not a contract supports a given method/event interface
-func (f *Fetcher) getSupportsInterface(contractAbi, contractAddress string, blockNumber int64, methodArgs []interface{}) (bool, error) {
-	return f.FetchBool("supportsInterface", contractAbi, contractAddress, blockNumber, methodArgs)
+func zeroArgs(inputs gethAbi.Arguments) []interface{} {
+	args := make([]interface{}, len(inputs))
+	for i, input := range inputs {
+		if t := input.Type.Type; t.Kind() == reflect.Ptr {
+			args[i] = reflect.New(t.Elem()).Interface()
+		} else {
+			args[i] = reflect.Zero(t).Interface()
+		}
+	}
+
+	return args
+}
+
+func interfaceID(hex string) [4]byte {
+	var id [4]byte
+	copy(id[:], hexutil.MustDecode(hex))
+	return id
+}
+
+// Merges the abi fragments of the standards, in order
+func mergeStandards(standards []constants.Standard) (string, error) {
+	if len(standards) == 0 {
+		return "", fmt.Errorf("%w: contract does not implement any known standard", abi.ErrAbiNotFound)
+	}
+	abis := make([]string, len(standards))
+	for i, standard := range standards {
+		abis[i] = standard.Abi
+	}
+
+	return abi.MergeAbis(abis...)
+}
+
+// NewStandardsProvider returns an abi provider which classifies contracts by the standards they implement,
+// supplying the merged abi fragments of those standards
+func NewStandardsProvider(interfaces InterfaceFetcher) abi.AbiProvider {
+	return &standardsProvider{interfaces: interfaces}
+}
+
+type standardsProvider struct {
+	interfaces InterfaceFetcher
+}
+
+// GetAbi returns the abi of the standards the contract implements at the latest block
+func (p *standardsProvider) GetAbi(contractAddr string) (string, error) {
+	standards, err := p.interfaces.DetectStandards(contractAddr, -1)
+	if err != nil {
+		return "", err
+	}
+	names := make([]string, len(standards))
+	for i, standard := range standards {
+		names[i] = standard.Name
+	}
+	if len(names) > 0 {
+		logrus.Infof("classified %s as implementing %s", contractAddr, strings.Join(names, ", "))
+	}
+
+	return mergeStandards(standards)
 }
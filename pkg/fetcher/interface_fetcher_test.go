@@ -0,0 +1,150 @@
+// VulcanizeDB
+// Copyright © 2019 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package fetcher_test
+
+import (
+	"errors"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/vulcanize/eth-header-sync/pkg/fakes"
+
+	a "github.com/vulcanize/eth-contract-watcher/pkg/abi"
+	"github.com/vulcanize/eth-contract-watcher/pkg/constants"
+	cwFakes "github.com/vulcanize/eth-contract-watcher/pkg/fakes"
+	f "github.com/vulcanize/eth-contract-watcher/pkg/fetcher"
+)
+
+var _ = Describe("Interface fetcher", func() {
+	const contract = "0x314159265dD8dbb310642f98f50C066173C1259b"
+	var (
+		rpcClient *cwFakes.MockBatchCaller
+		fetcher   *f.Fetcher
+	)
+
+	word := func(b byte) []byte {
+		return common.LeftPadBytes([]byte{b}, 32)
+	}
+	reverted := []byte{}
+	erc165 := [][]byte{word(1), word(0)}
+	// Answers supportsInterface for each of the registered interface ids
+	supporting := func(ids ...string) [][]byte {
+		answers := make([][]byte, 0)
+		for _, standard := range constants.Standards {
+			if standard.InterfaceID == "" {
+				continue
+			}
+			answer := word(0)
+			for _, id := range ids {
+				if id == standard.InterfaceID {
+					answer = word(1)
+				}
+			}
+			answers = append(answers, answer)
+		}
+		return answers
+	}
+	names := func(standards []constants.Standard) []string {
+		names := make([]string, len(standards))
+		for i, standard := range standards {
+			names[i] = standard.Name
+		}
+		return names
+	}
+	notERC4626 := [][]byte{reverted, reverted}
+	notERC20 := [][]byte{reverted, reverted, reverted}
+
+	BeforeEach(func() {
+		rpcClient = cwFakes.NewMockBatchCaller()
+		fetcher = f.NewBatchFetcher(fakes.NewMockEthClient(), rpcClient, "", time.Second)
+	})
+
+	It("detects the interfaces an ERC-165 contract supports", func() {
+		rpcClient.ReturnBytes = append(erc165, supporting("0x80ac58cd", "0x5b5e139f", "0x2a55205a")...)
+		rpcClient.ReturnBytes = append(append(rpcClient.ReturnBytes, notERC4626...), notERC20...)
+
+		standards, err := fetcher.DetectStandards(contract, 6885696)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(names(standards)).To(Equal([]string{"ERC-721", "ERC-721 Metadata", "ERC-2981"}))
+		Expect(rpcClient.PassedBatches).To(HaveLen(4))
+		Expect(rpcClient.PassedBatches[1]).To(HaveLen(14))
+	})
+
+	It("classifies contracts without ERC-165 by probing their views", func() {
+		rpcClient.ReturnBytes = append([][]byte{reverted, reverted}, notERC4626...)
+		rpcClient.ReturnBytes = append(rpcClient.ReturnBytes, word(0), word(0), word(0))
+
+		standards, err := fetcher.DetectStandards(contract, -1)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(names(standards)).To(Equal([]string{"ERC-20"}))
+		Expect(rpcClient.PassedBatches).To(HaveLen(3))
+		Expect(rpcClient.PassedBatches[1]).To(HaveLen(2))
+		Expect(rpcClient.PassedBatches[2]).To(HaveLen(3))
+	})
+
+	It("does not trust contracts which claim to support the invalid interface id", func() {
+		rpcClient.ReturnBytes = append([][]byte{word(1), word(1)}, notERC4626...)
+		rpcClient.ReturnBytes = append(rpcClient.ReturnBytes, notERC20...)
+
+		standards, err := fetcher.DetectStandards(contract, -1)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(standards).To(BeEmpty())
+		Expect(rpcClient.PassedBatches).To(HaveLen(3))
+	})
+
+	It("constructs the abi of ENS resolvers from their events", func() {
+		rpcClient.ReturnBytes = append(erc165, supporting(
+			constants.AddrChangeSig.Hex(),
+			constants.NameChangeSig.Hex(),
+			constants.ContentChangeSig.Hex(),
+			constants.AbiChangeSig.Hex(),
+			constants.PubkeyChangeSig.Hex(),
+		)...)
+		rpcClient.ReturnBytes = append(append(rpcClient.ReturnBytes, notERC4626...), notERC20...)
+
+		abi, err := fetcher.FetchABI(contract, 6885696)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(abi).To(Equal(`[` + constants.AddrChangeInterface + `,` + constants.NameChangeInterface + `,` + constants.ContentChangeInterface + `,` + constants.AbiChangeInterface + `,` + constants.PubkeyChangeInterface + `]`))
+	})
+
+	It("provides the merged abis of the standards a contract implements", func() {
+		rpcClient.ReturnBytes = [][]byte{reverted, reverted, word(1), word(1), word(0), word(0), word(0)}
+
+		abiStr, err := f.NewStandardsProvider(fetcher).GetAbi(contract)
+
+		Expect(err).NotTo(HaveOccurred())
+		parsed, err := a.ParseAbi(abiStr)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(parsed.Events).To(HaveKey("Deposit"))
+		Expect(parsed.Events).To(HaveKey("Transfer"))
+		Expect(parsed.Methods).To(HaveKey("totalAssets"))
+		Expect(parsed.Methods).To(HaveKey("decimals"))
+	})
+
+	It("provides no abi for contracts implementing no known standard", func() {
+		_, err := f.NewStandardsProvider(fetcher).GetAbi(contract)
+
+		Expect(errors.Is(err, a.ErrAbiNotFound)).To(BeTrue())
+	})
+})
@@ -17,7 +17,7 @@
 package fetcher_test
 
 import (
-	"context"
+	"time"
 
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
@@ -27,14 +27,15 @@ import (
 	"github.com/vulcanize/eth-header-sync/pkg/core"
 	"github.com/vulcanize/eth-header-sync/pkg/fakes"
 
+	cwFakes "github.com/vulcanize/eth-contract-watcher/pkg/fakes"
 	f "github.com/vulcanize/eth-contract-watcher/pkg/fetcher"
 )
 
 var _ = Describe("Fetcher", func() {
 	Describe("FetchLogs", func() {
 		It("fetches logs based on the given query", func() {
-			mockClient := fakes.NewMockEthClient()
-			fetcher := f.NewFetcher(mockClient)
+			mockClient := cwFakes.NewMockEthClient()
+			fetcher := f.NewFetcher(mockClient, time.Second)
 			header := fakes.FakeHeader
 
 			addresses := []string{"0xfakeAddress", "0xanotherFakeAddress"}
@@ -52,13 +53,13 @@ var _ = Describe("Fetcher", func() {
 				Addresses: []common.Address{address1, address2},
 				Topics:    topicZeros,
 			}
-			mockClient.AssertFilterLogsCalledWith(context.Background(), expectedQuery)
+			mockClient.AssertFilterLogsCalledWith(expectedQuery)
 		})
 
 		It("returns an error if fetching the logs fails", func() {
-			mockClient := fakes.NewMockEthClient()
+			mockClient := cwFakes.NewMockEthClient()
 			mockClient.SetFilterLogsErr(fakes.FakeError)
-			fetcher := f.NewFetcher(mockClient)
+			fetcher := f.NewFetcher(mockClient, time.Second)
 
 			_, err := fetcher.FetchLogs([]string{}, []common.Hash{}, core.Header{})
 
@@ -0,0 +1,135 @@
+// VulcanizeDB
+// Copyright © 2019 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package fetcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// Storage slots in which proxies keep the address of the implementation they delegate to
+var (
+	// EIP-1967, bytes32(uint256(keccak256('eip1967.proxy.implementation')) - 1)
+	ImplementationSlot = common.HexToHash("0x360894a13ba1a3210667c828492db98dca3e2076cc3735a920a3ca505d382bbc")
+	// EIP-1822, keccak256('PROXIABLE')
+	ProxiableSlot = common.HexToHash("0xc5f16f0fcc639fa48a6947836d9850f504798523bf8c9a3a87d5876cf622bcf7")
+	// OpenZeppelin proxies from before EIP-1967, keccak256('org.zeppelinos.proxy.implementation')
+	ZeppelinImplementationSlot = common.HexToHash("0x7050c9e0f4ca769c69bd3a8ef740bc37934f8e2c036e5a723fd8ee048ed3f8c3")
+	// EIP-1967 beacon proxies keep the address of a beacon which holds the implementation,
+	// bytes32(uint256(keccak256('eip1967.proxy.beacon')) - 1)
+	BeaconSlot = common.HexToHash("0xa3f0ad74e5423aebfd80d3ef4346578335a9a72aeaee59ff6cb3582b35133d50")
+)
+
+// Topics of the events proxies emit when they are upgraded
+var (
+	UpgradedTopic       = crypto.Keccak256Hash([]byte("Upgraded(address)"))
+	BeaconUpgradedTopic = crypto.Keccak256Hash([]byte("BeaconUpgraded(address)"))
+)
+
+// Selector of the beacon's `implementation()` method
+var implementationSelector = crypto.Keccak256([]byte("implementation()"))[:4]
+
+// ProxyFetcher is used to find the implementation a proxy contract delegates to
+type ProxyFetcher interface {
+	FetchImplementation(proxyAddr string, blockNumber int64) (string, error)
+	FetchBeaconImplementation(beaconAddr string, blockNumber int64) (string, error)
+}
+
+// FetchImplementation reads the address of the implementation the proxy delegates to at the block
+// from the EIP-1967, EIP-1822 or OpenZeppelin implementation slots, or from the beacon in the EIP-1967 beacon slot
+// An empty address is returned if the contract is not a proxy
+func (f *Fetcher) FetchImplementation(proxyAddr string, blockNumber int64) (string, error) {
+	slots := []common.Hash{ImplementationSlot, ProxiableSlot, ZeppelinImplementationSlot, BeaconSlot}
+	batch := make([]rpc.BatchElem, len(slots))
+	values := make([]hexutil.Bytes, len(slots))
+	for i, slot := range slots {
+		batch[i] = rpc.BatchElem{
+			Method: "eth_getStorageAt",
+			Args:   []interface{}{common.HexToAddress(proxyAddr), slot, blockTag(blockNumber)},
+			Result: &values[i],
+		}
+	}
+	err := f.batch(batch)
+	if err != nil {
+		return "", fmt.Errorf("error reading proxy slots of %s: %s", proxyAddr, err.Error())
+	}
+	for i, value := range values[:len(values)-1] {
+		if batch[i].Error != nil {
+			return "", fmt.Errorf("error reading proxy slots of %s: %s", proxyAddr, batch[i].Error.Error())
+		}
+		if addr := common.BytesToAddress(value); addr != (common.Address{}) {
+			return addr.Hex(), nil
+		}
+	}
+	beacon := common.BytesToAddress(values[len(values)-1])
+	if batch[len(batch)-1].Error != nil || beacon == (common.Address{}) {
+		return "", nil
+	}
+
+	return f.FetchBeaconImplementation(beacon.Hex(), blockNumber)
+}
+
+// FetchBeaconImplementation calls the beacon for the implementation its proxies delegate to at the block
+func (f *Fetcher) FetchBeaconImplementation(beaconAddr string, blockNumber int64) (string, error) {
+	var output hexutil.Bytes
+	batch := []rpc.BatchElem{{
+		Method: "eth_call",
+		Args: []interface{}{
+			map[string]interface{}{"to": common.HexToAddress(beaconAddr), "data": hexutil.Bytes(implementationSelector)},
+			blockTag(blockNumber),
+		},
+		Result: &output,
+	}}
+	err := f.batch(batch)
+	if err == nil {
+		err = batch[0].Error
+	}
+	if err != nil {
+		return "", fmt.Errorf("error calling beacon %s for its implementation: %s", beaconAddr, err.Error())
+	}
+
+	implementation := common.BytesToAddress(output)
+	if implementation == (common.Address{}) {
+		return "", nil
+	}
+
+	return implementation.Hex(), nil
+}
+
+func (f *Fetcher) batch(batch []rpc.BatchElem) error {
+	if f.rpcClient == nil {
+		return errors.New("no rpc client to read proxies with")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), f.timeout)
+	defer cancel()
+
+	return f.rpcClient.BatchCallContext(ctx, batch)
+}
+
+// Requests are made at the latest block if no block number is given
+func blockTag(blockNumber int64) string {
+	if blockNumber > 0 {
+		return hexutil.EncodeUint64(uint64(blockNumber))
+	}
+	return "latest"
+}
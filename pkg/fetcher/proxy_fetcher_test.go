@@ -0,0 +1,90 @@
+// VulcanizeDB
+// Copyright © 2019 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package fetcher_test
+
+import (
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/vulcanize/eth-header-sync/pkg/fakes"
+
+	cwFakes "github.com/vulcanize/eth-contract-watcher/pkg/fakes"
+	f "github.com/vulcanize/eth-contract-watcher/pkg/fetcher"
+)
+
+var _ = Describe("Proxy fetcher", func() {
+	const (
+		proxy          = "0x8dd5fbCe2F6a956C3022bA3663759011Dd51e73E"
+		implementation = "0x5BA1e12693Dc8F9c48aAD8770482f4739bEeD696"
+		beacon         = "0x1234567890123456789012345678901234567890"
+	)
+	var (
+		rpcClient *cwFakes.MockBatchCaller
+		fetcher   *f.Fetcher
+	)
+
+	slot := func(addr string) []byte {
+		return common.LeftPadBytes(common.HexToAddress(addr).Bytes(), 32)
+	}
+	empty := make([]byte, 32)
+
+	BeforeEach(func() {
+		rpcClient = cwFakes.NewMockBatchCaller()
+		fetcher = f.NewBatchFetcher(fakes.NewMockEthClient(), rpcClient, "", time.Second)
+	})
+
+	It("reads the implementation from the proxy's storage at the block", func() {
+		rpcClient.ReturnBytes = [][]byte{empty, empty, slot(implementation), empty}
+
+		impl, err := fetcher.FetchImplementation(proxy, 6194634)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(impl).To(Equal(implementation))
+		Expect(rpcClient.PassedBatches).To(HaveLen(1))
+		batch := rpcClient.PassedBatches[0]
+		Expect(batch).To(HaveLen(4))
+		Expect(batch[0].Method).To(Equal("eth_getStorageAt"))
+		Expect(batch[0].Args).To(Equal([]interface{}{common.HexToAddress(proxy), f.ImplementationSlot, "0x5e85ca"}))
+		Expect(batch[1].Args[1]).To(Equal(f.ProxiableSlot))
+		Expect(batch[2].Args[1]).To(Equal(f.ZeppelinImplementationSlot))
+	})
+
+	It("reads the implementation from the beacon of beacon proxies", func() {
+		rpcClient.ReturnBytes = [][]byte{empty, empty, empty, slot(beacon), slot(implementation)}
+
+		impl, err := fetcher.FetchImplementation(proxy, -1)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(impl).To(Equal(implementation))
+		Expect(rpcClient.PassedBatches).To(HaveLen(2))
+		call := rpcClient.PassedBatches[1][0]
+		Expect(call.Method).To(Equal("eth_call"))
+		Expect(call.Args[1]).To(Equal("latest"))
+	})
+
+	It("returns no implementation for contracts which are not proxies", func() {
+		rpcClient.ReturnBytes = [][]byte{empty, empty, empty, empty}
+
+		impl, err := fetcher.FetchImplementation(proxy, 6194634)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(impl).To(BeEmpty())
+	})
+})
@@ -0,0 +1,212 @@
+// VulcanizeDB
+// Copyright © 2019 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package fetcher
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/vulcanize/eth-contract-watcher/pkg/core"
+)
+
+// JSON-RPC error code geth uses for reverts which carry data
+const revertErrorCode = 3
+
+var (
+	errorSelector = crypto.Keccak256([]byte("Error(string)"))[:4]
+	panicSelector = crypto.Keccak256([]byte("Panic(uint256)"))[:4]
+)
+
+// Descriptions of the codes solidity panics with
+var panicReasons = map[uint64]string{
+	0x00: "generic panic",
+	0x01: "assertion failed",
+	0x11: "arithmetic underflow or overflow",
+	0x12: "division or modulo by zero",
+	0x21: "enum overflow",
+	0x22: "invalid encoded storage byte array",
+	0x31: "pop on empty array",
+	0x32: "array index out of bounds",
+	0x41: "out of memory",
+	0x51: "call to uninitialized function",
+}
+
+// Messages nodes give for calls which reverted or otherwise failed during execution, when no error code identifies them
+var revertMessages = []string{"revert", "invalid opcode", "vm execution error", "vm exception", "invalid jump"}
+
+// Returns the revert error for a failed call's error, or nil if the call failed for some other reason
+// (e.g. the node could not be reached), in which case it should be retried
+func revertFromError(abiJSON string, err error) *core.RevertError {
+	if err == nil {
+		return nil
+	}
+	rpcErr, ok := err.(rpc.Error)
+	if !ok {
+		return nil
+	}
+	message := strings.ToLower(err.Error())
+	reverted := rpcErr.ErrorCode() == revertErrorCode
+	for _, revertMessage := range revertMessages {
+		reverted = reverted || strings.Contains(message, revertMessage)
+	}
+	if !reverted {
+		return nil
+	}
+	data := errorData(err)
+	if len(data) == 0 {
+		return &core.RevertError{Reason: err.Error()}
+	}
+
+	return newRevertError(abiJSON, data)
+}
+
+// Newer clients expose the data of an error through ErrorData; otherwise it is read from the error's Data field
+func errorData(err error) []byte {
+	var data interface{}
+	if dataErr, ok := err.(interface{ ErrorData() interface{} }); ok {
+		data = dataErr.ErrorData()
+	} else {
+		v := reflect.ValueOf(err)
+		if v.Kind() == reflect.Ptr {
+			v = v.Elem()
+		}
+		if v.Kind() == reflect.Struct {
+			if field := v.FieldByName("Data"); field.IsValid() && field.CanInterface() {
+				data = field.Interface()
+			}
+		}
+	}
+	str, ok := data.(string)
+	if !ok {
+		return nil
+	}
+	// Some clients prefix the data with a message
+	if i := strings.Index(str, "0x"); i > 0 {
+		str = str[i:]
+	}
+	b, decodeErr := hexutil.Decode(str)
+	if decodeErr != nil {
+		return nil
+	}
+
+	return b
+}
+
+// Decodes the reason for a revert from its data: an Error(string) message, a Panic(uint256) code,
+// or one of the custom errors declared in the abi; any other data is given as hex
+func newRevertError(abiJSON string, data []byte) *core.RevertError {
+	revert := &core.RevertError{Data: data}
+	if len(data) == 0 {
+		return revert
+	}
+	revert.Reason = hexutil.Encode(data)
+	if len(data) < 4 {
+		return revert
+	}
+	selector, args := data[:4], data[4:]
+	switch {
+	case bytes.Equal(selector, errorSelector):
+		values, err := unpackErrorArgs([]string{"string"}, args)
+		if err == nil {
+			revert.Reason = values[0].(string)
+		}
+	case bytes.Equal(selector, panicSelector):
+		values, err := unpackErrorArgs([]string{"uint256"}, args)
+		if err == nil {
+			code := values[0].(*big.Int)
+			revert.Reason = fmt.Sprintf("Panic(0x%x)", code)
+			if reason, ok := panicReasons[code.Uint64()]; ok && code.IsUint64() {
+				revert.Reason += ": " + reason
+			}
+		}
+	default:
+		if reason, ok := decodeCustomError(abiJSON, selector, args); ok {
+			revert.Reason = reason
+		}
+	}
+
+	return revert
+}
+
+func unpackErrorArgs(argTypes []string, data []byte) ([]interface{}, error) {
+	args := make(abi.Arguments, 0, len(argTypes))
+	for _, argType := range argTypes {
+		t, err := abi.NewType(argType, "", nil)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, abi.Argument{Type: t})
+	}
+
+	return args.UnpackValues(data)
+}
+
+// Custom errors are not parsed from the abi by geth, so they are read from its json directly
+type abiError struct {
+	Type   string                   `json:"type"`
+	Name   string                   `json:"name"`
+	Inputs []abi.ArgumentMarshaling `json:"inputs"`
+}
+
+func decodeCustomError(abiJSON string, selector, data []byte) (string, bool) {
+	var entries []abiError
+	if err := json.Unmarshal([]byte(abiJSON), &entries); err != nil {
+		return "", false
+	}
+	for _, entry := range entries {
+		if entry.Type != "error" {
+			continue
+		}
+		args := make(abi.Arguments, 0, len(entry.Inputs))
+		argTypes := make([]string, 0, len(entry.Inputs))
+		for _, input := range entry.Inputs {
+			t, err := abi.NewType(input.Type, "", input.Components)
+			if err != nil {
+				break
+			}
+			args = append(args, abi.Argument{Name: input.Name, Type: t})
+			argTypes = append(argTypes, t.String())
+		}
+		if len(args) != len(entry.Inputs) {
+			continue
+		}
+		signature := entry.Name + "(" + strings.Join(argTypes, ",") + ")"
+		if !bytes.Equal(crypto.Keccak256([]byte(signature))[:4], selector) {
+			continue
+		}
+		values, err := args.UnpackValues(data)
+		if err != nil {
+			return "", false
+		}
+		strs := make([]string, len(values))
+		for i, value := range values {
+			strs[i] = fmt.Sprint(value)
+		}
+		return entry.Name + "(" + strings.Join(strs, ", ") + ")", true
+	}
+
+	return "", false
+}
@@ -17,6 +17,8 @@
 package test_helpers
 
 import (
+	"database/sql"
+
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/ethereum/go-ethereum/rpc"
 	. "github.com/onsi/gomega"
@@ -31,6 +33,7 @@ import (
 	"github.com/vulcanize/eth-contract-watcher/pkg/constants"
 	"github.com/vulcanize/eth-contract-watcher/pkg/contract"
 	"github.com/vulcanize/eth-contract-watcher/pkg/helpers/test_helpers/mocks"
+	"github.com/vulcanize/eth-contract-watcher/pkg/storage"
 	"github.com/vulcanize/eth-header-sync/pkg/postgres"
 )
 
@@ -81,27 +84,30 @@ type HeaderSyncNewOwnerLog struct {
 }
 
 type BalanceOf struct {
-	ID        int64  `db:"id"`
-	TokenName string `db:"token_name"`
-	Block     int64  `db:"block"`
-	Address   string `db:"who_"`
-	Balance   string `db:"returned"`
+	ID        int64         `db:"id"`
+	TokenName string        `db:"token_name"`
+	Block     int64         `db:"block"`
+	HeaderID  sql.NullInt64 `db:"header_id"`
+	Address   string        `db:"who_"`
+	Balance   string        `db:"returned"`
 }
 
 type Resolver struct {
-	ID        int64  `db:"id"`
-	TokenName string `db:"token_name"`
-	Block     int64  `db:"block"`
-	Node      string `db:"node_"`
-	Address   string `db:"returned"`
+	ID        int64         `db:"id"`
+	TokenName string        `db:"token_name"`
+	Block     int64         `db:"block"`
+	HeaderID  sql.NullInt64 `db:"header_id"`
+	Node      string        `db:"node_"`
+	Address   string        `db:"returned"`
 }
 
 type Owner struct {
-	ID        int64  `db:"id"`
-	TokenName string `db:"token_name"`
-	Block     int64  `db:"block"`
-	Node      string `db:"node_"`
-	Address   string `db:"returned"`
+	ID        int64         `db:"id"`
+	TokenName string        `db:"token_name"`
+	Block     int64         `db:"block"`
+	HeaderID  sql.NullInt64 `db:"header_id"`
+	Node      string        `db:"node_"`
+	Address   string        `db:"returned"`
 }
 
 func SetupHeaderFetcher() core.Fetcher {
@@ -117,7 +123,7 @@ func SetupHeaderFetcher() core.Fetcher {
 	return fetcher.NewFetcher(ethClient, rpcClient, n)
 }
 
-func SetupDBandClient() (*postgres.DB, core.EthClient) {
+func SetupDBandClient() (*storage.DB, core.EthClient) {
 	con := test_config.TestClient
 	rpcPath := con.RPCPath
 	rawRPCClient, err := rpc.Dial(rpcPath)
@@ -132,7 +138,7 @@ func SetupDBandClient() (*postgres.DB, core.EthClient) {
 	}, n)
 	Expect(err).NotTo(HaveOccurred())
 
-	return db, ethClient
+	return storage.NewPostgresDB(db), ethClient
 }
 
 func SetupTusdContract(wantedEvents, wantedMethods []string) *contract.Contract {
@@ -208,13 +214,16 @@ func SetupMolochContract(wantedEvents, wantedMethods []string) *contract.Contrac
 }
 
 // TODO: tear down/setup DB from migrations so this doesn't alter the schema between tests
-func TearDown(db *postgres.DB) {
+func TearDown(db *storage.DB) {
 	tx, err := db.Beginx()
 	Expect(err).NotTo(HaveOccurred())
 
 	_, err = tx.Exec(`DELETE FROM headers`)
 	Expect(err).NotTo(HaveOccurred())
 
+	_, err = tx.Exec(`DELETE FROM contract_watcher.contracts`)
+	Expect(err).NotTo(HaveOccurred())
+
 	_, err = tx.Exec(`DROP TABLE checked_headers`)
 	Expect(err).NotTo(HaveOccurred())
 
@@ -235,6 +244,9 @@ func TearDown(db *postgres.DB) {
 	_, err = tx.Exec(`DROP SCHEMA IF EXISTS header_0x314159265dd8dbb310642f98f50c066173c1259b CASCADE`)
 	Expect(err).NotTo(HaveOccurred())
 
+	_, err = tx.Exec(`DROP SCHEMA IF EXISTS trueusd CASCADE`)
+	Expect(err).NotTo(HaveOccurred())
+
 	err = tx.Commit()
 	Expect(err).NotTo(HaveOccurred())
 
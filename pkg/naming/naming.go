@@ -0,0 +1,242 @@
+// VulcanizeDB
+// Copyright © 2019 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package naming
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/vulcanize/eth-contract-watcher/pkg/types"
+)
+
+// Template placeholders
+const (
+	ModePlaceholder    = "{mode}"
+	AddressPlaceholder = "{address}"
+	AliasPlaceholder   = "{alias}"
+	EventPlaceholder   = "{event}"
+	MethodPlaceholder  = "{method}"
+)
+
+// Default templates reproduce the original `<mode>_<address>.<name>_<event|method>` naming convention
+const (
+	DefaultEventTemplate  = "{mode}_{address}.{event}_event"
+	DefaultMethodTemplate = "{mode}_{address}.{method}_method"
+)
+
+// Tables kept for each contract as a whole, in the schema of its event tables
+const (
+	EmittedArgsTable = "emitted_args"
+	KnownArgsTable   = "known_args"
+)
+
+// ReservedTables are the names event and method tables can not be given
+var ReservedTables = []string{EmittedArgsTable, KnownArgsTable}
+
+// Prefix of the address used for {alias} by contracts without an alias, as identifiers cannot begin with a digit
+const unaliasedPrefix = "c_"
+
+var aliasRegex = regexp.MustCompile(`^[a-z_][a-z0-9_]*$`)
+
+// TableName is a schema qualified Postgres table name
+type TableName struct {
+	Schema string
+	Table  string
+}
+
+// String returns the `schema.table` form of the name
+func (t TableName) String() string {
+	return t.Schema + "." + t.Table
+}
+
+// Namer generates the schema, table, and checked_headers column names used for watched contracts
+// The same Namer should be shared by every component that reads or writes the generated tables
+type Namer struct {
+	mode           types.Mode
+	eventTemplate  string
+	methodTemplate string
+	aliases        map[string]string // Map of lowercase contract address to alias
+}
+
+// NewNamer returns a new Namer
+// Empty templates fall back to the defaults
+func NewNamer(mode types.Mode, eventTemplate, methodTemplate string, aliases map[string]string) *Namer {
+	if eventTemplate == "" {
+		eventTemplate = DefaultEventTemplate
+	}
+	if methodTemplate == "" {
+		methodTemplate = DefaultMethodTemplate
+	}
+	lowerAliases := make(map[string]string, len(aliases))
+	for addr, alias := range aliases {
+		if alias != "" {
+			lowerAliases[strings.ToLower(addr)] = strings.ToLower(alias)
+		}
+	}
+
+	return &Namer{
+		mode:           mode,
+		eventTemplate:  eventTemplate,
+		methodTemplate: methodTemplate,
+		aliases:        lowerAliases,
+	}
+}
+
+// DefaultNamer returns a Namer which uses the default templates and no aliases
+func DefaultNamer(mode types.Mode) *Namer {
+	return NewNamer(mode, DefaultEventTemplate, DefaultMethodTemplate, nil)
+}
+
+// Alias returns the alias configured for the contract, or if it has none its lowercase address prefixed with `c_`,
+// so that the alias is always a valid unquoted identifier
+func (n *Namer) Alias(contractAddr string) string {
+	addr := strings.ToLower(contractAddr)
+	if alias, ok := n.aliases[addr]; ok {
+		return alias
+	}
+
+	return unaliasedPrefix + addr
+}
+
+// EventSchema returns the name of the schema holding the contract's event tables
+func (n *Namer) EventSchema(contractAddr string) string {
+	return n.EventTable(contractAddr, "").Schema
+}
+
+// MethodSchema returns the name of the schema holding the contract's method tables
+func (n *Namer) MethodSchema(contractAddr string) string {
+	return n.MethodTable(contractAddr, "").Schema
+}
+
+// EventTable returns the table name for the contract's event, given the event's identifier
+func (n *Namer) EventTable(contractAddr, eventName string) TableName {
+	return n.render(n.eventTemplate, EventPlaceholder, contractAddr, eventName)
+}
+
+// MethodTable returns the table name for the contract's method, given the method's identifier
+func (n *Namer) MethodTable(contractAddr, methodName string) TableName {
+	return n.render(n.methodTemplate, MethodPlaceholder, contractAddr, methodName)
+}
+
+// MethodVariantTable returns the name of a table or view derived from the contract's method table
+// The `_method` suffix of the method table's name is replaced by the variant, or the variant is appended if it has no such suffix
+func (n *Namer) MethodVariantTable(contractAddr, methodName, variant string) TableName {
+	table := n.MethodTable(contractAddr, methodName)
+	name := strings.TrimSuffix(table.Table, "_method") + "_" + variant
+	table.Table = types.TruncateIdentifier(name, table.String()+"_"+variant)
+
+	return table
+}
+
+// ContractTable returns the name of a table or view kept for the contract as a whole, alongside its event tables
+func (n *Namer) ContractTable(contractAddr, name string) TableName {
+	return TableName{Schema: n.EventSchema(contractAddr), Table: name}
+}
+
+// CheckReserved returns an error if the table, generated for one of the contract's events or methods,
+// takes the name of one of the tables kept for the contract as a whole
+func (n *Namer) CheckReserved(contractAddr string, table TableName) error {
+	for _, name := range ReservedTables {
+		if table == n.ContractTable(contractAddr, name) {
+			return fmt.Errorf("table name %s is reserved; change the naming template", table.String())
+		}
+	}
+
+	return nil
+}
+
+// LegacyEventTable returns the table name the event would have under the default naming convention
+func (n *Namer) LegacyEventTable(contractAddr, eventName string) TableName {
+	return n.render(DefaultEventTemplate, EventPlaceholder, contractAddr, eventName)
+}
+
+// LegacyMethodTable returns the table name the method would have under the default naming convention
+func (n *Namer) LegacyMethodTable(contractAddr, methodName string) TableName {
+	return n.render(DefaultMethodTemplate, MethodPlaceholder, contractAddr, methodName)
+}
+
+// EventCheckID returns the checked_headers column id for the contract's event
+// Check ids are always address based so that changing an alias or template does not reset progress
+func (n *Namer) EventCheckID(contractAddr, eventName string) string {
+	return checkID(contractAddr, eventName)
+}
+
+// MethodCheckID returns the checked_headers column id for the contract's method
+func (n *Namer) MethodCheckID(contractAddr, methodName string) string {
+	return checkID(contractAddr, methodName)
+}
+
+// Long check ids are cut to the plain prefix Postgres truncated them to before identifiers were hashed,
+// so that the check columns of existing deployments are still found
+func checkID(contractAddr, name string) string {
+	id := strings.ToLower(name + "_" + contractAddr)
+	if len(id) > types.MaxIdentifierLength {
+		id = id[:types.MaxIdentifierLength]
+	}
+
+	return id
+}
+
+func (n *Namer) render(template, namePlaceholder, contractAddr, name string) TableName {
+	addr := strings.ToLower(contractAddr)
+	replacer := strings.NewReplacer(
+		ModePlaceholder, n.mode.String(),
+		AddressPlaceholder, addr,
+		AliasPlaceholder, n.Alias(addr),
+		namePlaceholder, strings.ToLower(name),
+	)
+	parts := strings.SplitN(template, ".", 2)
+
+	schema := strings.ToLower(replacer.Replace(parts[0]))
+	table := strings.ToLower(replacer.Replace(parts[1]))
+
+	return TableName{
+		Schema: types.TruncateIdentifier(schema, schema),
+		Table:  types.TruncateIdentifier(table, table),
+	}
+}
+
+// ValidateTemplate checks that a naming template is of the form `<schema>.<table>`,
+// that the schema part identifies the contract and that the table part identifies the event or method
+func ValidateTemplate(template, namePlaceholder string) error {
+	parts := strings.Split(template, ".")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return fmt.Errorf("naming template %s must be of the form <schema>.<table>", template)
+	}
+	if !strings.Contains(parts[0], AddressPlaceholder) && !strings.Contains(parts[0], AliasPlaceholder) {
+		return fmt.Errorf("naming template %s schema must contain %s or %s", template, AddressPlaceholder, AliasPlaceholder)
+	}
+	if strings.Contains(parts[0], namePlaceholder) {
+		return fmt.Errorf("naming template %s schema cannot contain %s", template, namePlaceholder)
+	}
+	if !strings.Contains(parts[1], namePlaceholder) {
+		return fmt.Errorf("naming template %s table must contain %s", template, namePlaceholder)
+	}
+
+	return nil
+}
+
+// ValidateAlias checks that an alias can be used as part of a Postgres identifier
+func ValidateAlias(alias string) error {
+	if !aliasRegex.MatchString(alias) {
+		return errors.New("alias must start with a lowercase letter or underscore and contain only lowercase letters, digits, and underscores")
+	}
+
+	return nil
+}
@@ -0,0 +1,110 @@
+// VulcanizeDB
+// Copyright © 2019 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package naming_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/vulcanize/eth-contract-watcher/pkg/constants"
+	"github.com/vulcanize/eth-contract-watcher/pkg/naming"
+	"github.com/vulcanize/eth-contract-watcher/pkg/types"
+)
+
+var _ = Describe("Namer", func() {
+	Describe("Default naming", func() {
+		namer := naming.DefaultNamer(types.HeaderSync)
+
+		It("Names schemas and tables by mode and lowercase contract address", func() {
+			Expect(namer.EventSchema(constants.TusdContractAddress)).To(Equal("header_0x8dd5fbce2f6a956c3022ba3663759011dd51e73e"))
+			Expect(namer.EventTable(constants.TusdContractAddress, "Transfer").String()).To(Equal("header_0x8dd5fbce2f6a956c3022ba3663759011dd51e73e.transfer_event"))
+			Expect(namer.MethodTable(constants.TusdContractAddress, "balanceOf").String()).To(Equal("header_0x8dd5fbce2f6a956c3022ba3663759011dd51e73e.balanceof_method"))
+		})
+
+		It("Uses the legacy names as the default names", func() {
+			Expect(namer.EventTable(constants.TusdContractAddress, "Transfer")).To(Equal(namer.LegacyEventTable(constants.TusdContractAddress, "Transfer")))
+			Expect(namer.MethodTable(constants.TusdContractAddress, "balanceOf")).To(Equal(namer.LegacyMethodTable(constants.TusdContractAddress, "balanceOf")))
+		})
+
+		It("Generates check column ids from the event or method name and contract address", func() {
+			Expect(namer.EventCheckID(constants.TusdContractAddress, "Transfer")).To(Equal("transfer_0x8dd5fbce2f6a956c3022ba3663759011dd51e73e"))
+			Expect(namer.MethodCheckID(constants.TusdContractAddress, "balanceOf")).To(Equal("balanceof_0x8dd5fbce2f6a956c3022ba3663759011dd51e73e"))
+		})
+
+		It("Truncates long check column ids as Postgres does", func() {
+			Expect(namer.EventCheckID(constants.TusdContractAddress, "OwnershipTransferProposed")).To(Equal("ownershiptransferproposed_0x8dd5fbce2f6a956c3022ba3663759011dd5"))
+		})
+
+		It("Names tables derived from a method table in place of its method suffix", func() {
+			Expect(namer.MethodVariantTable(constants.TusdContractAddress, "balanceOf", "at_block").String()).To(Equal("header_0x8dd5fbce2f6a956c3022ba3663759011dd51e73e.balanceof_at_block"))
+		})
+	})
+
+	Describe("Aliased naming", func() {
+		namer := naming.NewNamer(types.HeaderSync, "{alias}.{event}_events", "{alias}.{method}_calls", map[string]string{
+			constants.TusdContractAddress: "TrueUSD",
+		})
+
+		It("Uses the alias in place of the address", func() {
+			Expect(namer.Alias(constants.TusdContractAddress)).To(Equal("trueusd"))
+			Expect(namer.EventTable(constants.TusdContractAddress, "Transfer").String()).To(Equal("trueusd.transfer_events"))
+			Expect(namer.MethodTable(constants.TusdContractAddress, "balanceOf").String()).To(Equal("trueusd.balanceof_calls"))
+			Expect(namer.MethodSchema(constants.TusdContractAddress)).To(Equal("trueusd"))
+			Expect(namer.MethodVariantTable(constants.TusdContractAddress, "balanceOf", "at_block").String()).To(Equal("trueusd.balanceof_calls_at_block"))
+		})
+
+		It("Falls back to an identifier made from the address for contracts without an alias", func() {
+			Expect(namer.Alias(constants.EnsContractAddress)).To(Equal("c_0x314159265dd8dbb310642f98f50c066173c1259b"))
+			Expect(namer.EventSchema(constants.EnsContractAddress)).To(Equal("c_0x314159265dd8dbb310642f98f50c066173c1259b"))
+			Expect(namer.EventTable(constants.EnsContractAddress, "Transfer").String()).To(Equal("c_0x314159265dd8dbb310642f98f50c066173c1259b.transfer_events"))
+		})
+
+		It("Reserves the names of the tables kept for the contract as a whole", func() {
+			namer := naming.NewNamer(types.HeaderSync, "{alias}.{event}", "{alias}.{method}_calls", nil)
+			Expect(namer.CheckReserved(constants.TusdContractAddress, namer.EventTable(constants.TusdContractAddress, "Transfer"))).To(Succeed())
+			Expect(namer.CheckReserved(constants.TusdContractAddress, namer.EventTable(constants.TusdContractAddress, "Emitted_Args"))).ToNot(Succeed())
+			Expect(namer.CheckReserved(constants.TusdContractAddress, namer.EventTable(constants.TusdContractAddress, "known_args"))).ToNot(Succeed())
+		})
+
+		It("Keeps legacy names and check column ids address based", func() {
+			Expect(namer.LegacyEventTable(constants.TusdContractAddress, "Transfer").String()).To(Equal("header_0x8dd5fbce2f6a956c3022ba3663759011dd51e73e.transfer_event"))
+			Expect(namer.EventCheckID(constants.TusdContractAddress, "Transfer")).To(Equal("transfer_0x8dd5fbce2f6a956c3022ba3663759011dd51e73e"))
+		})
+	})
+
+	Describe("ValidateTemplate", func() {
+		It("Accepts templates which identify both the contract and the event or method", func() {
+			Expect(naming.ValidateTemplate(naming.DefaultEventTemplate, naming.EventPlaceholder)).To(Succeed())
+			Expect(naming.ValidateTemplate("{alias}.{method}_method", naming.MethodPlaceholder)).To(Succeed())
+		})
+
+		It("Rejects malformed templates", func() {
+			Expect(naming.ValidateTemplate("{alias}_{event}", naming.EventPlaceholder)).ToNot(Succeed())
+			Expect(naming.ValidateTemplate("contracts.{event}", naming.EventPlaceholder)).ToNot(Succeed())
+			Expect(naming.ValidateTemplate("{alias}_{event}.events", naming.EventPlaceholder)).ToNot(Succeed())
+			Expect(naming.ValidateTemplate("{alias}.{method}", naming.EventPlaceholder)).ToNot(Succeed())
+		})
+	})
+
+	Describe("ValidateAlias", func() {
+		It("Only accepts aliases usable in Postgres identifiers", func() {
+			Expect(naming.ValidateAlias("true_usd")).To(Succeed())
+			Expect(naming.ValidateAlias("1inch")).ToNot(Succeed())
+			Expect(naming.ValidateAlias("true-usd")).ToNot(Succeed())
+		})
+	})
+})
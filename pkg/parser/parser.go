@@ -17,10 +17,10 @@
 package parser
 
 import (
-	"errors"
+	"fmt"
+	"sort"
 
 	"github.com/ethereum/go-ethereum/accounts/abi"
-	"github.com/ethereum/go-ethereum/common"
 
 	a "github.com/vulcanize/eth-contract-watcher/pkg/abi"
 	"github.com/vulcanize/eth-contract-watcher/pkg/constants"
@@ -28,7 +28,7 @@ import (
 )
 
 // Parser is used to fetch and parse contract ABIs
-// It is dependent on etherscan's api
+// ABIs are fetched from a chain of providers, by default the ABIs built into the watcher followed by etherscan's api
 type Parser interface {
 	Parse(contractAddr string) error
 	ParseAbiStr(abiStr string) error
@@ -40,17 +40,25 @@ type Parser interface {
 }
 
 type parser struct {
-	client    *a.EtherScanAPI
-	abi       string
-	parsedAbi abi.ABI
+	provider          a.AbiProvider
+	abi               string
+	parsedAbi         abi.ABI
+	eventIdentifiers  map[string]string // Map of event names to their contract-wide unique identifiers
+	methodIdentifiers map[string]string // Map of method names to their contract-wide unique identifiers
 }
 
-// NewParser returns a new Parser
-func NewParser(network string) Parser {
-	url := a.GenURL(network)
-
+// NewParser returns a new Parser which fetches ABIs from the provider
+func NewParser(provider a.AbiProvider) Parser {
 	return &parser{
-		client: a.NewEtherScanClient(url),
+		provider: provider,
+	}
+}
+
+// DefaultProvider returns the ABIs built into the watcher followed by etherscan's api for the network
+func DefaultProvider(network string) a.AbiProvider {
+	return a.ProviderChain{
+		a.MapProvider(constants.ABIs),
+		a.NewEtherScanClient(a.GenURL(network)),
 	}
 }
 
@@ -67,24 +75,12 @@ func (p *parser) ParsedAbi() abi.ABI {
 // Parse retrieves and parses the abi string
 // for the given contract address
 func (p *parser) Parse(contractAddr string) error {
-	// If the abi is one our locally stored abis, fetch
-	// TODO: Allow users to pass abis through config
-	knownAbi, err := p.lookUp(contractAddr)
-	if err == nil {
-		p.abi = knownAbi
-		p.parsedAbi, err = a.ParseAbi(knownAbi)
-		return err
-	}
-	// Try getting abi from etherscan
-	abiStr, err := p.client.GetAbi(contractAddr)
+	abiStr, err := p.provider.GetAbi(contractAddr)
 	if err != nil {
 		return err
 	}
-	//TODO: Implement other ways to fetch abi
-	p.abi = abiStr
-	p.parsedAbi, err = a.ParseAbi(abiStr)
 
-	return err
+	return p.ParseAbiStr(abiStr)
 }
 
 // ParseAbiStr loads and parses an abi from a given abi string
@@ -92,16 +88,138 @@ func (p *parser) ParseAbiStr(abiStr string) error {
 	var err error
 	p.abi = abiStr
 	p.parsedAbi, err = a.ParseAbi(abiStr)
+	nameArguments(p.parsedAbi)
+	p.eventIdentifiers, p.methodIdentifiers = identifiers(p.parsedAbi)
 
 	return err
 }
 
-func (p *parser) lookUp(contractAddr string) (string, error) {
-	if v, ok := constants.ABIs[common.HexToAddress(contractAddr)]; ok {
-		return v, nil
+// AddedByImplementation returns the wanted events and methods of a proxy's implementation whose signatures are in none
+// of the events and methods already watched, leaving the parser with the current abi merged with the implementation's
+// An event or method the implementation changes, such as one taking other arguments, is returned under the name
+// the merged abi gives its new overload
+func AddedByImplementation(p Parser, currentAbi, implAbi string, events map[string]types.Event, methods []types.Method,
+	wantedEvents, wantedMethods []string) (map[string]types.Event, []types.Method, error) {
+	parseErr := p.ParseAbiStr(implAbi)
+	if parseErr != nil {
+		return nil, nil, parseErr
+	}
+	implEvents := make(map[string]bool)
+	for _, event := range p.GetEvents(wantedEvents) {
+		implEvents[event.Signature()] = true
+	}
+	implMethods := make(map[string]bool)
+	for _, m := range p.GetSelectMethods(wantedMethods) {
+		if m.Name != "" {
+			implMethods[m.Signature()] = true
+		}
+	}
+	for _, event := range events {
+		delete(implEvents, event.Signature())
 	}
+	for _, m := range methods {
+		delete(implMethods, m.Signature())
+	}
+
+	merged, mergeErr := a.MergeAbis(currentAbi, implAbi)
+	if mergeErr != nil {
+		return nil, nil, mergeErr
+	}
+	parseErr = p.ParseAbiStr(merged)
+	if parseErr != nil {
+		return nil, nil, parseErr
+	}
+	added := make(map[string]types.Event)
+	for name, event := range p.GetEvents([]string{}) {
+		if implEvents[event.Signature()] {
+			added[name] = event
+		}
+	}
+	var addedMethods []types.Method
+	for _, m := range p.GetMethods([]string{}) {
+		if implMethods[m.Signature()] {
+			addedMethods = append(addedMethods, m)
+		}
+	}
+	// Methods are polled in a stable order
+	sort.Slice(addedMethods, func(i, j int) bool { return addedMethods[i].Name < addedMethods[j].Name })
+
+	return added, addedMethods, nil
+}
 
-	return "", errors.New("ABI not present in lookup table")
+// Gives unnamed event and method arguments a positional name (e.g. `arg0`) so that
+// their values do not collide when unpacked into maps keyed by argument name
+func nameArguments(parsedAbi abi.ABI) {
+	for _, e := range parsedAbi.Events {
+		nameArgs(e.Inputs)
+	}
+	for _, m := range parsedAbi.Methods {
+		nameArgs(m.Inputs)
+	}
+}
+
+func nameArgs(args abi.Arguments) {
+	names := make(map[string]bool, len(args))
+	for _, arg := range args {
+		names[arg.Name] = true
+	}
+	for i := range args {
+		if args[i].Name != "" {
+			continue
+		}
+		name := fmt.Sprintf("arg%d", i)
+		for names[name] {
+			name = name + "_"
+		}
+		names[name] = true
+		args[i].Name = name
+	}
+}
+
+// Generates contract-wide unique identifiers for event and method names
+// Names are processed in sorted order so the same abi always produces the same identifiers
+func identifiers(parsedAbi abi.ABI) (map[string]string, map[string]string) {
+	eventNames := make([]string, 0, len(parsedAbi.Events))
+	for name := range parsedAbi.Events {
+		eventNames = append(eventNames, name)
+	}
+	sort.Strings(eventNames)
+	eventIDs := types.NewIdentifierSet()
+	for i, name := range eventNames {
+		eventIDs.Add(name, i)
+	}
+
+	methodNames := make([]string, 0, len(parsedAbi.Methods))
+	for name := range parsedAbi.Methods {
+		methodNames = append(methodNames, name)
+	}
+	sort.Strings(methodNames)
+	methodIDs := types.NewIdentifierSet()
+	for i, name := range methodNames {
+		methodIDs.Add(name, i)
+	}
+
+	return eventIDs.Mapping(), methodIDs.Mapping()
+}
+
+// Builds our custom Method, using the contract-wide identifier for the method name
+func (p *parser) newMethod(m abi.Method) types.Method {
+	method := types.NewMethod(m)
+	if id, ok := p.methodIdentifiers[m.Name]; ok {
+		method.Identifier = id
+	}
+
+	return method
+}
+
+// Builds our custom Event, using the contract-wide identifier for the event name
+func (p *parser) newEvent(e abi.Event) types.Event {
+	event := types.NewEvent(e)
+	if id, ok := p.eventIdentifiers[e.Name]; ok {
+		event.Identifier = id
+	}
+
+	return event
 }
 
 // GetSelectMethods returns only specified methods, if they meet the criteria
@@ -116,7 +234,7 @@ func (p *parser) GetSelectMethods(wanted []string) []types.Method {
 	for _, m := range p.parsedAbi.Methods {
 		for i, name := range wanted {
 			if name == m.Name && okTypes(m, wanted) {
-				methods[i] = types.NewMethod(m)
+				methods[i] = p.newMethod(m)
 			}
 		}
 	}
@@ -135,7 +253,7 @@ func (p *parser) GetMethods(wanted []string) []types.Method {
 	length := len(wanted)
 	for _, m := range p.parsedAbi.Methods {
 		if length == 0 || stringInSlice(wanted, m.Name) {
-			methods = append(methods, types.NewMethod(m))
+			methods = append(methods, p.newMethod(m))
 		}
 	}
 
@@ -154,7 +272,7 @@ func (p *parser) GetEvents(wanted []string) map[string]types.Event {
 	length := len(wanted)
 	for _, e := range p.parsedAbi.Events {
 		if length == 0 || stringInSlice(wanted, e.Name) {
-			events[e.Name] = types.NewEvent(e)
+			events[e.Name] = p.newEvent(e)
 		}
 	}
 
@@ -184,23 +302,16 @@ func okReturnType(arg abi.Argument) bool {
 }
 
 func okTypes(m abi.Method, wanted []string) bool {
-	// Only return method if it has less than 3 arguments, a single output value, and it is a method we want or we want all methods (empty 'wanted' slice)
-	if len(m.Inputs) < 3 && len(m.Outputs) == 1 && (len(wanted) == 0 || stringInSlice(wanted, m.Name)) {
-		// Only return methods if inputs are all of accepted types and output is of the accepted types
-		if !okReturnType(m.Outputs[0]) {
-			return false
+	// Only return method if it has output values, and it is a method we want or we want all methods (empty 'wanted' slice)
+	if len(m.Outputs) > 0 && (len(wanted) == 0 || stringInSlice(wanted, m.Name)) {
+		// Only return methods if inputs are all of types we can poll with and outputs, including the components of tuples, are of the accepted types
+		for _, output := range types.FlattenOutputs(m.Outputs) {
+			if !okReturnType(output) {
+				return false
+			}
 		}
 		for _, input := range m.Inputs {
-			switch input.Type.T {
-			// Addresses are properly labeled and caught
-			// But hashes tend to not be explicitly labeled and caught
-			// Instead bytes32 are assumed to be hashes
-			case abi.AddressTy, abi.HashTy:
-			case abi.FixedBytesTy:
-				if input.Type.Size != 32 {
-					return false
-				}
-			default:
+			if !types.PollableArg(input.Type) {
 				return false
 			}
 		}
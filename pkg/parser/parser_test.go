@@ -34,7 +34,7 @@ var _ = Describe("Parser", func() {
 	var err error
 
 	BeforeEach(func() {
-		p = parser.NewParser("")
+		p = parser.NewParser(parser.DefaultProvider(""))
 	})
 
 	Describe("Mock Parse", func() {
@@ -162,6 +162,23 @@ var _ = Describe("Parser", func() {
 			Expect(allow.Name).To(Equal("allowance"))
 		})
 
+		It("Returns methods taking any number of arguments of any elementary type", func() {
+			err = p.ParseAbiStr(`[
+				{"constant":true,"inputs":[{"name":"tokenId","type":"uint256"}],"name":"ownerOf","outputs":[{"name":"","type":"address"}],"type":"function"},
+				{"constant":true,"inputs":[{"name":"a","type":"address"},{"name":"b","type":"uint8"},{"name":"c","type":"bool"}],"name":"three","outputs":[{"name":"","type":"uint256"}],"type":"function"},
+				{"constant":true,"inputs":[{"name":"ids","type":"uint256[]"}],"name":"many","outputs":[{"name":"","type":"uint256"}],"type":"function"}
+			]`)
+			Expect(err).ToNot(HaveOccurred())
+
+			selectMethods := p.GetSelectMethods([]string{"ownerOf", "three", "many"})
+			Expect(len(selectMethods)).To(Equal(3))
+			Expect(selectMethods[0].Name).To(Equal("ownerOf"))
+			Expect(selectMethods[0].Args[0].Type.T).To(Equal(abi.UintTy))
+			Expect(selectMethods[1].Name).To(Equal("three"))
+			Expect(len(selectMethods[1].Args)).To(Equal(3))
+			Expect(selectMethods[2].Name).To(Equal(""))
+		})
+
 		It("Returns nil if given a nil or empty array", func() {
 			contractAddr := "0x89d24a6b4ccb1b6faa2625fe562bdd9a23260359"
 			err = p.Parse(contractAddr)
@@ -223,4 +240,55 @@ var _ = Describe("Parser", func() {
 			Expect(len(selectMethods)).To(Equal(25))
 		})
 	})
+
+	Describe("AddedByImplementation", func() {
+		It("Adds the entries an implementation changes the arguments of as new overloads", func() {
+			currentAbi := `[{"constant":true,"inputs":[{"name":"owner","type":"address"}],"name":"balanceOf","outputs":[{"name":"","type":"uint256"}],"type":"function"},` +
+				`{"constant":true,"inputs":[],"name":"totalSupply","outputs":[{"name":"","type":"uint256"}],"type":"function"},` +
+				`{"anonymous":false,"inputs":[{"indexed":true,"name":"to","type":"address"}],"name":"Minted","type":"event"}]`
+			implAbi := `[{"constant":true,"inputs":[{"name":"owner","type":"address"},{"name":"id","type":"uint256"}],"name":"balanceOf","outputs":[{"name":"","type":"uint256"}],"type":"function"},` +
+				`{"constant":true,"inputs":[],"name":"totalSupply","outputs":[{"name":"","type":"uint256"}],"type":"function"},` +
+				`{"anonymous":false,"inputs":[{"indexed":true,"name":"to","type":"address"},{"indexed":false,"name":"id","type":"uint256"}],"name":"Minted","type":"event"}]`
+			err = p.ParseAbiStr(currentAbi)
+			Expect(err).ToNot(HaveOccurred())
+			events := p.GetEvents([]string{})
+			methods := p.GetSelectMethods([]string{"balanceOf", "totalSupply"})
+
+			added, addedMethods, err := parser.AddedByImplementation(p, currentAbi, implAbi, events, methods, []string{}, []string{"balanceOf", "totalSupply"})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(addedMethods).To(HaveLen(1))
+			Expect(addedMethods[0].Name).To(Equal("balanceOf0"))
+			Expect(addedMethods[0].Signature()).To(Equal("balanceOf(address,uint256)"))
+			Expect(addedMethods[0].Identifier).ToNot(Equal(methods[0].Identifier))
+			Expect(added).To(HaveLen(1))
+			Expect(added["Minted0"].Signature()).To(Equal("Minted(address,uint256)"))
+			Expect(p.ParsedAbi().Methods).To(HaveKey("balanceOf"))
+			Expect(p.ParsedAbi().Methods).To(HaveKey("balanceOf0"))
+		})
+	})
+
+	Describe("Identifiers", func() {
+		It("Generates unique, safe identifiers for unnamed and case colliding names", func() {
+			abiStr := `[{"anonymous":false,"inputs":[{"indexed":true,"name":"","type":"address"},{"indexed":true,"name":"","type":"address"},{"indexed":false,"name":"Value","type":"uint256"},{"indexed":false,"name":"value","type":"uint256"}],"name":"Transfer","type":"event"},` +
+				`{"anonymous":false,"inputs":[{"indexed":false,"name":"amount","type":"uint256"}],"name":"transfer","type":"event"}]`
+			err = p.ParseAbiStr(abiStr)
+			Expect(err).ToNot(HaveOccurred())
+
+			events := p.GetEvents([]string{})
+			upper := events["Transfer"]
+			lower := events["transfer"]
+			Expect(upper.Identifier).To(Equal("transfer"))
+			Expect(lower.Identifier).To(Equal("transfer_1"))
+
+			Expect(upper.Fields[0].Name).To(Equal("arg0"))
+			Expect(upper.Fields[1].Name).To(Equal("arg1"))
+			Expect(upper.Columns()).To(Equal(map[string]string{
+				"arg0":  "arg0_",
+				"arg1":  "arg1_",
+				"Value": "value_",
+				"value": "value_1_",
+			}))
+		})
+	})
 })
@@ -20,20 +20,23 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
+	"reflect"
 	"strconv"
+	"strings"
 	"time"
 
-	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/sirupsen/logrus"
 
 	hc "github.com/vulcanize/eth-header-sync/pkg/core"
-	"github.com/vulcanize/eth-header-sync/pkg/postgres"
 
 	"github.com/vulcanize/eth-contract-watcher/pkg/contract"
 	"github.com/vulcanize/eth-contract-watcher/pkg/core"
 	"github.com/vulcanize/eth-contract-watcher/pkg/fetcher"
+	"github.com/vulcanize/eth-contract-watcher/pkg/naming"
 	"github.com/vulcanize/eth-contract-watcher/pkg/repository"
+	"github.com/vulcanize/eth-contract-watcher/pkg/storage"
 	"github.com/vulcanize/eth-contract-watcher/pkg/types"
 )
 
@@ -51,10 +54,11 @@ type poller struct {
 }
 
 // NewPoller returns a new Poller
-func NewPoller(client hc.EthClient, db *postgres.DB, mode types.Mode, timeout time.Duration) Poller {
+// The calls made at each block are batched through the multicall contract or the rpc client, either of which may be left empty
+func NewPoller(client hc.EthClient, rpcClient core.BatchCaller, multicall string, db *storage.DB, mode types.Mode, namer *naming.Namer, timeout time.Duration) Poller {
 	return &poller{
-		MethodRepository: repository.NewMethodRepository(db, mode),
-		fetcher:          fetcher.NewFetcher(client, timeout),
+		MethodRepository: repository.NewMethodRepository(db, mode, namer),
+		fetcher:          fetcher.NewBatchFetcher(client, rpcClient, multicall, timeout),
 	}
 }
 
@@ -73,173 +77,207 @@ func (p *poller) PollContract(con contract.Contract, lastBlock int64) error {
 func (p *poller) PollContractAt(con contract.Contract, blockNumber int64) error {
 	p.contract = con
 	for _, m := range con.Methods {
-		switch len(m.Args) {
-		case 0:
-			if err := p.pollNoArgAt(m, blockNumber); err != nil {
-				return err
-			}
-		case 1:
-			if err := p.pollSingleArgAt(m, blockNumber); err != nil {
-				return err
-			}
-		case 2:
-			if err := p.pollDoubleArgAt(m, blockNumber); err != nil {
-				return err
-			}
-		default:
-			return errors.New("poller error: too many arguments to handle")
-
+		if err := p.pollMethodAt(m, blockNumber); err != nil {
+			return err
 		}
 	}
 
 	return nil
 }
 
-func (p *poller) pollNoArgAt(m types.Method, bn int64) error {
+// Polls the method with each set of the values available for its arguments (e.g. token holder addresses for balanceOf)
+func (p *poller) pollMethodAt(m types.Method, bn int64) error {
 	result := types.Result{
 		Block:  bn,
 		Method: m,
-		Inputs: nil,
 		PgType: m.Return[0].PgType,
 	}
 
-	var out interface{}
-	err := p.fetcher.FetchContractData(p.contract.Abi, p.contract.Address, m.Name, nil, &out, bn)
-	if err != nil {
-		return fmt.Errorf("poller error calling 0 argument method\r\nblock: %d, method: %s, contract: %s\r\nerr: %v", bn, m.Name, p.contract.Address, err)
-	}
-	strOut, err := stringify(out)
+	argSets, err := p.argSets(m, bn)
 	if err != nil {
-		return err
+		return fmt.Errorf("poller error collecting method arguments\r\nblock: %d, method: %s, contract: %s\r\nerr: %v", bn, m.Name, p.contract.Address, err)
 	}
-
-	// Cache returned value if piping is turned on
-	p.cache(out)
-	result.Output = strOut
-
-	// Persist result immediately
-	err = p.PersistResults([]types.Result{result}, m, p.contract.Address, p.contract.Name)
-	if err != nil {
-		return fmt.Errorf("poller error persisting 0 argument method result\r\nblock: %d, method: %s, contract: %s\r\nerr: %v", bn, m.Name, p.contract.Address, err)
+	if len(argSets) == 0 { // If we haven't collected any values for the args by now we can't call the method
+		return nil
 	}
 
-	return nil
-}
-
-// Use token holder address to poll methods that take 1 address argument (e.g. balanceOf)
-func (p *poller) pollSingleArgAt(m types.Method, bn int64) error {
-	result := types.Result{
-		Block:  bn,
-		Method: m,
-		Inputs: make([]interface{}, 1),
-		PgType: m.Return[0].PgType,
+	calls := make([]core.ContractCall, len(argSets))
+	outs := make([][]interface{}, len(argSets))
+	for i, in := range argSets {
+		calls[i] = core.ContractCall{Method: m.Name, Args: in, Result: &outs[i]}
 	}
 
-	// Depending on the type of the arg choose
-	// the correct argument set to iterate over
-	var args map[interface{}]bool
-	switch m.Args[0].Type.T {
-	case abi.HashTy, abi.FixedBytesTy:
-		args = p.contract.EmittedHashes
-	case abi.AddressTy:
-		args = p.contract.EmittedAddrs
-	}
-	if len(args) == 0 { // If we haven't collected any args by now we can't call the method
-		return nil
+	// Make the calls as a batch
+	err = p.fetcher.FetchContractDataBatch(p.contract.Abi, p.contract.Address, calls, bn)
+	if err != nil {
+		return fmt.Errorf("poller error calling %d argument method\r\nblock: %d, method: %s, contract: %s\r\nerr: %v", len(m.Args), bn, m.Name, p.contract.Address, err)
 	}
 
-	results := make([]types.Result, 0, len(args))
-	for arg := range args {
-		in := []interface{}{arg}
-		strIn := []interface{}{contract.StringifyArg(arg)}
+	results := make([]types.Result, 0, len(calls))
+	for i, call := range calls {
+		strIn := make([]interface{}, 0, len(call.Args))
+		for _, arg := range call.Args {
+			strIn = append(strIn, contract.StringifyArg(arg))
+		}
+		// A call which reverted is recorded in place of its result, and doesn't prevent the rest being persisted
+		if revert, ok := call.Err.(*core.RevertError); ok {
+			logrus.Debugf("poller recording reverted call\r\nblock: %d, method: %s, contract: %s, args: %v\r\nerr: %v", bn, m.Name, p.contract.Address, strIn, revert)
+			reverted := result
+			reverted.Inputs = strIn
+			reverted.Reverted = true
+			reverted.RevertReason = revert.Reason
+			if len(revert.Data) > 0 {
+				reverted.RevertData = hexutil.Encode(revert.Data)
+			}
+			results = append(results, reverted)
+			continue
+		}
+		if call.Err != nil {
+			logrus.Warnf("poller skipping failed call\r\nblock: %d, method: %s, contract: %s, args: %v\r\nerr: %v", bn, m.Name, p.contract.Address, strIn, call.Err)
+			continue
+		}
+		strOut := make([]interface{}, 0, len(m.Outputs))
+		for j, value := range flatten(outs[i]) {
+			str, err := stringify(value)
+			if err != nil {
+				return err
+			}
+			strOut = append(strOut, str)
 
-		var out interface{}
-		err := p.fetcher.FetchContractData(p.contract.Abi, p.contract.Address, m.Name, in, &out, bn)
-		if err != nil {
-			return fmt.Errorf("poller error calling 1 argument method\r\nblock: %d, method: %s, contract: %s\r\nerr: %v", bn, m.Name, p.contract.Address, err)
+			// Cache returned value if piping is turned on
+			if j < len(m.Outputs) {
+				p.cache(m, m.Outputs[j], value)
+			}
 		}
-		strOut, err := stringify(out)
-		if err != nil {
-			return err
+		if len(strOut) != len(m.Outputs) {
+			return fmt.Errorf("poller error unpacking method result\r\nblock: %d, method: %s, contract: %s\r\nerr: expected %d values, got %d", bn, m.Name, p.contract.Address, len(m.Outputs), len(strOut))
 		}
-		p.cache(out)
 
 		// Write inputs and outputs to result and append result to growing set
 		result.Inputs = strIn
-		result.Output = strOut
+		if len(strOut) == 1 {
+			result.Output = strOut[0]
+		} else {
+			result.Returned = strOut
+		}
 		results = append(results, result)
 	}
+
 	// Persist result set as batch
-	err := p.PersistResults(results, m, p.contract.Address, p.contract.Name)
+	err = p.PersistResults(results, m, p.contract.Address, p.contract.Name)
 	if err != nil {
-		return fmt.Errorf("poller error persisting 1 argument method result\r\nblock: %d, method: %s, contract: %s\r\nerr: %v", bn, m.Name, p.contract.Address, err)
+		return fmt.Errorf("poller error persisting %d argument method result\r\nblock: %d, method: %s, contract: %s\r\nerr: %v", len(m.Args), bn, m.Name, p.contract.Address, err)
 	}
 
 	return nil
 }
 
-// Use token holder address to poll methods that take 2 address arguments (e.g. allowance)
-func (p *poller) pollDoubleArgAt(m types.Method, bn int64) error {
-	result := types.Result{
-		Block:  bn,
-		Method: m,
-		Inputs: make([]interface{}, 2),
-		PgType: m.Return[0].PgType,
-	}
-
-	// Depending on the type of the args choose
-	// the correct argument sets to iterate over
-	var firstArgs map[interface{}]bool
-	switch m.Args[0].Type.T {
-	case abi.HashTy, abi.FixedBytesTy:
-		firstArgs = p.contract.EmittedHashes
-	case abi.AddressTy:
-		firstArgs = p.contract.EmittedAddrs
+// Returns the sets of arguments to call the method with: the tuples emitted together for methods
+// whose arguments are taken from the same log, otherwise every combination of the values available for each argument,
+// followed by any configured sets which are not among them
+func (p *poller) argSets(m types.Method, bn int64) ([][]interface{}, error) {
+	sets, correlated, err := p.contract.ArgTuples(m, bn)
+	if err != nil {
+		return nil, err
 	}
-	if len(firstArgs) == 0 {
-		return nil
+	if !correlated {
+		sets, err = p.combinations(m, bn)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	var secondArgs map[interface{}]bool
-	switch m.Args[1].Type.T {
-	case abi.HashTy, abi.FixedBytesTy:
-		secondArgs = p.contract.EmittedHashes
-	case abi.AddressTy:
-		secondArgs = p.contract.EmittedAddrs
+	static := p.contract.StaticArgSetsAt(m, bn)
+	if len(static) == 0 {
+		return sets, nil
 	}
-	if len(secondArgs) == 0 {
-		return nil
+	seen := make(map[string]bool, len(sets))
+	for _, set := range sets {
+		seen[argsKey(set)] = true
+	}
+	for _, set := range static {
+		if key := argsKey(set); !seen[key] {
+			seen[key] = true
+			sets = append(sets, set)
+		}
 	}
 
-	results := make([]types.Result, 0, len(firstArgs)*len(secondArgs))
-	for arg1 := range firstArgs {
-		for arg2 := range secondArgs {
-			in := []interface{}{arg1, arg2}
-			strIn := []interface{}{contract.StringifyArg(arg1), contract.StringifyArg(arg2)}
+	return sets, nil
+}
 
-			var out interface{}
-			err := p.fetcher.FetchContractData(p.contract.Abi, p.contract.Address, m.Name, in, &out, bn)
-			if err != nil {
-				return fmt.Errorf("poller error calling 2 argument method\r\nblock: %d, method: %s, contract: %s\r\nerr: %v", bn, m.Name, p.contract.Address, err)
-			}
-			strOut, err := stringify(out)
-			if err != nil {
-				return err
+// Returns every combination of the values available for each of the method's arguments
+func (p *poller) combinations(m types.Method, bn int64) ([][]interface{}, error) {
+	// Depending on the type of each arg choose
+	// the correct set of values to iterate over
+	argValues := make([][]interface{}, len(m.Args))
+	for i, arg := range m.Args {
+		values, err := p.contract.ArgValues(arg, bn)
+		if err != nil {
+			return nil, err
+		}
+		piped, err := p.contract.PipedArgValues(m, arg, bn)
+		if err != nil {
+			return nil, err
+		}
+		values = appendNew(values, piped)
+		if len(values) == 0 {
+			return nil, nil
+		}
+		argValues[i] = values
+	}
+
+	sets := make([][]interface{}, 0)
+	positions := make([]int, len(argValues))
+	for {
+		var in []interface{}
+		for i, values := range argValues {
+			in = append(in, values[positions[i]])
+		}
+		sets = append(sets, in)
+
+		// Advance to the next combination of argument values, stopping once they have all been used
+		i := len(positions) - 1
+		for ; i >= 0; i-- {
+			positions[i]++
+			if positions[i] < len(argValues[i]) {
+				break
 			}
-			p.cache(out)
+			positions[i] = 0
+		}
+		if i < 0 {
+			break
+		}
+	}
+
+	return sets, nil
+}
 
-			result.Output = strOut
-			result.Inputs = strIn
-			results = append(results, result)
+// Appends those of the values which are not already present
+func appendNew(values, more []interface{}) []interface{} {
+	if len(more) == 0 {
+		return values
+	}
+	seen := make(map[string]bool, len(values))
+	for _, value := range values {
+		seen[contract.StringifyArg(value)] = true
+	}
+	for _, value := range more {
+		if str := contract.StringifyArg(value); !seen[str] {
+			seen[str] = true
+			values = append(values, value)
 		}
 	}
 
-	err := p.PersistResults(results, m, p.contract.Address, p.contract.Name)
-	if err != nil {
-		return fmt.Errorf("poller error persisting 2 argument method result\r\nblock: %d, method: %s, contract: %s\r\nerr: %v", bn, m.Name, p.contract.Address, err)
+	return values
+}
+
+func argsKey(args []interface{}) string {
+	strs := make([]string, len(args))
+	for i, arg := range args {
+		strs[i] = contract.StringifyArg(arg)
 	}
 
-	return nil
+	return strings.Join(strs, ",")
 }
 
 // FetchContractData is just a wrapper around the poller blockchain's FetchContractData method
@@ -248,7 +286,14 @@ func (p *poller) FetchContractData(contractAbi, contractAddress, method string,
 }
 
 // This is used to cache a method return value if method piping is turned on
-func (p *poller) cache(out interface{}) {
+// Where pipes are configured the value is piped only to the methods the method is piped to, which are polled after it
+func (p *poller) cache(m types.Method, output types.Field, out interface{}) {
+	if len(p.contract.Pipes) > 0 {
+		for _, target := range p.contract.Pipes[m.Name] {
+			p.contract.AddPipedValue(target, output.Type.String(), contract.StringifyArg(out))
+		}
+		return
+	}
 	if p.contract.Piping {
 		switch out.(type) {
 		case common.Hash:
@@ -268,6 +313,23 @@ func (p *poller) cache(out interface{}) {
 	}
 }
 
+// Replaces any tuples, which are unpacked as structs, among the returned values with their components
+func flatten(values []interface{}) []interface{} {
+	flattened := make([]interface{}, 0, len(values))
+	for _, value := range values {
+		v := reflect.ValueOf(value)
+		if v.Kind() != reflect.Struct {
+			flattened = append(flattened, value)
+			continue
+		}
+		for i := 0; i < v.NumField(); i++ {
+			flattened = append(flattened, v.Field(i).Interface())
+		}
+	}
+
+	return flattened
+}
+
 func stringify(input interface{}) (string, error) {
 	switch input.(type) {
 	case *big.Int:
@@ -284,12 +346,22 @@ func stringify(input interface{}) (string, error) {
 	case []byte:
 		b := hexutil.Encode(input.([]byte))
 		return b, nil
-	case byte:
-		b := input.(byte)
-		return string(b), nil
 	case bool:
 		return strconv.FormatBool(input.(bool)), nil
-	default:
-		return "", errors.New("error: unhandled return type")
 	}
+
+	// Integers of 64 bits or less, and fixed size byte arrays other than hashes, are unpacked as their native go types
+	v := reflect.ValueOf(input)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return contract.StringifyArg(input), nil
+		}
+	}
+
+	return "", errors.New("error: unhandled return type")
 }
@@ -0,0 +1,175 @@
+// VulcanizeDB
+// Copyright © 2019 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/vulcanize/eth-contract-watcher/pkg/contract"
+	"github.com/vulcanize/eth-contract-watcher/pkg/naming"
+	"github.com/vulcanize/eth-contract-watcher/pkg/storage"
+)
+
+// ArgRepository persists the method argument values collected from each contract's events, so that they survive restarts
+// Each value is recorded against the header it was emitted at, and is removed along with that header
+type ArgRepository interface {
+	CreateArgTable(contractAddr string) error
+	PersistArgs(contractAddr string, headerID, blockNumber int64, args map[contract.TouchedArg]bool) error
+	LoadArgs(contractAddr string) ([]KnownArg, error)
+	LoadArgsBetween(contractAddr string, fromBlock, toBlock int64) ([]KnownArg, error)
+	QueryArgs(query string) ([][]string, error)
+}
+
+// KnownArg is an argument value collected for a contract, with its abi type and, where loaded, the block it was emitted at
+type KnownArg struct {
+	Type        string `db:"arg_type"`
+	Value       string `db:"value"`
+	BlockNumber int64  `db:"block_number"`
+}
+
+type argRepository struct {
+	db    *storage.DB
+	namer *naming.Namer
+}
+
+// NewArgRepository returns a new ArgRepository
+func NewArgRepository(db *storage.DB, namer *naming.Namer) ArgRepository {
+	return &argRepository{
+		db:    db,
+		namer: namer,
+	}
+}
+
+// CreateArgTable creates the contract's `emitted_args` table, if it does not already exist,
+// and its `known_args` view of every distinct value with the range of blocks it was seen over
+func (r *argRepository) CreateArgTable(contractAddr string) error {
+	err := r.db.Driver.CreateSchema(r.db.DB, r.namer.EventSchema(contractAddr))
+	if err != nil {
+		return fmt.Errorf("error creating schema: %s", err.Error())
+	}
+	table := r.namer.ContractTable(contractAddr, naming.EmittedArgsTable)
+	_, err = r.db.Exec(fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (id %s,
+			header_id INTEGER NOT NULL REFERENCES headers (id) ON DELETE CASCADE,
+			block_number BIGINT NOT NULL,
+			arg_type %s NOT NULL,
+			value %s NOT NULL,
+			UNIQUE (header_id, arg_type, value))`,
+		r.db.Driver.Table(table), r.db.Driver.ColumnType("SERIAL"), r.db.Driver.ColumnType("TEXT"), r.db.Driver.ColumnType("TEXT")))
+	if err != nil {
+		return fmt.Errorf("error creating emitted args table: %s", err.Error())
+	}
+
+	return r.db.Driver.CreateView(r.db.DB, r.namer.ContractTable(contractAddr, naming.KnownArgsTable), fmt.Sprintf(
+		`SELECT arg_type, value, MIN(block_number) AS first_block, MAX(block_number) AS last_block FROM %s GROUP BY arg_type, value`,
+		r.db.Driver.Table(table)))
+}
+
+// PersistArgs records the argument values emitted at the header, once for each abi type they were emitted as
+func (r *argRepository) PersistArgs(contractAddr string, headerID, blockNumber int64, args map[contract.TouchedArg]bool) error {
+	if len(args) == 0 {
+		return nil
+	}
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	// Insert in a stable order
+	touched := make([]contract.TouchedArg, 0, len(args))
+	for arg := range args {
+		touched = append(touched, arg)
+	}
+	sort.Slice(touched, func(i, j int) bool {
+		if touched[i].Type != touched[j].Type {
+			return touched[i].Type < touched[j].Type
+		}
+		return touched[i].Value < touched[j].Value
+	})
+	pgStr := fmt.Sprintf(`INSERT INTO %s (header_id, block_number, arg_type, value) VALUES ($1, $2, $3, $4)
+			ON CONFLICT (header_id, arg_type, value) DO NOTHING`, r.db.Driver.Table(r.namer.ContractTable(contractAddr, naming.EmittedArgsTable)))
+	for _, arg := range touched {
+		_, err = tx.Exec(pgStr, headerID, blockNumber, arg.Type, arg.Value)
+		if err != nil {
+			rollbackErr := tx.Rollback()
+			if rollbackErr != nil {
+				logrus.Warnf("error rolling back transaction: %s", rollbackErr.Error())
+			}
+			return fmt.Errorf("error persisting emitted args: %s", err.Error())
+		}
+	}
+
+	return tx.Commit()
+}
+
+// LoadArgs returns every distinct argument value recorded for the contract
+func (r *argRepository) LoadArgs(contractAddr string) ([]KnownArg, error) {
+	var args []KnownArg
+	err := r.db.Select(&args, fmt.Sprintf(`SELECT DISTINCT arg_type, value FROM %s ORDER BY arg_type, value`,
+		r.db.Driver.Table(r.namer.ContractTable(contractAddr, naming.EmittedArgsTable))))
+	if err != nil {
+		return nil, fmt.Errorf("error loading emitted args: %s", err.Error())
+	}
+
+	return args, nil
+}
+
+// LoadArgsBetween returns the argument values recorded after the first block up to and including the second,
+// in the order they were emitted
+func (r *argRepository) LoadArgsBetween(contractAddr string, fromBlock, toBlock int64) ([]KnownArg, error) {
+	var args []KnownArg
+	err := r.db.Select(&args, fmt.Sprintf(`SELECT arg_type, value, block_number FROM %s
+			WHERE block_number > $1 AND block_number <= $2 ORDER BY block_number, id`,
+		r.db.Driver.Table(r.namer.ContractTable(contractAddr, naming.EmittedArgsTable))), fromBlock, toBlock)
+	if err != nil {
+		return nil, fmt.Errorf("error loading emitted args: %s", err.Error())
+	}
+
+	return args, nil
+}
+
+// QueryArgs runs a configured query for sets of method argument values, returning the columns of each row as strings
+func (r *argRepository) QueryArgs(query string) ([][]string, error) {
+	rows, err := r.db.Queryx(query)
+	if err != nil {
+		return nil, fmt.Errorf("error querying method arguments: %s", err.Error())
+	}
+	defer rows.Close()
+	sets := make([][]string, 0)
+	for rows.Next() {
+		columns, err := rows.SliceScan()
+		if err != nil {
+			return nil, fmt.Errorf("error scanning method arguments: %s", err.Error())
+		}
+		set := make([]string, len(columns))
+		for i, column := range columns {
+			switch value := column.(type) {
+			case nil:
+				return nil, errors.New("error scanning method arguments: null value")
+			case []byte:
+				set[i] = string(value)
+			default:
+				set[i] = fmt.Sprint(value)
+			}
+		}
+		sets = append(sets, set)
+	}
+
+	return sets, rows.Err()
+}
@@ -0,0 +1,344 @@
+// VulcanizeDB
+// Copyright © 2019 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+
+	"github.com/vulcanize/eth-contract-watcher/pkg/abi"
+	"github.com/vulcanize/eth-contract-watcher/pkg/contract"
+	"github.com/vulcanize/eth-contract-watcher/pkg/naming"
+	"github.com/vulcanize/eth-contract-watcher/pkg/storage"
+	"github.com/vulcanize/eth-contract-watcher/pkg/types"
+)
+
+// CatalogRepository records the watched contracts, their events and methods,
+// and the tables generated for them in the contract_watcher catalog schema
+type CatalogRepository interface {
+	RecordContract(con contract.Contract) error
+	RecordImplementation(contractAddr string, impl contract.Implementation) error
+	LoadImplementations(contractAddr string) ([]contract.Implementation, error)
+}
+
+// The catalog tables, in the contract_watcher schema
+var (
+	catalogContracts     = naming.TableName{Schema: "contract_watcher", Table: "contracts"}
+	catalogEvents        = naming.TableName{Schema: "contract_watcher", Table: "events"}
+	catalogEventFields   = naming.TableName{Schema: "contract_watcher", Table: "event_fields"}
+	catalogMethods       = naming.TableName{Schema: "contract_watcher", Table: "methods"}
+	catalogMethodArgs    = naming.TableName{Schema: "contract_watcher", Table: "method_args"}
+	catalogMethodOutputs = naming.TableName{Schema: "contract_watcher", Table: "method_outputs"}
+	catalogImpls         = naming.TableName{Schema: "contract_watcher", Table: "implementations"}
+)
+
+type catalogRepository struct {
+	db    *storage.DB
+	namer *naming.Namer
+}
+
+type catalogAbis struct {
+	db *storage.DB
+}
+
+// NewCatalogAbiProvider returns an abi provider reading the abis recorded in the catalog for contracts watched before,
+// so that they need not be fetched again
+func NewCatalogAbiProvider(db *storage.DB) abi.AbiProvider {
+	return &catalogAbis{db: db}
+}
+
+// GetAbi returns the abi recorded for the contract
+func (c *catalogAbis) GetAbi(contractAddr string) (string, error) {
+	var abiStr string
+	err := c.db.Get(&abiStr, `SELECT abi FROM `+c.db.Driver.Table(catalogContracts)+` WHERE LOWER(address) = LOWER($1)`, contractAddr)
+	if err == sql.ErrNoRows {
+		return "", abi.ErrAbiNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("error reading abi from catalog: %s", err.Error())
+	}
+
+	return abiStr, nil
+}
+
+// NewCatalogRepository returns a new CatalogRepository
+func NewCatalogRepository(db *storage.DB, namer *naming.Namer) CatalogRepository {
+	return &catalogRepository{
+		db:    db,
+		namer: namer,
+	}
+}
+
+// Watcher settings recorded alongside each contract
+type contractSettings struct {
+	Network    string      `json:"network"`
+	Events     []string    `json:"events"`
+	Methods    []string    `json:"methods"`
+	EventArgs  []string    `json:"eventArgs"`
+	MethodArgs []string    `json:"methodArgs"`
+	Piping     bool        `json:"piping"`
+	Pipes      types.Pipes `json:"pipes,omitempty"`
+}
+
+// RecordContract upserts the catalog entries for the contract in a single transaction
+// Events and methods which are no longer watched are removed from the catalog
+func (r *catalogRepository) RecordContract(con contract.Contract) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+
+	err = r.recordContract(tx, con)
+	if err != nil {
+		rollbackErr := tx.Rollback()
+		if rollbackErr != nil {
+			logrus.Warnf("error rolling back transaction: %s", rollbackErr.Error())
+		}
+		return fmt.Errorf("error recording contract %s in catalog: %s", con.Address, err.Error())
+	}
+
+	return tx.Commit()
+}
+
+func (r *catalogRepository) recordContract(tx *sqlx.Tx, con contract.Contract) error {
+	settings, err := json.Marshal(newContractSettings(con))
+	if err != nil {
+		return err
+	}
+
+	var contractID int64
+	err = tx.QueryRowx(`INSERT INTO `+r.db.Driver.Table(catalogContracts)+`
+			(address, name, alias, abi, starting_block, event_schema, method_schema, config, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, CURRENT_TIMESTAMP)
+			ON CONFLICT (address) DO UPDATE SET
+			(name, alias, abi, starting_block, event_schema, method_schema, config, updated_at) =
+			($2, $3, $4, $5, $6, $7, $8, CURRENT_TIMESTAMP)
+			RETURNING id`,
+		con.Address, con.Name, r.namer.Alias(con.Address), con.OwnAbi(), con.StartingBlock,
+		r.namer.EventSchema(con.Address), r.namer.MethodSchema(con.Address), settings).Scan(&contractID)
+	if err != nil {
+		return err
+	}
+
+	eventNames := make(map[string]bool, len(con.Events))
+	for _, event := range con.Events {
+		eventNames[event.Name] = true
+		err = r.recordEvent(tx, contractID, con.Address, event)
+		if err != nil {
+			return err
+		}
+	}
+	err = r.deleteStale(tx, catalogEvents, contractID, eventNames)
+	if err != nil {
+		return err
+	}
+
+	methodNames := make(map[string]bool, len(con.Methods))
+	for _, method := range con.Methods {
+		methodNames[method.Name] = true
+		err = r.recordMethod(tx, contractID, con.Address, method)
+		if err != nil {
+			return err
+		}
+	}
+
+	return r.deleteStale(tx, catalogMethods, contractID, methodNames)
+}
+
+// Deletes the contract's entries in the given catalog table whose names are not in the provided set
+func (r *catalogRepository) deleteStale(tx *sqlx.Tx, table naming.TableName, contractID int64, names map[string]bool) error {
+	var recorded []string
+	err := tx.Select(&recorded, `SELECT name FROM `+r.db.Driver.Table(table)+` WHERE contract_id = $1`, contractID)
+	if err != nil {
+		return err
+	}
+	for _, name := range recorded {
+		if names[name] {
+			continue
+		}
+		_, err = tx.Exec(`DELETE FROM `+r.db.Driver.Table(table)+` WHERE contract_id = $1 AND name = $2`, contractID, name)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *catalogRepository) recordEvent(tx *sqlx.Tx, contractID int64, contractAddr string, event types.Event) error {
+	table := r.namer.EventTable(contractAddr, event.Identifier)
+	var eventID int64
+	err := tx.QueryRowx(`INSERT INTO `+r.db.Driver.Table(catalogEvents)+`
+			(contract_id, name, identifier, signature, signature_hash, anonymous, table_schema, table_name, check_column)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+			ON CONFLICT (contract_id, name) DO UPDATE SET
+			(identifier, signature, signature_hash, anonymous, table_schema, table_name, check_column) =
+			($3, $4, $5, $6, $7, $8, $9)
+			RETURNING id`,
+		contractID, event.Name, event.Identifier, event.Signature(), event.Sig().Hex(), event.Anonymous,
+		table.Schema, table.Table, r.namer.EventCheckID(contractAddr, event.Identifier)).Scan(&eventID)
+	if err != nil {
+		return err
+	}
+
+	for i, field := range event.Fields {
+		_, err = tx.Exec(`INSERT INTO `+r.db.Driver.Table(catalogEventFields)+`
+				(event_id, position, name, abi_type, pg_type, column_name, indexed)
+				VALUES ($1, $2, $3, $4, $5, $6, $7)
+				ON CONFLICT (event_id, position) DO UPDATE SET
+				(name, abi_type, pg_type, column_name, indexed) = ($3, $4, $5, $6, $7)`,
+			eventID, i, field.Name, field.Type.String(), field.PgType, field.ColumnName, field.Indexed)
+		if err != nil {
+			return err
+		}
+	}
+	_, err = tx.Exec(`DELETE FROM `+r.db.Driver.Table(catalogEventFields)+` WHERE event_id = $1 AND position >= $2`,
+		eventID, len(event.Fields))
+
+	return err
+}
+
+func (r *catalogRepository) recordMethod(tx *sqlx.Tx, contractID int64, contractAddr string, method types.Method) error {
+	table := r.namer.MethodTable(contractAddr, method.Identifier)
+	// Methods returning several values are described by their outputs, with the column each is persisted to
+	var returnType, returnPgType string
+	if len(method.Return) == 1 {
+		returnType = method.Return[0].Type.String()
+		returnPgType = method.Return[0].PgType
+	} else if len(method.Return) > 1 {
+		returnTypes := make([]string, 0, len(method.Return))
+		for _, output := range method.Return {
+			returnTypes = append(returnTypes, output.Type.String())
+		}
+		returnType = "(" + strings.Join(returnTypes, ",") + ")"
+	}
+	var methodID int64
+	err := tx.QueryRowx(`INSERT INTO `+r.db.Driver.Table(catalogMethods)+`
+			(contract_id, name, identifier, signature, selector, return_type, return_pg_type, table_schema, table_name, check_column)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+			ON CONFLICT (contract_id, name) DO UPDATE SET
+			(identifier, signature, selector, return_type, return_pg_type, table_schema, table_name, check_column) =
+			($3, $4, $5, $6, $7, $8, $9, $10)
+			RETURNING id`,
+		contractID, method.Name, method.Identifier, method.Signature(), method.Sig().Hex()[:10], returnType, returnPgType,
+		table.Schema, table.Table, r.namer.MethodCheckID(contractAddr, method.Identifier)).Scan(&methodID)
+	if err != nil {
+		return err
+	}
+
+	for i, arg := range method.Args {
+		_, err = tx.Exec(`INSERT INTO `+r.db.Driver.Table(catalogMethodArgs)+`
+				(method_id, position, name, abi_type, pg_type, column_name)
+				VALUES ($1, $2, $3, $4, $5, $6)
+				ON CONFLICT (method_id, position) DO UPDATE SET
+				(name, abi_type, pg_type, column_name) = ($3, $4, $5, $6)`,
+			methodID, i, arg.Name, arg.Type.String(), arg.PgType, arg.ColumnName)
+		if err != nil {
+			return err
+		}
+	}
+	_, err = tx.Exec(`DELETE FROM `+r.db.Driver.Table(catalogMethodArgs)+` WHERE method_id = $1 AND position >= $2`,
+		methodID, len(method.Args))
+	if err != nil {
+		return err
+	}
+
+	for i, output := range method.Outputs {
+		_, err = tx.Exec(`INSERT INTO `+r.db.Driver.Table(catalogMethodOutputs)+`
+				(method_id, position, name, abi_type, pg_type, column_name)
+				VALUES ($1, $2, $3, $4, $5, $6)
+				ON CONFLICT (method_id, position) DO UPDATE SET
+				(name, abi_type, pg_type, column_name) = ($3, $4, $5, $6)`,
+			methodID, i, output.Name, output.Type.String(), output.PgType, output.ColumnName)
+		if err != nil {
+			return err
+		}
+	}
+	_, err = tx.Exec(`DELETE FROM `+r.db.Driver.Table(catalogMethodOutputs)+` WHERE method_id = $1 AND position >= $2`,
+		methodID, len(method.Outputs))
+
+	return err
+}
+
+// RecordImplementation records the catalogued proxy contract delegating to the implementation from its block on
+func (r *catalogRepository) RecordImplementation(contractAddr string, impl contract.Implementation) error {
+	_, err := r.db.Exec(`INSERT INTO `+r.db.Driver.Table(catalogImpls)+` (contract_id, address, block_number, abi)
+			SELECT id, $1, $2, $3 FROM `+r.db.Driver.Table(catalogContracts)+` WHERE address = $4
+			ON CONFLICT (contract_id, block_number) DO UPDATE SET (address, abi) = ($1, $3)`,
+		impl.Address, impl.Block, impl.Abi, contractAddr)
+	if err != nil {
+		return fmt.Errorf("error recording implementation %s of contract %s: %s", impl.Address, contractAddr, err.Error())
+	}
+
+	return nil
+}
+
+// LoadImplementations returns the implementations recorded for the proxy contract, ascending by block
+func (r *catalogRepository) LoadImplementations(contractAddr string) ([]contract.Implementation, error) {
+	var implementations []contract.Implementation
+	err := r.db.Select(&implementations, `SELECT impls.address, impls.block_number, impls.abi
+			FROM `+r.db.Driver.Table(catalogImpls)+` impls
+			INNER JOIN `+r.db.Driver.Table(catalogContracts)+` contracts ON (impls.contract_id = contracts.id)
+			WHERE contracts.address = $1
+			ORDER BY impls.block_number`, contractAddr)
+	if err != nil {
+		return nil, fmt.Errorf("error loading implementations of contract %s: %s", contractAddr, err.Error())
+	}
+	for i := range implementations {
+		implementations[i].ParsedAbi, err = abi.ParseAbi(implementations[i].Abi)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing abi of implementation %s: %s", implementations[i].Address, err.Error())
+		}
+	}
+
+	return implementations, nil
+}
+
+func newContractSettings(con contract.Contract) contractSettings {
+	settings := contractSettings{
+		Network:    con.Network,
+		Events:     make([]string, 0, len(con.Events)),
+		Methods:    make([]string, 0, len(con.Methods)),
+		EventArgs:  make([]string, 0, len(con.FilterArgs)),
+		MethodArgs: make([]string, 0, len(con.MethodArgs)),
+		Piping:     con.Piping,
+		Pipes:      con.Pipes,
+	}
+	for name := range con.Events {
+		settings.Events = append(settings.Events, name)
+	}
+	for _, method := range con.Methods {
+		settings.Methods = append(settings.Methods, method.Name)
+	}
+	for arg := range con.FilterArgs {
+		settings.EventArgs = append(settings.EventArgs, arg)
+	}
+	for arg := range con.MethodArgs {
+		settings.MethodArgs = append(settings.MethodArgs, arg)
+	}
+	sort.Strings(settings.Events)
+	sort.Strings(settings.EventArgs)
+	sort.Strings(settings.MethodArgs)
+
+	return settings
+}
@@ -0,0 +1,88 @@
+// VulcanizeDB
+// Copyright © 2019 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package repository_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/vulcanize/eth-contract-watcher/pkg/contract"
+	"github.com/vulcanize/eth-contract-watcher/pkg/helpers/test_helpers"
+	"github.com/vulcanize/eth-contract-watcher/pkg/naming"
+	"github.com/vulcanize/eth-contract-watcher/pkg/repository"
+	"github.com/vulcanize/eth-contract-watcher/pkg/storage"
+	"github.com/vulcanize/eth-contract-watcher/pkg/types"
+)
+
+var _ = Describe("Catalog repository", func() {
+	var db *storage.DB
+	var catalog repository.CatalogRepository
+	var con *contract.Contract
+
+	BeforeEach(func() {
+		con = test_helpers.SetupTusdContract([]string{"Transfer"}, []string{"balanceOf"})
+		db, _ = test_helpers.SetupDBandClient()
+		catalog = repository.NewCatalogRepository(db, naming.DefaultNamer(types.HeaderSync))
+	})
+
+	AfterEach(func() {
+		test_helpers.TearDown(db)
+	})
+
+	It("Records the contract, its events, methods and generated tables", func() {
+		err := catalog.RecordContract(*con)
+		Expect(err).ToNot(HaveOccurred())
+
+		var tableName, checkColumn string
+		err = db.QueryRow(`SELECT e.table_name, e.check_column FROM contract_watcher.events e
+			INNER JOIN contract_watcher.contracts c ON (e.contract_id = c.id)
+			WHERE c.address = $1 AND e.name = 'Transfer'`, con.Address).Scan(&tableName, &checkColumn)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(tableName).To(Equal("transfer_event"))
+		Expect(checkColumn).To(Equal(naming.DefaultNamer(types.HeaderSync).EventCheckID(con.Address, "transfer")))
+
+		var fieldCount int
+		err = db.Get(&fieldCount, `SELECT COUNT(*) FROM contract_watcher.event_fields`)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(fieldCount).To(Equal(3))
+
+		var selector string
+		err = db.Get(&selector, `SELECT selector FROM contract_watcher.methods WHERE name = 'balanceOf'`)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(selector).To(Equal("0x70a08231"))
+	})
+
+	It("Removes events and methods which are no longer watched", func() {
+		err := catalog.RecordContract(*con)
+		Expect(err).ToNot(HaveOccurred())
+
+		con.Methods = nil
+		err = catalog.RecordContract(*con)
+		Expect(err).ToNot(HaveOccurred())
+
+		var contractCount, methodCount, argCount int
+		err = db.Get(&contractCount, `SELECT COUNT(*) FROM contract_watcher.contracts`)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(contractCount).To(Equal(1))
+		err = db.Get(&methodCount, `SELECT COUNT(*) FROM contract_watcher.methods`)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(methodCount).To(Equal(0))
+		err = db.Get(&argCount, `SELECT COUNT(*) FROM contract_watcher.method_args`)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(argCount).To(Equal(0))
+	})
+})
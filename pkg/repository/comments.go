@@ -0,0 +1,40 @@
+// VulcanizeDB
+// Copyright © 2019 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package repository
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/vulcanize/eth-contract-watcher/pkg/types"
+)
+
+// Generates the statements which comment a generated table and its columns with the abi names they were generated from,
+// so that readers can map abi names to the (possibly renamed, deduplicated, or truncated) identifiers
+func tableComment(tableID, abiName string, fields []types.Field) string {
+	stmts := make([]string, 0, len(fields)+1)
+	stmts = append(stmts, fmt.Sprintf("COMMENT ON TABLE %s IS %s", tableID, quoteLiteral(abiName)))
+	for _, field := range fields {
+		stmts = append(stmts, fmt.Sprintf("COMMENT ON COLUMN %s.%s IS %s", tableID, field.ColumnName, quoteLiteral(field.Name)))
+	}
+
+	return strings.Join(stmts, "; ")
+}
+
+func quoteLiteral(str string) string {
+	return "'" + strings.Replace(str, "'", "''", -1) + "'"
+}
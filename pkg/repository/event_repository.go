@@ -19,13 +19,13 @@ package repository
 import (
 	"errors"
 	"fmt"
-	"strings"
+	"sync"
 
 	"github.com/hashicorp/golang-lru"
 	"github.com/sirupsen/logrus"
 
-	"github.com/vulcanize/eth-header-sync/pkg/postgres"
-
+	"github.com/vulcanize/eth-contract-watcher/pkg/naming"
+	"github.com/vulcanize/eth-contract-watcher/pkg/storage"
 	"github.com/vulcanize/eth-contract-watcher/pkg/types"
 )
 
@@ -45,21 +45,28 @@ type EventRepository interface {
 }
 
 type eventRepository struct {
-	db      *postgres.DB
-	mode    types.Mode
-	schemas *lru.Cache // Cache names of recently used schemas to minimize db connections
-	tables  *lru.Cache // Cache names of recently used tables to minimize db connections
+	db         *storage.DB
+	mode       types.Mode
+	namer      *naming.Namer
+	schemas    *lru.Cache // Cache names of recently used schemas to minimize db connections
+	tables     *lru.Cache // Cache names of recently used tables to minimize db connections
+	partitions *partitioner
+	indexMutex sync.Mutex
+	indexing   map[string]bool // Tables whose missing indexes are being built in the background
 }
 
 // NewEventRepository returns a new EventRepository
-func NewEventRepository(db *postgres.DB, mode types.Mode) EventRepository {
+func NewEventRepository(db *storage.DB, mode types.Mode, namer *naming.Namer) EventRepository {
 	ccs, _ := lru.New(contractCacheSize)
 	ecs, _ := lru.New(eventCacheSize)
 	return &eventRepository{
-		db:      db,
-		mode:    mode,
-		schemas: ccs,
-		tables:  ecs,
+		db:         db,
+		mode:       mode,
+		namer:      namer,
+		schemas:    ccs,
+		tables:     ecs,
+		partitions: newPartitioner(db, eventCacheSize),
+		indexing:   make(map[string]bool),
 	}
 }
 
@@ -99,14 +106,40 @@ func (r *eventRepository) persistLogs(logs []types.Log, eventInfo types.Event, c
 
 // Creates a custom postgres command to persist logs for the given event (compatible with header synced vDB)
 func (r *eventRepository) persistHeaderSyncLogs(logs []types.Log, eventInfo types.Event, contractAddr, contractName string) error {
+	// Partitioned tables also record the block number of the header, which they are partitioned on
+	table := r.namer.EventTable(contractAddr, eventInfo.Identifier)
+	partitioned, partitionErr := r.partitions.track(table, eventInfo.PartitionSize, false)
+	if partitionErr != nil {
+		return fmt.Errorf("error checking for partitions: %s", partitionErr.Error())
+	}
+	blockNumbers := make(map[int64]int64)
+	if partitioned {
+		for _, event := range logs {
+			if _, ok := blockNumbers[event.ID]; ok {
+				continue
+			}
+			var blockNumber int64
+			blockErr := r.db.Get(&blockNumber, `SELECT block_number FROM headers WHERE id = $1`, event.ID)
+			if blockErr != nil {
+				return fmt.Errorf("error retrieving block number for header %d: %s", event.ID, blockErr.Error())
+			}
+			ensureErr := r.partitions.ensure(table, eventInfo.PartitionSize, blockNumber)
+			if ensureErr != nil {
+				return ensureErr
+			}
+			blockNumbers[event.ID] = blockNumber
+		}
+	}
+
 	tx, txErr := r.db.Beginx()
 	if txErr != nil {
 		return fmt.Errorf("error beginning db transaction: %s", txErr.Error())
 	}
 
+	columns := eventInfo.Columns()
 	for _, event := range logs {
 		// Begin pg query string
-		pgStr := fmt.Sprintf("INSERT INTO %s_%s.%s_event ", r.mode.String(), strings.ToLower(contractAddr), strings.ToLower(eventInfo.Name))
+		pgStr := fmt.Sprintf("INSERT INTO %s ", r.db.Driver.Table(table))
 		pgStr = pgStr + "(header_id, token_name, raw_log, log_idx, tx_idx"
 		el := len(event.Values)
 
@@ -121,9 +154,14 @@ func (r *eventRepository) persistHeaderSyncLogs(logs []types.Log, eventInfo type
 
 		// Iterate over inputs and append name to query string and value to input data
 		for inputName, input := range event.Values {
-			pgStr = pgStr + fmt.Sprintf(", %s_", strings.ToLower(inputName)) // Add underscore after to avoid any collisions with reserved pg words
+			pgStr = pgStr + fmt.Sprintf(", %s", columns[inputName])
 			data = append(data, input)
 		}
+		if partitioned {
+			pgStr = pgStr + ", block_number"
+			data = append(data, blockNumbers[event.ID])
+			el++
+		}
 
 		// For each input entry we created we add its postgres command variable to the string
 		pgStr = pgStr + ") VALUES ($1, $2, $3, $4, $5"
@@ -149,13 +187,22 @@ func (r *eventRepository) persistHeaderSyncLogs(logs []types.Log, eventInfo type
 
 // Creates a custom postgres command to persist logs for the given event (compatible with fully synced vDB)
 func (r *eventRepository) persistFullSyncLogs(logs []types.Log, eventInfo types.Event, contractAddr, contractName string) error {
+	table := r.namer.EventTable(contractAddr, eventInfo.Identifier)
+	for _, event := range logs {
+		ensureErr := r.partitions.ensure(table, eventInfo.PartitionSize, event.Block)
+		if ensureErr != nil {
+			return ensureErr
+		}
+	}
+
 	tx, txErr := r.db.Beginx()
 	if txErr != nil {
 		return fmt.Errorf("error beginning db transaction: %s", txErr.Error())
 	}
 
+	columns := eventInfo.Columns()
 	for _, event := range logs {
-		pgStr := fmt.Sprintf("INSERT INTO %s_%s.%s_event ", r.mode.String(), strings.ToLower(contractAddr), strings.ToLower(eventInfo.Name))
+		pgStr := fmt.Sprintf("INSERT INTO %s ", r.db.Driver.Table(table))
 		pgStr = pgStr + "(vulcanize_log_id, token_name, block, tx"
 		el := len(event.Values)
 
@@ -167,7 +214,7 @@ func (r *eventRepository) persistFullSyncLogs(logs []types.Log, eventInfo types.
 			event.Tx)
 
 		for inputName, input := range event.Values {
-			pgStr = pgStr + fmt.Sprintf(", %s_", strings.ToLower(inputName))
+			pgStr = pgStr + fmt.Sprintf(", %s", columns[inputName])
 			data = append(data, input)
 		}
 
@@ -175,7 +222,7 @@ func (r *eventRepository) persistFullSyncLogs(logs []types.Log, eventInfo types.
 		for i := 0; i < el; i++ {
 			pgStr = pgStr + fmt.Sprintf(", $%d", i+5)
 		}
-		pgStr = pgStr + ") ON CONFLICT (vulcanize_log_id) DO NOTHING"
+		pgStr = pgStr + ") ON CONFLICT DO NOTHING"
 
 		logrus.Tracef("query for inserting log: %s", pgStr)
 		_, execErr := tx.Exec(pgStr, data...)
@@ -194,72 +241,144 @@ func (r *eventRepository) persistFullSyncLogs(logs []types.Log, eventInfo types.
 // CreateEventTable checks for event table and creates it if it does not already exist
 // Returns true if it created a new table; returns false if table already existed
 func (r *eventRepository) CreateEventTable(contractAddr string, event types.Event) (bool, error) {
-	tableID := fmt.Sprintf("%s_%s.%s_event", r.mode.String(), strings.ToLower(contractAddr), strings.ToLower(event.Name))
+	event.PartitionSize = r.partitions.size(event.PartitionSize)
+	table := r.namer.EventTable(contractAddr, event.Identifier)
+	tableID := table.String()
 	// Check cache before querying pq to see if table exists
 	_, ok := r.tables.Get(tableID)
 	if ok {
 		return false, nil
 	}
-	tableExists, checkTableErr := r.checkForTable(contractAddr, event.Name)
+	tableExists, checkTableErr := r.db.Driver.TableExists(r.db.DB, table)
 	if checkTableErr != nil {
 		return false, fmt.Errorf("error checking for table: %s", checkTableErr)
 	}
 
+	legacy := r.namer.LegacyEventTable(contractAddr, event.Identifier)
+	if !tableExists && legacy != table {
+		moved, moveErr := moveLegacyTable(r.db, legacy, table)
+		if moveErr != nil {
+			return false, fmt.Errorf("error moving legacy table: %s", moveErr.Error())
+		}
+		tableExists = moved
+	}
+
 	if !tableExists {
-		createTableErr := r.newEventTable(tableID, event)
+		createTableErr := r.newEventTable(table, event)
 		if createTableErr != nil {
 			return false, fmt.Errorf("error creating table: %s", createTableErr.Error())
 		}
 	}
 
+	// Keep the default name working if the table has been renamed
+	if legacy != table {
+		viewErr := createCompatibilityView(r.db, legacy, table)
+		if viewErr != nil {
+			return false, fmt.Errorf("error creating compatibility view: %s", viewErr.Error())
+		}
+	}
+
+	partitioned, partitionErr := r.partitions.track(table, event.PartitionSize, !tableExists)
+	if partitionErr != nil {
+		return false, fmt.Errorf("error checking for partitions: %s", partitionErr.Error())
+	}
+	if event.PartitionSize > 0 && !partitioned {
+		logrus.Warnf("table %s was created without partitions and will not be partitioned", tableID)
+	}
+
+	// Indexes on a new table build immediately; missing indexes on an existing table
+	// are built in the background so that persisting logs is not held up
+	if len(event.Indexes) > 0 {
+		if !tableExists {
+			indexErr := createIndexes(r.db, table, event.Indexes, partitioned)
+			if indexErr != nil {
+				return false, fmt.Errorf("error creating indexes: %s", indexErr.Error())
+			}
+		} else {
+			r.createIndexesInBackground(table, event.Indexes, partitioned)
+		}
+	}
+
 	// Add table id to cache
 	r.tables.Add(tableID, true)
 
 	return !tableExists, nil
 }
 
+// Builds the missing indexes of an existing table in the background, unless they are already being built
+// An index still being built concurrently is not yet valid, so a second build would drop it as if it had been interrupted
+func (r *eventRepository) createIndexesInBackground(table naming.TableName, columns []string, partitioned bool) {
+	tableID := table.String()
+	r.indexMutex.Lock()
+	defer r.indexMutex.Unlock()
+	if r.indexing[tableID] {
+		return
+	}
+	r.indexing[tableID] = true
+	go func() {
+		indexErr := createIndexes(r.db, table, columns, partitioned)
+		if indexErr != nil {
+			logrus.Errorf("error creating indexes for table %s: %s", tableID, indexErr.Error())
+		}
+		r.indexMutex.Lock()
+		delete(r.indexing, tableID)
+		r.indexMutex.Unlock()
+	}()
+}
+
 // Creates a table for the given contract and event
-func (r *eventRepository) newEventTable(tableID string, event types.Event) error {
+func (r *eventRepository) newEventTable(table naming.TableName, event types.Event) error {
 	// Begin pg string
-	var pgStr = fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s ", tableID)
+	var pgStr = fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s ", r.db.Driver.Table(table))
 	var err error
+	columnType := r.db.Driver.ColumnType
 
 	// Handle different modes
+	// Unique constraints on a partitioned table must include the block number it is partitioned on
 	switch r.mode {
 	case types.FullSync:
-		pgStr = pgStr + "(id SERIAL, vulcanize_log_id INTEGER NOT NULL UNIQUE, token_name CHARACTER VARYING(66) NOT NULL, block INTEGER NOT NULL, tx CHARACTER VARYING(66) NOT NULL,"
+		pgStr = pgStr + fmt.Sprintf("(id %s, vulcanize_log_id INTEGER NOT NULL, token_name %s NOT NULL, block INTEGER NOT NULL, tx %s NOT NULL,",
+			columnType("SERIAL"), columnType("CHARACTER VARYING(66)"), columnType("CHARACTER VARYING(66)"))
 
 		// Iterate over event fields, using their name and pgType to grow the string
 		for _, field := range event.Fields {
-			pgStr = pgStr + fmt.Sprintf(" %s_ %s NOT NULL,", strings.ToLower(field.Name), field.PgType)
+			pgStr = pgStr + fmt.Sprintf(" %s %s NOT NULL,", field.ColumnName, columnType(field.PgType))
+		}
+		if event.PartitionSize > 0 {
+			pgStr = pgStr + " UNIQUE (block, vulcanize_log_id),"
+		} else {
+			pgStr = pgStr + " UNIQUE (vulcanize_log_id),"
 		}
 		pgStr = pgStr + " CONSTRAINT log_index_fk FOREIGN KEY (vulcanize_log_id) REFERENCES full_sync_logs (id) ON DELETE CASCADE)"
+		if event.PartitionSize > 0 {
+			pgStr = pgStr + " PARTITION BY RANGE (block)"
+		}
 	case types.HeaderSync:
-		pgStr = pgStr + "(id SERIAL, header_id INTEGER NOT NULL REFERENCES headers (id) ON DELETE CASCADE, token_name CHARACTER VARYING(66) NOT NULL, raw_log JSONB, log_idx INTEGER NOT NULL, tx_idx INTEGER NOT NULL,"
+		pgStr = pgStr + fmt.Sprintf("(id %s, header_id INTEGER NOT NULL REFERENCES headers (id) ON DELETE CASCADE, token_name %s NOT NULL, raw_log %s, log_idx INTEGER NOT NULL, tx_idx INTEGER NOT NULL,",
+			columnType("SERIAL"), columnType("CHARACTER VARYING(66)"), columnType("JSONB"))
 
 		for _, field := range event.Fields {
-			pgStr = pgStr + fmt.Sprintf(" %s_ %s NOT NULL,", strings.ToLower(field.Name), field.PgType)
+			pgStr = pgStr + fmt.Sprintf(" %s %s NOT NULL,", field.ColumnName, columnType(field.PgType))
+		}
+		if event.PartitionSize > 0 {
+			pgStr = pgStr + " block_number BIGINT NOT NULL, UNIQUE (block_number, header_id, tx_idx, log_idx)) PARTITION BY RANGE (block_number)"
+		} else {
+			pgStr = pgStr + " UNIQUE (header_id, tx_idx, log_idx))"
 		}
-		pgStr = pgStr + " UNIQUE (header_id, tx_idx, log_idx))"
 	default:
 		return errors.New("unhandled repository mode")
 	}
 
+	// Record the abi names the table and columns were generated from
+	if r.db.Driver.Supports(storage.Comments) {
+		pgStr = pgStr + "; " + tableComment(table.String(), event.Name, event.Fields)
+	}
+
 	_, err = r.db.Exec(pgStr)
 
 	return err
 }
 
-// Checks if a table already exists for the given contract and event
-func (r *eventRepository) checkForTable(contractAddr string, eventName string) (bool, error) {
-	pgStr := fmt.Sprintf("SELECT EXISTS (SELECT 1 FROM information_schema.tables WHERE table_schema = '%s_%s' AND table_name = '%s_event')", r.mode.String(), strings.ToLower(contractAddr), strings.ToLower(eventName))
-
-	var exists bool
-	err := r.db.Get(&exists, pgStr)
-
-	return exists, err
-}
-
 // CreateContractSchema checks for contract schema and creates it if it does not already exist
 // Returns true if it created a new schema; returns false if schema already existed
 func (r *eventRepository) CreateContractSchema(contractAddr string) (bool, error) {
@@ -291,19 +410,12 @@ func (r *eventRepository) CreateContractSchema(contractAddr string) (bool, error
 
 // Creates a schema for the given contract
 func (r *eventRepository) newContractSchema(contractAddr string) error {
-	_, err := r.db.Exec("CREATE SCHEMA IF NOT EXISTS " + r.mode.String() + "_" + strings.ToLower(contractAddr))
-
-	return err
+	return r.db.Driver.CreateSchema(r.db.DB, r.namer.EventSchema(contractAddr))
 }
 
 // Checks if a schema already exists for the given contract
 func (r *eventRepository) checkForSchema(contractAddr string) (bool, error) {
-	pgStr := fmt.Sprintf("SELECT EXISTS (SELECT schema_name FROM information_schema.schemata WHERE schema_name = '%s_%s')", r.mode.String(), strings.ToLower(contractAddr))
-
-	var exists bool
-	err := r.db.QueryRow(pgStr).Scan(&exists)
-
-	return exists, err
+	return r.db.Driver.SchemaExists(r.db.DB, r.namer.EventSchema(contractAddr))
 }
 
 // CheckSchemaCache is used to query the schema name cache
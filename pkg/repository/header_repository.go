@@ -23,11 +23,15 @@ import (
 	"github.com/sirupsen/logrus"
 
 	"github.com/vulcanize/eth-header-sync/pkg/core"
-	"github.com/vulcanize/eth-header-sync/pkg/postgres"
+
+	"github.com/vulcanize/eth-contract-watcher/pkg/naming"
+	"github.com/vulcanize/eth-contract-watcher/pkg/storage"
 )
 
 const columnCacheSize = 1000
 
+var checkedHeaders = naming.TableName{Schema: "public", Table: "checked_headers"}
+
 // HeaderRepository interfaces with the header and checked_headers tables
 type HeaderRepository interface {
 	AddCheckColumn(id string) error
@@ -42,12 +46,12 @@ type HeaderRepository interface {
 }
 
 type headerRepository struct {
-	db      *postgres.DB
+	db      *storage.DB
 	columns *lru.Cache // Cache created columns to minimize db connections
 }
 
 // NewHeaderRepository returns a new HeaderRepository
-func NewHeaderRepository(db *postgres.DB) HeaderRepository {
+func NewHeaderRepository(db *storage.DB) HeaderRepository {
 	ccs, _ := lru.New(columnCacheSize)
 	return &headerRepository{
 		db:      db,
@@ -63,9 +67,7 @@ func (r *headerRepository) AddCheckColumn(id string) error {
 		return nil
 	}
 
-	pgStr := "ALTER TABLE public.checked_headers ADD COLUMN IF NOT EXISTS "
-	pgStr = pgStr + id + " INTEGER NOT NULL DEFAULT 0"
-	_, err := r.db.Exec(pgStr)
+	err := r.db.Driver.AddColumns(r.db.DB, checkedHeaders, []string{id}, "INTEGER NOT NULL DEFAULT 0")
 	if err != nil {
 		return err
 	}
@@ -79,17 +81,15 @@ func (r *headerRepository) AddCheckColumn(id string) error {
 // AddCheckColumns adds a checked_header column for all of the provided column ids
 func (r *headerRepository) AddCheckColumns(ids []string) error {
 	var err error
-	baseQuery := "ALTER TABLE public.checked_headers"
 	input := make([]string, 0, len(ids))
 	for _, id := range ids {
 		_, ok := r.columns.Get(id)
 		if !ok {
-			baseQuery += " ADD COLUMN IF NOT EXISTS " + id + " INTEGER NOT NULL DEFAULT 0,"
 			input = append(input, id)
 		}
 	}
 	if len(input) > 0 {
-		_, err = r.db.Exec(baseQuery[:len(baseQuery)-1])
+		err = r.db.Driver.AddColumns(r.db.DB, checkedHeaders, input, "INTEGER NOT NULL DEFAULT 0")
 		if err == nil {
 			for _, id := range input {
 				r.columns.Add(id, true)
@@ -102,7 +102,7 @@ func (r *headerRepository) AddCheckColumns(ids []string) error {
 
 // MarkHeaderChecked marks the header checked for the provided column id
 func (r *headerRepository) MarkHeaderChecked(headerID int64, id string) error {
-	_, err := r.db.Exec(`INSERT INTO public.checked_headers (header_id, `+id+`)
+	_, err := r.db.Exec(`INSERT INTO `+r.db.Driver.Table(checkedHeaders)+` (header_id, `+id+`)
 		VALUES ($1, $2) 
 		ON CONFLICT (header_id) DO
 			UPDATE SET `+id+` = checked_headers.`+id+` + 1`, headerID, 1)
@@ -111,7 +111,7 @@ func (r *headerRepository) MarkHeaderChecked(headerID int64, id string) error {
 
 // MarkHeaderCheckedForAll marks the header checked for all of the provided column ids
 func (r *headerRepository) MarkHeaderCheckedForAll(headerID int64, ids []string) error {
-	pgStr := "INSERT INTO public.checked_headers (header_id, "
+	pgStr := "INSERT INTO " + r.db.Driver.Table(checkedHeaders) + " (header_id, "
 	for _, id := range ids {
 		pgStr += id + ", "
 	}
@@ -135,7 +135,7 @@ func (r *headerRepository) MarkHeadersCheckedForAll(headers []core.Header, ids [
 		return err
 	}
 	for _, header := range headers {
-		pgStr := "INSERT INTO public.checked_headers (header_id, "
+		pgStr := "INSERT INTO " + r.db.Driver.Table(checkedHeaders) + " (header_id, "
 		for _, id := range ids {
 			pgStr += id + ", "
 		}
@@ -167,7 +167,7 @@ func (r *headerRepository) MissingHeaders(startingBlockNumber, endingBlockNumber
 	var query string
 	var err error
 	if endingBlockNumber == -1 {
-		query = `SELECT headers.id, headers.block_number, headers.hash FROM headers
+		query = `SELECT headers.id, headers.block_number, headers.hash, COALESCE(headers.block_timestamp, 0) AS block_timestamp FROM headers
 				LEFT JOIN checked_headers on headers.id = header_id
 				WHERE (header_id ISNULL OR checked_headers.` + id + `=0)
 				AND headers.block_number >= $1
@@ -175,7 +175,7 @@ func (r *headerRepository) MissingHeaders(startingBlockNumber, endingBlockNumber
 				ORDER BY headers.block_number`
 		err = r.db.Select(&result, query, startingBlockNumber, r.db.Node.ID)
 	} else {
-		query = `SELECT headers.id, headers.block_number, headers.hash FROM headers
+		query = `SELECT headers.id, headers.block_number, headers.hash, COALESCE(headers.block_timestamp, 0) AS block_timestamp FROM headers
 				LEFT JOIN checked_headers on headers.id = header_id
 				WHERE (header_id ISNULL OR checked_headers.` + id + `=0)
 				AND headers.block_number >= $1
@@ -187,12 +187,12 @@ func (r *headerRepository) MissingHeaders(startingBlockNumber, endingBlockNumber
 	return continuousHeaders(result), err
 }
 
-// MissingHeadersForAll returns missing headers for all of the provided checked_headers column ids
+// MissingHeadersForAll returns missing headers for all of the provided checked_headers column ids, along with their block times
 func (r *headerRepository) MissingHeadersForAll(startingBlockNumber, endingBlockNumber int64, ids []string) ([]core.Header, error) {
 	var result []core.Header
 	var query string
 	var err error
-	baseQuery := `SELECT headers.id, headers.block_number, headers.hash FROM headers
+	baseQuery := `SELECT headers.id, headers.block_number, headers.hash, COALESCE(headers.block_timestamp, 0) AS block_timestamp FROM headers
 				  LEFT JOIN checked_headers on headers.id = header_id
 				  WHERE (header_id ISNULL`
 	for _, id := range ids {
@@ -220,7 +220,7 @@ func (r *headerRepository) MissingMethodsCheckedEventsIntersection(startingBlock
 	var result []core.Header
 	var query string
 	var err error
-	baseQuery := `SELECT headers.id, headers.block_number, headers.hash FROM headers
+	baseQuery := `SELECT headers.id, headers.block_number, headers.hash, COALESCE(headers.block_timestamp, 0) AS block_timestamp FROM headers
 				  LEFT JOIN checked_headers on headers.id = header_id
 				  WHERE (header_id IS NOT NULL`
 	for _, id := range eventIds {
@@ -23,18 +23,17 @@ import (
 	. "github.com/onsi/gomega"
 
 	"github.com/vulcanize/eth-header-sync/pkg/core"
-	"github.com/vulcanize/eth-header-sync/pkg/postgres"
-	hr "github.com/vulcanize/eth-header-sync/pkg/repository"
 
 	"github.com/vulcanize/eth-contract-watcher/pkg/helpers/test_helpers"
 	"github.com/vulcanize/eth-contract-watcher/pkg/helpers/test_helpers/mocks"
 	"github.com/vulcanize/eth-contract-watcher/pkg/repository"
+	"github.com/vulcanize/eth-contract-watcher/pkg/storage"
 )
 
 var _ = Describe("Repository", func() {
-	var db *postgres.DB
+	var db *storage.DB
 	var contractHeaderRepo repository.HeaderRepository // contract_watcher headerSync header repository
-	var coreHeaderRepo hr.HeaderRepository             // pkg/datastore header repository
+	var coreHeaderRepo storage.HeaderRepository        // pkg/datastore header repository
 	var eventIDs = []string{
 		"eventName_contractAddr",
 		"eventName_contractAddr2",
@@ -49,7 +48,7 @@ var _ = Describe("Repository", func() {
 	BeforeEach(func() {
 		db, _ = test_helpers.SetupDBandClient()
 		contractHeaderRepo = repository.NewHeaderRepository(db)
-		coreHeaderRepo = hr.NewHeaderRepository(db)
+		coreHeaderRepo = storage.NewHeaderRepository(db)
 	})
 
 	AfterEach(func() {
@@ -346,7 +345,7 @@ var _ = Describe("Repository", func() {
 	})
 })
 
-func addHeaders(coreHeaderRepo hr.HeaderRepository) {
+func addHeaders(coreHeaderRepo storage.HeaderRepository) {
 	_, err := coreHeaderRepo.CreateOrUpdateHeader(mocks.MockHeader1)
 	Expect(err).NotTo(HaveOccurred())
 	_, err = coreHeaderRepo.CreateOrUpdateHeader(mocks.MockHeader2)
@@ -355,7 +354,7 @@ func addHeaders(coreHeaderRepo hr.HeaderRepository) {
 	Expect(err).NotTo(HaveOccurred())
 }
 
-func addDiscontinuousHeaders(coreHeaderRepo hr.HeaderRepository) {
+func addDiscontinuousHeaders(coreHeaderRepo storage.HeaderRepository) {
 	_, err := coreHeaderRepo.CreateOrUpdateHeader(mocks.MockHeader1)
 	Expect(err).NotTo(HaveOccurred())
 	_, err = coreHeaderRepo.CreateOrUpdateHeader(mocks.MockHeader2)
@@ -364,7 +363,7 @@ func addDiscontinuousHeaders(coreHeaderRepo hr.HeaderRepository) {
 	Expect(err).NotTo(HaveOccurred())
 }
 
-func addLaterHeaders(coreHeaderRepo hr.HeaderRepository) {
+func addLaterHeaders(coreHeaderRepo storage.HeaderRepository) {
 	_, err := coreHeaderRepo.CreateOrUpdateHeader(mocks.MockHeader3)
 	Expect(err).NotTo(HaveOccurred())
 	_, err = coreHeaderRepo.CreateOrUpdateHeader(mocks.MockHeader4)
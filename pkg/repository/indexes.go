@@ -0,0 +1,156 @@
+// VulcanizeDB
+// Copyright © 2019 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+
+	"github.com/vulcanize/eth-contract-watcher/pkg/naming"
+	"github.com/vulcanize/eth-contract-watcher/pkg/storage"
+	"github.com/vulcanize/eth-contract-watcher/pkg/types"
+)
+
+// Builds btree indexes on the given columns of the table, if they do not already exist
+// Indexes are built concurrently so that writes to the table are not blocked while they build
+// Partitioned tables can not be indexed concurrently, so their partitions are indexed one at a time
+// and attached to an index created on the partitioned table alone
+// Databases which can not build indexes concurrently build them in place
+func createIndexes(db *storage.DB, table naming.TableName, columns []string, partitioned bool) error {
+	for _, column := range columns {
+		name := indexName(table, column)
+		if !db.Driver.Supports(storage.ConcurrentIndexes) {
+			_, err := db.Exec(fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s ON %s (%s)",
+				db.Driver.Table(naming.TableName{Schema: table.Schema, Table: name}), db.Driver.Table(table), column))
+			if err != nil {
+				return fmt.Errorf("error creating index %s: %s", name, err.Error())
+			}
+			continue
+		}
+		if !partitioned {
+			err := createIndex(db, table, name, column)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		_, err := db.Exec(fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s ON ONLY %s USING btree (%s)", name, table.String(), column))
+		if err != nil {
+			return fmt.Errorf("error creating index %s: %s", name, err.Error())
+		}
+		partitions, err := listPartitions(db, table)
+		if err != nil {
+			return err
+		}
+		for _, partition := range partitions {
+			partitionIndex := indexName(partition, column)
+			err = createIndex(db, partition, partitionIndex, column)
+			if err != nil {
+				return err
+			}
+			_, err = db.Exec(fmt.Sprintf("ALTER INDEX %s.%s ATTACH PARTITION %s.%s", table.Schema, name, partition.Schema, partitionIndex))
+			if err != nil {
+				return fmt.Errorf("error attaching index %s: %s", partitionIndex, err.Error())
+			}
+		}
+	}
+
+	return nil
+}
+
+// Concurrently builds a btree index on the column of the table, if it does not already exist
+// An index left invalid by an interrupted concurrent build is dropped and rebuilt
+func createIndex(db *storage.DB, table naming.TableName, name, column string) error {
+	var valid bool
+	err := db.Get(&valid, `SELECT i.indisvalid FROM pg_index i
+			INNER JOIN pg_class c ON (i.indexrelid = c.oid)
+			INNER JOIN pg_namespace n ON (c.relnamespace = n.oid)
+			WHERE n.nspname = $1 AND c.relname = $2`, table.Schema, name)
+	if err == nil && valid {
+		return nil
+	}
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+	if err == nil {
+		_, err = db.Exec(fmt.Sprintf("DROP INDEX CONCURRENTLY IF EXISTS %s.%s", table.Schema, name))
+		if err != nil {
+			return err
+		}
+	}
+	_, err = db.Exec(fmt.Sprintf("CREATE INDEX CONCURRENTLY IF NOT EXISTS %s ON %s USING btree (%s)", name, table.String(), column))
+	if err != nil {
+		return fmt.Errorf("error creating index %s: %s", name, err.Error())
+	}
+
+	return nil
+}
+
+// Builds a unique index on the columns of the table, if it does not already exist
+// Tables created before they were given the index can hold duplicate rows; only when the index fails to build
+// because of them are they removed, keeping the most recently inserted of each, together with building the index
+func createUniqueIndex(db *storage.DB, table naming.TableName, columns []string) error {
+	name := types.TruncateIdentifier(table.Table+"_key", table.String())
+	if !db.Driver.Supports(storage.Schemas) {
+		name = db.Driver.Table(naming.TableName{Schema: table.Schema, Table: name})
+	}
+	key := strings.Join(columns, ", ")
+	pgStr := fmt.Sprintf("CREATE UNIQUE INDEX IF NOT EXISTS %s ON %s (%s)", name, db.Driver.Table(table), key)
+	_, err := db.Exec(pgStr)
+	if err == nil {
+		return nil
+	}
+	if !db.Driver.IsUniqueViolation(err) {
+		return fmt.Errorf("error creating unique index on %s: %s", table.String(), err.Error())
+	}
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return err
+	}
+	err = dedupe(tx, db.Driver.Table(table), key, pgStr)
+	if err != nil {
+		rollbackErr := tx.Rollback()
+		if rollbackErr != nil {
+			logrus.Warnf("error rolling back transaction: %s", rollbackErr.Error())
+		}
+		return fmt.Errorf("error creating unique index on %s: %s", table.String(), err.Error())
+	}
+
+	return tx.Commit()
+}
+
+// Removes the rows duplicating the key of a later row, then builds the unique index
+func dedupe(tx *sqlx.Tx, table, key, createIndex string) error {
+	_, err := tx.Exec(fmt.Sprintf("DELETE FROM %s WHERE id NOT IN (SELECT MAX(id) FROM %s GROUP BY %s)", table, table, key))
+	if err != nil {
+		return fmt.Errorf("error removing duplicate rows: %s", err.Error())
+	}
+	_, err = tx.Exec(createIndex)
+
+	return err
+}
+
+// Index names share a namespace with the tables in a schema, so they are prefixed with the table name
+func indexName(table naming.TableName, column string) string {
+	return types.TruncateIdentifier(table.Table+"_"+column+"_idx", table.String())
+}
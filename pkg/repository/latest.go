@@ -0,0 +1,179 @@
+// VulcanizeDB
+// Copyright © 2019 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package repository
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/vulcanize/eth-contract-watcher/pkg/naming"
+	"github.com/vulcanize/eth-contract-watcher/pkg/types"
+)
+
+// Each method table is accompanied by a `<method>_latest` table holding the most recent result for each set of arguments
+// It is maintained in the same transaction as the method table, and a trigger restores the previous result
+// for a set of arguments when its latest result is removed from the method table, such as along with its header in a reorg
+func (r *methodRepository) createLatestTable(latest, table naming.TableName, method types.Method) error {
+	exists, err := r.Driver.TableExists(r.DB.DB, latest)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		columnType := r.Driver.ColumnType
+		pgStr := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (id %s, token_name %s NOT NULL, block INTEGER NOT NULL,",
+			r.Driver.Table(latest), columnType("SERIAL"), columnType("CHARACTER VARYING(66)"))
+		for _, arg := range method.Args {
+			pgStr = pgStr + fmt.Sprintf(" %s %s NOT NULL,", arg.ColumnName, columnType(arg.PgType))
+		}
+		for _, output := range method.Outputs {
+			pgStr = pgStr + fmt.Sprintf(" %s %s NOT NULL,", output.ColumnName, columnType(output.PgType))
+		}
+		pgStr = pgStr[:len(pgStr)-1] + ")"
+		_, err = r.DB.Exec(pgStr)
+		if err != nil {
+			return err
+		}
+
+		// Fill the table from any results already in the method table
+		_, err = r.DB.Exec(fmt.Sprintf("INSERT INTO %s (%s) SELECT %s FROM %s AS results WHERE %s = (SELECT MAX(%s) FROM %s AS previous%s)",
+			r.Driver.Table(latest), strings.Join(latestColumns(method), ", "), strings.Join(latestSelect(method, "results"), ", "),
+			r.Driver.Table(table), blockColumn(method), blockColumn(method), r.Driver.Table(table), where(argsMatch(method, "previous", "results"))))
+		if err != nil {
+			return fmt.Errorf("error filling latest results table: %s", err.Error())
+		}
+	}
+	if len(method.Args) > 0 {
+		err = createUniqueIndex(r.DB, latest, argColumns(method))
+		if err != nil {
+			return err
+		}
+	}
+
+	// A result is restored from the method table when the latest result for its arguments is removed
+	removed := "block = OLD.block"
+	if method.ChangesOnly {
+		removed = "OLD.valid_to_block IS NULL"
+	}
+	latestArgs := argsMatch(method, r.Driver.Table(latest), "OLD")
+	return r.Driver.CreateDeleteTrigger(r.DB.DB, naming.TableName{Schema: latest.Schema, Table: latest.Table + "_restore"}, table, []string{
+		fmt.Sprintf("DELETE FROM %s%s", r.Driver.Table(latest), where(append(latestArgs, removed))),
+		fmt.Sprintf("INSERT INTO %s (%s) SELECT %s FROM %s AS results WHERE NOT EXISTS (SELECT 1 FROM %s%s)%s ORDER BY %s DESC LIMIT 1",
+			r.Driver.Table(latest), strings.Join(latestColumns(method), ", "), strings.Join(latestSelect(method, "results"), ", "),
+			r.Driver.Table(table), r.Driver.Table(latest), where(latestArgs), and(argsMatch(method, "results", "OLD")), blockColumn(method)),
+	})
+}
+
+// Updates the latest result for the arguments of the result, unless it is already more recent
+func (r *methodRepository) persistLatest(tx *sqlx.Tx, latest naming.TableName, methodInfo types.Method, result types.Result, contractName string) error {
+	data := append([]interface{}{contractName, result.Block}, result.Values()...)
+	updates := []string{"token_name = $1", "block = $2"}
+	for _, column := range outputColumns(methodInfo) {
+		updates = append(updates, fmt.Sprintf("%s = $%d", column, len(updates)+1))
+	}
+	matches := make([]string, 0, len(methodInfo.Args))
+	for i, arg := range methodInfo.Args {
+		data = append(data, result.Inputs[i])
+		matches = append(matches, fmt.Sprintf("%s = $%d", arg.ColumnName, len(data)))
+	}
+	res, err := tx.Exec(fmt.Sprintf("UPDATE %s SET %s%s",
+		r.Driver.Table(latest), strings.Join(updates, ", "), where(append(matches, "block <= $2"))), data...)
+	if err != nil {
+		return err
+	}
+	updated, err := res.RowsAffected()
+	if err != nil || updated > 0 {
+		return err
+	}
+
+	var exists bool
+	inputs := data[len(updates):]
+	err = tx.Get(&exists, fmt.Sprintf("SELECT EXISTS (SELECT 1 FROM %s%s)", r.Driver.Table(latest), where(matchArgs(methodInfo, 1))), inputs...)
+	if err != nil || exists {
+		return err
+	}
+	columns := append(append([]string{"token_name", "block"}, outputColumns(methodInfo)...), argColumns(methodInfo)...)
+	_, err = tx.Exec(fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)",
+		r.Driver.Table(latest), strings.Join(columns, ", "), placeholders(1, len(data))), data...)
+
+	return err
+}
+
+// The columns of a latest results table, other than its id
+func latestColumns(method types.Method) []string {
+	return append([]string{"token_name", "block"}, append(argColumns(method), outputColumns(method)...)...)
+}
+
+// Selects the columns of a latest results table from the method table
+func latestSelect(method types.Method, alias string) []string {
+	selects := []string{alias + ".token_name", alias + "." + blockColumn(method)}
+	for _, column := range argColumns(method) {
+		selects = append(selects, alias+"."+column)
+	}
+
+	for _, column := range outputColumns(method) {
+		selects = append(selects, alias+"."+column)
+	}
+
+	return selects
+}
+
+func argColumns(method types.Method) []string {
+	columns := make([]string, 0, len(method.Args))
+	for _, arg := range method.Args {
+		columns = append(columns, arg.ColumnName)
+	}
+
+	return columns
+}
+
+// Conditions matching the arguments of two tables
+func argsMatch(method types.Method, left, right string) []string {
+	conditions := make([]string, 0, len(method.Args))
+	for _, column := range argColumns(method) {
+		conditions = append(conditions, fmt.Sprintf("%s.%s = %s.%s", left, column, right, column))
+	}
+
+	return conditions
+}
+
+// Conditions matching the arguments to numbered parameters, starting from the given parameter
+func matchArgs(method types.Method, first int) []string {
+	conditions := make([]string, 0, len(method.Args))
+	for i, column := range argColumns(method) {
+		conditions = append(conditions, fmt.Sprintf("%s = $%d", column, first+i))
+	}
+
+	return conditions
+}
+
+func where(conditions []string) string {
+	if len(conditions) == 0 {
+		return ""
+	}
+
+	return " WHERE " + strings.Join(conditions, " AND ")
+}
+
+func and(conditions []string) string {
+	if len(conditions) == 0 {
+		return ""
+	}
+
+	return " AND " + strings.Join(conditions, " AND ")
+}
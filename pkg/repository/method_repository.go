@@ -17,15 +17,17 @@
 package repository
 
 import (
+	"database/sql"
 	"errors"
 	"fmt"
 	"strings"
 
 	"github.com/hashicorp/golang-lru"
+	"github.com/jmoiron/sqlx"
 	"github.com/sirupsen/logrus"
 
-	"github.com/vulcanize/eth-header-sync/pkg/postgres"
-
+	"github.com/vulcanize/eth-contract-watcher/pkg/naming"
+	"github.com/vulcanize/eth-contract-watcher/pkg/storage"
 	"github.com/vulcanize/eth-contract-watcher/pkg/types"
 )
 
@@ -41,21 +43,25 @@ type MethodRepository interface {
 }
 
 type methodRepository struct {
-	*postgres.DB
-	mode    types.Mode
-	schemas *lru.Cache // Cache names of recently used schemas to minimize db connections
-	tables  *lru.Cache // Cache names of recently used tables to minimize db connections
+	*storage.DB
+	mode       types.Mode
+	namer      *naming.Namer
+	schemas    *lru.Cache // Cache names of recently used schemas to minimize db connections
+	tables     *lru.Cache // Cache names of recently used tables to minimize db connections
+	partitions *partitioner
 }
 
 // NewMethodRepository returns a new MethodRepository
-func NewMethodRepository(db *postgres.DB, mode types.Mode) MethodRepository {
+func NewMethodRepository(db *storage.DB, mode types.Mode, namer *naming.Namer) MethodRepository {
 	ccs, _ := lru.New(contractCacheSize)
 	mcs, _ := lru.New(methodCacheSize)
 	return &methodRepository{
-		DB:      db,
-		mode:    mode,
-		schemas: ccs,
-		tables:  mcs,
+		DB:         db,
+		mode:       mode,
+		namer:      namer,
+		schemas:    ccs,
+		tables:     mcs,
+		partitions: newPartitioner(db, methodCacheSize),
 	}
 }
 
@@ -81,40 +87,50 @@ func (r *methodRepository) PersistResults(results []types.Result, methodInfo typ
 
 // Creates a custom postgres command to persist logs for the given event
 func (r *methodRepository) persistResults(results []types.Result, methodInfo types.Method, contractAddr, contractName string) error {
+	table := r.namer.MethodTable(contractAddr, methodInfo.Identifier)
+	for _, result := range results {
+		err := r.partitions.ensure(table, methodInfo.PartitionSize, result.Block)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Header sync results reference the header at their block, so that they are removed along with it
+	headerIDs := make(map[int64]sql.NullInt64)
+	if r.mode == types.HeaderSync {
+		for _, result := range results {
+			if _, ok := headerIDs[result.Block]; ok {
+				continue
+			}
+			headerID, err := r.headerID(result.Block)
+			if err != nil {
+				return fmt.Errorf("error retrieving header at block %d: %s", result.Block, err.Error())
+			}
+			headerIDs[result.Block] = headerID
+		}
+	}
+
 	tx, err := r.DB.Beginx()
 	if err != nil {
 		return err
 	}
 
+	latest := r.namer.MethodVariantTable(contractAddr, methodInfo.Identifier, "latest")
+	reverts := r.namer.MethodVariantTable(contractAddr, methodInfo.Identifier, "reverts")
 	for _, result := range results {
-		// Begin postgres string
-		pgStr := fmt.Sprintf("INSERT INTO %s_%s.%s_method ", r.mode.String(), strings.ToLower(contractAddr), strings.ToLower(result.Name))
-		pgStr = pgStr + "(token_name, block"
-		ml := len(result.Args)
-
-		// Preallocate slice of needed capacity and proceed to pack variables into it in same order they appear in string
-		data := make([]interface{}, 0, 3+ml)
-		data = append(data,
-			contractName,
-			result.Block)
-
-		// Iterate over method args and return value, adding names
-		// to the string and pushing values to the slice
-		for i, arg := range result.Args {
-			pgStr = pgStr + fmt.Sprintf(", %s_", strings.ToLower(arg.Name)) // Add underscore after to avoid any collisions with reserved pg words
-			data = append(data, result.Inputs[i])
-		}
-		pgStr = pgStr + ", returned) VALUES ($1, $2"
-		data = append(data, result.Output)
-
-		// For each input entry we created we add its postgres command variable to the string
-		for i := 0; i <= ml; i++ {
-			pgStr = pgStr + fmt.Sprintf(", $%d", i+3)
-		}
-		pgStr = pgStr + ")"
-
-		// Add this query to the transaction
-		_, err = tx.Exec(pgStr, data...)
+		if result.Reverted {
+			err = r.persistRevert(tx, reverts, methodInfo, result, contractName, headerIDs[result.Block])
+		} else if methodInfo.ChangesOnly {
+			err = r.persistChange(tx, table, methodInfo, result, contractName, headerIDs[result.Block])
+		} else {
+			err = r.persistResult(tx, table, methodInfo, result, contractName, headerIDs[result.Block])
+		}
+		if err == nil && !result.Reverted {
+			err = r.persistLatest(tx, latest, methodInfo, result, contractName)
+		}
+		if err == nil && !result.Reverted {
+			err = r.clearRevert(tx, reverts, methodInfo, result)
+		}
 		if err != nil {
 			rollbackErr := tx.Rollback()
 			if rollbackErr != nil {
@@ -127,26 +143,209 @@ func (r *methodRepository) persistResults(results []types.Result, methodInfo typ
 	return tx.Commit()
 }
 
+// Persists a result as a row for its block
+// Re-polling a block replaces the results persisted for it
+func (r *methodRepository) persistResult(tx *sqlx.Tx, table naming.TableName, methodInfo types.Method, result types.Result, contractName string, headerID sql.NullInt64) error {
+	columns, data := r.resultRow(methodInfo, result, contractName, headerID)
+	updates := "token_name = excluded.token_name"
+	for _, column := range outputColumns(methodInfo) {
+		updates = updates + fmt.Sprintf(", %s = excluded.%s", column, column)
+	}
+	if r.mode == types.HeaderSync {
+		updates = updates + ", header_id = excluded.header_id"
+	}
+	pgStr := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s) ON CONFLICT (%s) DO UPDATE SET %s",
+		r.Driver.Table(table), strings.Join(columns, ", "), placeholders(1, len(data)), strings.Join(methodKey(methodInfo), ", "), updates)
+	_, err := tx.Exec(pgStr, data...)
+
+	return err
+}
+
+// Persists a result to a table which only records changes to the method's value
+// Each row holds the value from its valid_from_block up to, but not including, its valid_to_block;
+// the current value has a null valid_to_block
+func (r *methodRepository) persistChange(tx *sqlx.Tx, table naming.TableName, methodInfo types.Method, result types.Result, contractName string, headerID sql.NullInt64) error {
+	tableName := r.Driver.Table(table)
+	// The result's values are compared to those of the rows around it in the query, so they are numbered first
+	args := result.Values()
+	unchanged := make([]string, 0, len(args))
+	for i, column := range outputColumns(methodInfo) {
+		unchanged = append(unchanged, fmt.Sprintf("%s = $%d", column, i+1))
+	}
+	block := len(args) + 1
+	args = append(args, result.Block)
+	argsStr := ""
+	for i, arg := range methodInfo.Args {
+		args = append(args, result.Inputs[i])
+		argsStr = argsStr + fmt.Sprintf(" AND %s = $%d", arg.ColumnName, len(args))
+	}
+	selectStr := fmt.Sprintf("SELECT id, valid_from_block, valid_to_block, (%s) AS unchanged FROM %s", strings.Join(unchanged, " AND "), tableName)
+
+	// Find the row holding the value at this block
+	var current changeRow
+	err := tx.Get(&current, fmt.Sprintf(`%s WHERE valid_from_block <= $%d AND (valid_to_block IS NULL OR valid_to_block > $%d)`,
+		selectStr, block, block)+argsStr, args...)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+	if err == nil {
+		if current.Unchanged {
+			return nil
+		}
+		// Re-polling the block the row begins at replaces its value
+		if current.ValidFrom == result.Block {
+			updates := []string{"token_name = $1"}
+			data := []interface{}{contractName}
+			for i, column := range outputColumns(methodInfo) {
+				updates = append(updates, fmt.Sprintf("%s = $%d", column, i+2))
+				data = append(data, result.Values()[i])
+			}
+			data = append(data, current.ID)
+			_, err = tx.Exec(fmt.Sprintf(`UPDATE %s SET %s WHERE id = $%d`, tableName, strings.Join(updates, ", "), len(data)), data...)
+			return err
+		}
+		_, err = tx.Exec(`UPDATE `+tableName+` SET valid_to_block = $1 WHERE id = $2`, result.Block, current.ID)
+		if err != nil {
+			return err
+		}
+		return r.insertChange(tx, table, methodInfo, result, contractName, headerID, current.ValidTo)
+	}
+
+	// Blocks polled ahead of this one may already hold the same value
+	var next changeRow
+	err = tx.Get(&next, fmt.Sprintf(`%s WHERE valid_from_block > $%d`, selectStr, block)+argsStr+` ORDER BY valid_from_block LIMIT 1`, args...)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+	if err == sql.ErrNoRows {
+		return r.insertChange(tx, table, methodInfo, result, contractName, headerID, sql.NullInt64{})
+	}
+	if next.Unchanged {
+		if r.mode == types.HeaderSync {
+			_, err = tx.Exec(`UPDATE `+tableName+` SET valid_from_block = $1, header_id = $2 WHERE id = $3`, result.Block, headerID, next.ID)
+			return err
+		}
+		_, err = tx.Exec(`UPDATE `+tableName+` SET valid_from_block = $1 WHERE id = $2`, result.Block, next.ID)
+		return err
+	}
+
+	return r.insertChange(tx, table, methodInfo, result, contractName, headerID, sql.NullInt64{Int64: next.ValidFrom, Valid: true})
+}
+
+// A row of a table storing only changes to a method's value, and whether it holds the same value as a result
+type changeRow struct {
+	ID        int64         `db:"id"`
+	ValidFrom int64         `db:"valid_from_block"`
+	ValidTo   sql.NullInt64 `db:"valid_to_block"`
+	Unchanged bool          `db:"unchanged"`
+}
+
+// Inserts a change to a method's value which holds until the given block
+func (r *methodRepository) insertChange(tx *sqlx.Tx, table naming.TableName, methodInfo types.Method, result types.Result, contractName string, headerID, validTo sql.NullInt64) error {
+	columns, data := r.resultRow(methodInfo, result, contractName, headerID)
+	columns = append(columns, "valid_to_block")
+	data = append(data, validTo)
+	_, err := tx.Exec(fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)",
+		r.Driver.Table(table), strings.Join(columns, ", "), placeholders(1, len(data))), data...)
+
+	return err
+}
+
+// Returns the columns and values of the row persisting the result
+func (r *methodRepository) resultRow(methodInfo types.Method, result types.Result, contractName string, headerID sql.NullInt64) ([]string, []interface{}) {
+	columns := make([]string, 0, 4+len(methodInfo.Args))
+	data := make([]interface{}, 0, 4+len(methodInfo.Args))
+	columns = append(columns, "token_name", blockColumn(methodInfo))
+	data = append(data, contractName, result.Block)
+	for i, arg := range methodInfo.Args {
+		columns = append(columns, arg.ColumnName)
+		data = append(data, result.Inputs[i])
+	}
+	columns = append(columns, outputColumns(methodInfo)...)
+	data = append(data, result.Values()...)
+	if r.mode == types.HeaderSync {
+		columns = append(columns, "header_id")
+		data = append(data, headerID)
+	}
+
+	return columns, data
+}
+
 // CreateMethodTable checks for event table and creates it if it does not already exist
 func (r *methodRepository) CreateMethodTable(contractAddr string, method types.Method) (bool, error) {
-	tableID := fmt.Sprintf("%s_%s.%s_method", r.mode.String(), strings.ToLower(contractAddr), strings.ToLower(method.Name))
+	method.PartitionSize = r.partitions.size(method.PartitionSize)
+	table := r.namer.MethodTable(contractAddr, method.Identifier)
+	tableID := table.String()
 
 	// Check cache before querying pq to see if table exists
 	_, ok := r.tables.Get(tableID)
 	if ok {
 		return false, nil
 	}
-	tableExists, err := r.checkForTable(contractAddr, method.Name)
+	tableExists, err := r.Driver.TableExists(r.DB.DB, table)
 	if err != nil {
 		return false, err
 	}
+	legacy := r.namer.LegacyMethodTable(contractAddr, method.Identifier)
+	if !tableExists && legacy != table {
+		tableExists, err = moveLegacyTable(r.DB, legacy, table)
+		if err != nil {
+			return false, err
+		}
+	}
 	if !tableExists {
-		err = r.newMethodTable(tableID, method)
+		err = r.newMethodTable(table, method)
+		if err != nil {
+			return false, err
+		}
+	} else if r.mode == types.HeaderSync {
+		// Tables created before results referenced their headers
+		err = r.Driver.AddColumns(r.DB.DB, table, []string{"header_id"}, "INTEGER REFERENCES headers (id) ON DELETE CASCADE")
+		if err != nil {
+			return false, err
+		}
+	}
+	err = createUniqueIndex(r.DB, table, methodKey(method))
+	if err != nil {
+		return false, err
+	}
+	err = r.createLatestTable(r.namer.MethodVariantTable(contractAddr, method.Identifier, "latest"), table, method)
+	if err != nil {
+		return false, fmt.Errorf("error creating latest results table: %s", err.Error())
+	}
+	err = r.createRevertTable(r.namer.MethodVariantTable(contractAddr, method.Identifier, "reverts"), method)
+	if err != nil {
+		return false, fmt.Errorf("error creating reverts table: %s", err.Error())
+	}
+	if method.ChangesOnly {
+		err = r.createReopenTrigger(r.namer.MethodVariantTable(contractAddr, method.Identifier, "reopen"), table, method)
+		if err != nil {
+			return false, fmt.Errorf("error creating change removal trigger: %s", err.Error())
+		}
+	}
+	if method.ChangesOnly && r.mode == types.HeaderSync {
+		err = r.createValueAtBlockView(r.namer.MethodVariantTable(contractAddr, method.Identifier, "at_block"), table, method)
 		if err != nil {
 			return false, err
 		}
 	}
 
+	// Keep the default name working if the table has been renamed
+	if legacy != table {
+		err = createCompatibilityView(r.DB, legacy, table)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	partitioned, err := r.partitions.track(table, method.PartitionSize, !tableExists)
+	if err != nil {
+		return false, err
+	}
+	if method.PartitionSize > 0 && !partitioned {
+		logrus.Warnf("table %s was created without partitions and will not be partitioned", tableID)
+	}
+
 	// Add schema name to cache
 	r.tables.Add(tableID, true)
 
@@ -154,30 +353,117 @@ func (r *methodRepository) CreateMethodTable(contractAddr string, method types.M
 }
 
 // Creates a table for the given contract and event
-func (r *methodRepository) newMethodTable(tableID string, method types.Method) error {
+func (r *methodRepository) newMethodTable(table naming.TableName, method types.Method) error {
+	columnType := r.Driver.ColumnType
 	// Begin pg string
-	pgStr := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s ", tableID)
-	pgStr = pgStr + "(id SERIAL, token_name CHARACTER VARYING(66) NOT NULL, block INTEGER NOT NULL,"
+	pgStr := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s ", r.Driver.Table(table))
+	pgStr = pgStr + fmt.Sprintf("(id %s, token_name %s NOT NULL, %s INTEGER NOT NULL,", columnType("SERIAL"), columnType("CHARACTER VARYING(66)"), blockColumn(method))
+	if method.ChangesOnly {
+		pgStr = pgStr + " valid_to_block INTEGER,"
+	}
+	if r.mode == types.HeaderSync {
+		pgStr = pgStr + " header_id INTEGER REFERENCES headers (id) ON DELETE CASCADE,"
+	}
 
 	// Iterate over method inputs and outputs, using their name and pgType to grow the string
 	for _, arg := range method.Args {
-		pgStr = pgStr + fmt.Sprintf(" %s_ %s NOT NULL,", strings.ToLower(arg.Name), arg.PgType)
+		pgStr = pgStr + fmt.Sprintf(" %s %s NOT NULL,", arg.ColumnName, columnType(arg.PgType))
 	}
 
-	pgStr = pgStr + fmt.Sprintf(" returned %s NOT NULL)", method.Return[0].PgType)
+	for _, output := range method.Outputs {
+		pgStr = pgStr + fmt.Sprintf(" %s %s NOT NULL,", output.ColumnName, columnType(output.PgType))
+	}
+	pgStr = pgStr[:len(pgStr)-1] + ")"
+	if method.PartitionSize > 0 {
+		pgStr = pgStr + fmt.Sprintf(" PARTITION BY RANGE (%s)", blockColumn(method))
+	}
+
+	// Record the abi names the table and columns were generated from
+	if r.Driver.Supports(storage.Comments) {
+		pgStr = pgStr + "; " + tableComment(table.String(), method.Name, append(append([]types.Field{}, method.Args...), method.Outputs...))
+	}
 
 	_, err := r.DB.Exec(pgStr)
 
 	return err
 }
 
-// Checks if a table already exists for the given contract and event
-func (r *methodRepository) checkForTable(contractAddr string, methodName string) (bool, error) {
-	pgStr := fmt.Sprintf("SELECT EXISTS (SELECT 1 FROM information_schema.tables WHERE table_schema = '%s_%s' AND table_name = '%s_method')", r.mode.String(), strings.ToLower(contractAddr), strings.ToLower(methodName))
-	var exists bool
-	err := r.DB.Get(&exists, pgStr)
+// Returns the id of the node's header at the block, or null if it has not been synced
+func (r *methodRepository) headerID(blockNumber int64) (sql.NullInt64, error) {
+	var headerID sql.NullInt64
+	err := r.DB.Get(&headerID, `SELECT id FROM `+r.Driver.Table(naming.TableName{Schema: "public", Table: "headers"})+`
+			WHERE block_number = $1 AND eth_node_fingerprint = $2`, blockNumber, r.Node.ID)
+	if err == sql.ErrNoRows {
+		return headerID, nil
+	}
+
+	return headerID, err
+}
+
+// Creates a trigger which reopens the range of the previous value when a change is removed from a table storing only changes,
+// such as along with its header in a reorg, so that the previous value holds until the block is polled again rather than leaving a gap
+func (r *methodRepository) createReopenTrigger(trigger, table naming.TableName, method types.Method) error {
+	return r.Driver.CreateDeleteTrigger(r.DB.DB, trigger, table, []string{
+		fmt.Sprintf("UPDATE %s SET valid_to_block = OLD.valid_to_block WHERE valid_to_block = OLD.valid_from_block%s",
+			r.Driver.Table(table), and(argsMatch(method, r.Driver.Table(table), "OLD"))),
+	})
+}
+
+// Creates a view which holds the method's value at every synced header, for a table storing only changes to the value
+func (r *methodRepository) createValueAtBlockView(view, table naming.TableName, method types.Method) error {
+	pgStr := "SELECT headers.block_number AS block, headers.id AS header_id, changes.token_name"
+	for _, arg := range method.Args {
+		pgStr = pgStr + ", changes." + arg.ColumnName
+	}
+	for _, column := range outputColumns(method) {
+		pgStr = pgStr + ", changes." + column
+	}
+	pgStr = pgStr + fmt.Sprintf(` FROM %s AS headers
+			INNER JOIN %s AS changes ON (headers.block_number >= changes.valid_from_block
+				AND (changes.valid_to_block IS NULL OR headers.block_number < changes.valid_to_block))`,
+		r.Driver.Table(naming.TableName{Schema: "public", Table: "headers"}), r.Driver.Table(table))
+
+	return r.Driver.CreateView(r.DB.DB, view, pgStr)
+}
+
+// Results are persisted by the block they were polled at, or by the first block of their value for tables storing only changes
+func blockColumn(method types.Method) string {
+	if method.ChangesOnly {
+		return "valid_from_block"
+	}
+
+	return "block"
+}
 
-	return exists, err
+// Returns the numbered parameter placeholders `$first, ..., $(first+count-1)`
+func placeholders(first, count int) string {
+	params := make([]string, 0, count)
+	for i := first; i < first+count; i++ {
+		params = append(params, fmt.Sprintf("$%d", i))
+	}
+
+	return strings.Join(params, ", ")
+}
+
+// The columns holding a method's returned values
+func outputColumns(method types.Method) []string {
+	columns := make([]string, 0, len(method.Outputs))
+	for _, output := range method.Outputs {
+		columns = append(columns, output.ColumnName)
+	}
+
+	return columns
+}
+
+// A method's results are unique to their block and arguments
+func methodKey(method types.Method) []string {
+	key := make([]string, 0, 1+len(method.Args))
+	key = append(key, blockColumn(method))
+	for _, arg := range method.Args {
+		key = append(key, arg.ColumnName)
+	}
+
+	return key
 }
 
 // CreateContractSchema checks for contract schema and creates it if it does not already exist
@@ -210,19 +496,12 @@ func (r *methodRepository) CreateContractSchema(contractAddr string) (bool, erro
 
 // Creates a schema for the given contract
 func (r *methodRepository) newContractSchema(contractAddr string) error {
-	_, err := r.DB.Exec("CREATE SCHEMA IF NOT EXISTS " + r.mode.String() + "_" + strings.ToLower(contractAddr))
-
-	return err
+	return r.Driver.CreateSchema(r.DB.DB, r.namer.MethodSchema(contractAddr))
 }
 
 // Checks if a schema already exists for the given contract
 func (r *methodRepository) checkForSchema(contractAddr string) (bool, error) {
-	pgStr := fmt.Sprintf("SELECT EXISTS (SELECT schema_name FROM information_schema.schemata WHERE schema_name = '%s_%s')", r.mode.String(), strings.ToLower(contractAddr))
-
-	var exists bool
-	err := r.DB.Get(&exists, pgStr)
-
-	return exists, err
+	return r.Driver.SchemaExists(r.DB.DB, r.namer.MethodSchema(contractAddr))
 }
 
 // CheckSchemaCache is used to query the schema name cache
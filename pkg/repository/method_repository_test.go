@@ -23,17 +23,17 @@ import (
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
-	"github.com/vulcanize/eth-header-sync/pkg/postgres"
-
 	"github.com/vulcanize/eth-contract-watcher/pkg/constants"
 	"github.com/vulcanize/eth-contract-watcher/pkg/contract"
 	"github.com/vulcanize/eth-contract-watcher/pkg/helpers/test_helpers"
+	"github.com/vulcanize/eth-contract-watcher/pkg/naming"
 	"github.com/vulcanize/eth-contract-watcher/pkg/repository"
+	"github.com/vulcanize/eth-contract-watcher/pkg/storage"
 	"github.com/vulcanize/eth-contract-watcher/pkg/types"
 )
 
 var _ = Describe("Repository", func() {
-	var db *postgres.DB
+	var db *storage.DB
 	var dataStore repository.MethodRepository
 	var con *contract.Contract
 	var err error
@@ -54,7 +54,7 @@ var _ = Describe("Repository", func() {
 		mockResult.Inputs[0] = "0xfE9e8709d3215310075d67E3ed32A380CCf451C8"
 		mockResult.Output = "66386309548896882859581786"
 		db, _ = test_helpers.SetupDBandClient()
-		dataStore = repository.NewMethodRepository(db, types.FullSync)
+		dataStore = repository.NewMethodRepository(db, types.FullSync, naming.DefaultNamer(types.FullSync))
 	})
 
 	AfterEach(func() {
@@ -63,7 +63,7 @@ var _ = Describe("Repository", func() {
 
 	Describe("Full Sync Mode", func() {
 		BeforeEach(func() {
-			dataStore = repository.NewMethodRepository(db, types.FullSync)
+			dataStore = repository.NewMethodRepository(db, types.FullSync, naming.DefaultNamer(types.FullSync))
 		})
 
 		Describe("CreateContractSchema", func() {
@@ -152,7 +152,7 @@ var _ = Describe("Repository", func() {
 
 	Describe("Header Sync Mode", func() {
 		BeforeEach(func() {
-			dataStore = repository.NewMethodRepository(db, types.HeaderSync)
+			dataStore = repository.NewMethodRepository(db, types.HeaderSync, naming.DefaultNamer(types.HeaderSync))
 		})
 
 		Describe("CreateContractSchema", func() {
@@ -236,6 +236,89 @@ var _ = Describe("Repository", func() {
 				err = dataStore.PersistResults([]types.Result{}, method, con.Address, con.Name)
 				Expect(err).To(HaveOccurred())
 			})
+
+			It("Replaces the persisted result when a block is re-polled", func() {
+				err = dataStore.PersistResults([]types.Result{mockResult}, method, con.Address, con.Name)
+				Expect(err).ToNot(HaveOccurred())
+				mockResult.Output = "1"
+				err = dataStore.PersistResults([]types.Result{mockResult}, method, con.Address, con.Name)
+				Expect(err).ToNot(HaveOccurred())
+
+				var balances []string
+				err = db.Select(&balances, fmt.Sprintf("SELECT returned FROM header_%s.balanceof_method", constants.TusdContractAddress))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(balances).To(Equal([]string{"1"}))
+			})
+
+			It("Creates block range partitions ahead of the persisted block for partitioned tables", func() {
+				method.PartitionSize = 100000
+				mockResult.Method = method
+				err = dataStore.PersistResults([]types.Result{mockResult}, method, con.Address, con.Name)
+				Expect(err).ToNot(HaveOccurred())
+
+				var partitions []string
+				err = db.Select(&partitions, `SELECT c.relname FROM pg_inherits i
+					INNER JOIN pg_class c ON (i.inhrelid = c.oid)
+					INNER JOIN pg_class p ON (i.inhparent = p.oid)
+					INNER JOIN pg_namespace n ON (p.relnamespace = n.oid)
+					WHERE n.nspname = $1 AND p.relname = 'balanceof_method' ORDER BY c.relname`,
+					fmt.Sprintf("header_%s", strings.ToLower(constants.TusdContractAddress)))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(partitions).To(Equal([]string{"balanceof_method_p6700000", "balanceof_method_p6800000"}))
+
+				var count int
+				err = db.Get(&count, fmt.Sprintf("SELECT COUNT(*) FROM header_%s.balanceof_method_p6700000", constants.TusdContractAddress))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(count).To(Equal(1))
+			})
+
+			It("Creates the partitions of blocks persisted behind those already persisted", func() {
+				method.PartitionSize = 100000
+				mockResult.Method = method
+				ahead := mockResult
+				ahead.Block = mockResult.Block + 2*method.PartitionSize
+				err = dataStore.PersistResults([]types.Result{ahead}, method, con.Address, con.Name)
+				Expect(err).ToNot(HaveOccurred())
+				err = dataStore.PersistResults([]types.Result{mockResult}, method, con.Address, con.Name)
+				Expect(err).ToNot(HaveOccurred())
+
+				var partitions []string
+				err = db.Select(&partitions, `SELECT c.relname FROM pg_inherits i
+					INNER JOIN pg_class c ON (i.inhrelid = c.oid)
+					INNER JOIN pg_class p ON (i.inhparent = p.oid)
+					INNER JOIN pg_namespace n ON (p.relnamespace = n.oid)
+					WHERE n.nspname = $1 AND p.relname = 'balanceof_method' ORDER BY c.relname`,
+					fmt.Sprintf("header_%s", strings.ToLower(constants.TusdContractAddress)))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(partitions).To(Equal([]string{"balanceof_method_p6700000", "balanceof_method_p6800000", "balanceof_method_p6900000", "balanceof_method_p7000000"}))
+
+				var count int
+				err = db.Get(&count, fmt.Sprintf("SELECT COUNT(*) FROM header_%s.balanceof_method", constants.TusdContractAddress))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(count).To(Equal(2))
+			})
+
+			It("Moves the legacy table to a template which only changes its schema", func() {
+				err = dataStore.PersistResults([]types.Result{mockResult}, method, con.Address, con.Name)
+				Expect(err).ToNot(HaveOccurred())
+
+				namer := naming.NewNamer(types.HeaderSync, "", "{alias}.{method}_method", map[string]string{con.Address: "trueusd"})
+				dataStore = repository.NewMethodRepository(db, types.HeaderSync, namer)
+				_, err = dataStore.CreateContractSchema(con.Address)
+				Expect(err).ToNot(HaveOccurred())
+				created, err := dataStore.CreateMethodTable(con.Address, method)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(created).To(Equal(false))
+
+				var count int
+				err = db.Get(&count, "SELECT COUNT(*) FROM trueusd.balanceof_method")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(count).To(Equal(1))
+				// The legacy name is kept working by a view
+				err = db.Get(&count, fmt.Sprintf("SELECT COUNT(*) FROM header_%s.balanceof_method", constants.TusdContractAddress))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(count).To(Equal(1))
+			})
 		})
 	})
 })
@@ -0,0 +1,136 @@
+// VulcanizeDB
+// Copyright © 2019 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package repository
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/hashicorp/golang-lru"
+
+	"github.com/vulcanize/eth-contract-watcher/pkg/naming"
+	"github.com/vulcanize/eth-contract-watcher/pkg/storage"
+	"github.com/vulcanize/eth-contract-watcher/pkg/types"
+)
+
+// Number of partitions to keep created beyond the one holding the most recent block
+const partitionsAhead = 1
+
+// Creates the block range partitions of partitioned tables as blocks are persisted to them
+type partitioner struct {
+	db         *storage.DB
+	mutex      sync.Mutex
+	partitions *lru.Cache // Cache the partitionSet of each table
+}
+
+// The first blocks of the partitions known to have been created for a table, nil if the table is not partitioned
+// Blocks are not persisted in order, as backfills and gap fills write behind the head, so every range is tracked
+type partitionSet map[int64]bool
+
+func newPartitioner(db *storage.DB, size int) *partitioner {
+	pcs, _ := lru.New(size)
+	return &partitioner{
+		db:         db,
+		partitions: pcs,
+	}
+}
+
+// Returns the partition size to use, which is 0 if the database does not support partitions
+func (p *partitioner) size(partitionSize int64) int64 {
+	if !p.db.Driver.Supports(storage.Partitions) {
+		return 0
+	}
+
+	return partitionSize
+}
+
+// Records whether the table is partitioned, checking the database if it is not known
+func (p *partitioner) track(table naming.TableName, partitionSize int64, created bool) (bool, error) {
+	set, err := p.partitionSet(table, partitionSize, created)
+	return set != nil, err
+}
+
+func (p *partitioner) partitionSet(table naming.TableName, partitionSize int64, created bool) (partitionSet, error) {
+	if p.size(partitionSize) <= 0 {
+		return nil, nil
+	}
+	if set, ok := p.partitions.Get(table.String()); ok {
+		return set.(partitionSet), nil
+	}
+	partitioned := created
+	if !created {
+		err := p.db.Get(&partitioned, `SELECT EXISTS (SELECT 1 FROM pg_partitioned_table pt
+				INNER JOIN pg_class c ON (pt.partrelid = c.oid)
+				INNER JOIN pg_namespace n ON (c.relnamespace = n.oid)
+				WHERE n.nspname = $1 AND c.relname = $2)`, table.Schema, table.Table)
+		if err != nil {
+			return nil, err
+		}
+	}
+	var set partitionSet
+	if partitioned {
+		set = make(partitionSet)
+	}
+	p.partitions.Add(table.String(), set)
+
+	return set, nil
+}
+
+// Ensures partitions exist for the range holding the given block and the ranges ahead of it
+// Does nothing if the table is not partitioned
+func (p *partitioner) ensure(table naming.TableName, partitionSize, blockNumber int64) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	set, err := p.partitionSet(table, partitionSize, false)
+	if err != nil || set == nil {
+		return err
+	}
+	start := blockNumber - blockNumber%partitionSize
+	end := start + (partitionsAhead+1)*partitionSize
+	for from := start; from < end; from += partitionSize {
+		if set[from] {
+			continue
+		}
+		pgStr := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s.%s PARTITION OF %s FOR VALUES FROM (%d) TO (%d)",
+			table.Schema, partitionName(table, from), table.String(), from, from+partitionSize)
+		_, err = p.db.Exec(pgStr)
+		if err != nil {
+			return fmt.Errorf("error creating partition of %s from block %d: %s", table.String(), from, err.Error())
+		}
+		set[from] = true
+	}
+
+	return nil
+}
+
+// Partitions are named after their table and the first block in their range
+func partitionName(table naming.TableName, from int64) string {
+	return types.TruncateIdentifier(fmt.Sprintf("%s_p%d", table.Table, from), table.String())
+}
+
+// Returns the partitions of the given table
+func listPartitions(db *storage.DB, table naming.TableName) ([]naming.TableName, error) {
+	partitions := make([]naming.TableName, 0)
+	err := db.Select(&partitions, `SELECT cn.nspname AS schema, c.relname AS table FROM pg_inherits i
+			INNER JOIN pg_class c ON (i.inhrelid = c.oid)
+			INNER JOIN pg_namespace cn ON (c.relnamespace = cn.oid)
+			INNER JOIN pg_class p ON (i.inhparent = p.oid)
+			INNER JOIN pg_namespace pn ON (p.relnamespace = pn.oid)
+			WHERE pn.nspname = $1 AND p.relname = $2`, table.Schema, table.Table)
+
+	return partitions, err
+}
@@ -0,0 +1,76 @@
+// VulcanizeDB
+// Copyright © 2019 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/vulcanize/eth-contract-watcher/pkg/naming"
+	"github.com/vulcanize/eth-contract-watcher/pkg/types"
+)
+
+// Each method table is accompanied by a `<method>_reverts` table recording the calls which reverted,
+// with their decoded reason and raw revert data, in place of the results they would have returned
+func (r *methodRepository) createRevertTable(reverts naming.TableName, method types.Method) error {
+	columnType := r.Driver.ColumnType
+	pgStr := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (id %s, token_name %s NOT NULL, block INTEGER NOT NULL,",
+		r.Driver.Table(reverts), columnType("SERIAL"), columnType("CHARACTER VARYING(66)"))
+	if r.mode == types.HeaderSync {
+		pgStr = pgStr + " header_id INTEGER REFERENCES headers (id) ON DELETE CASCADE,"
+	}
+	for _, arg := range method.Args {
+		pgStr = pgStr + fmt.Sprintf(" %s %s NOT NULL,", arg.ColumnName, columnType(arg.PgType))
+	}
+	pgStr = pgStr + fmt.Sprintf(" reason %s, data %s)", columnType("TEXT"), columnType("TEXT"))
+	_, err := r.DB.Exec(pgStr)
+	if err != nil {
+		return err
+	}
+
+	return createUniqueIndex(r.DB, reverts, append([]string{"block"}, argColumns(method)...))
+}
+
+// Records a call which reverted, replacing any revert already recorded for its block and arguments
+func (r *methodRepository) persistRevert(tx *sqlx.Tx, reverts naming.TableName, methodInfo types.Method, result types.Result, contractName string, headerID sql.NullInt64) error {
+	columns := append([]string{"token_name", "block"}, argColumns(methodInfo)...)
+	data := append([]interface{}{contractName, result.Block}, result.Inputs...)
+	columns = append(columns, "reason", "data")
+	data = append(data, result.RevertReason, sql.NullString{String: result.RevertData, Valid: result.RevertData != ""})
+	updates := "token_name = excluded.token_name, reason = excluded.reason, data = excluded.data"
+	if r.mode == types.HeaderSync {
+		columns = append(columns, "header_id")
+		data = append(data, headerID)
+		updates = updates + ", header_id = excluded.header_id"
+	}
+	_, err := tx.Exec(fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s) ON CONFLICT (%s) DO UPDATE SET %s",
+		r.Driver.Table(reverts), strings.Join(columns, ", "), placeholders(1, len(data)),
+		strings.Join(append([]string{"block"}, argColumns(methodInfo)...), ", "), updates), data...)
+
+	return err
+}
+
+// Removes any revert recorded for the block and arguments of a result, such as when a block is re-polled after a reorg
+func (r *methodRepository) clearRevert(tx *sqlx.Tx, reverts naming.TableName, methodInfo types.Method, result types.Result) error {
+	data := append([]interface{}{result.Block}, result.Inputs...)
+	_, err := tx.Exec(fmt.Sprintf("DELETE FROM %s%s", r.Driver.Table(reverts), where(append([]string{"block = $1"}, matchArgs(methodInfo, 2)...))), data...)
+
+	return err
+}
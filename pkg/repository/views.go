@@ -0,0 +1,72 @@
+// VulcanizeDB
+// Copyright © 2019 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package repository
+
+import (
+	"github.com/sirupsen/logrus"
+
+	"github.com/vulcanize/eth-contract-watcher/pkg/naming"
+	"github.com/vulcanize/eth-contract-watcher/pkg/storage"
+)
+
+// Moves a table created under the legacy name to its configured name, if such a table exists
+// This carries existing data over when an alias or naming template is introduced for a watched contract
+// Returns true if a table was moved
+func moveLegacyTable(db *storage.DB, legacy, table naming.TableName) (bool, error) {
+	exists, err := db.Driver.TableExists(db.DB, legacy)
+	if err != nil || !exists {
+		return false, err
+	}
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return false, err
+	}
+	stmts := []string{"ALTER TABLE " + db.Driver.Table(legacy) + " RENAME TO " + db.Driver.Table(table)}
+	if db.Driver.Supports(storage.Schemas) {
+		stmts = []string{"CREATE SCHEMA IF NOT EXISTS " + table.Schema}
+		// Templates which only change the schema keep the legacy table name
+		if legacy.Table != table.Table {
+			stmts = append(stmts, "ALTER TABLE "+legacy.String()+" RENAME TO "+table.Table)
+		}
+		if legacy.Schema != table.Schema {
+			stmts = append(stmts, "ALTER TABLE "+legacy.Schema+"."+table.Table+" SET SCHEMA "+table.Schema)
+		}
+	}
+	for _, stmt := range stmts {
+		if _, err := tx.Exec(stmt); err != nil {
+			rollbackErr := tx.Rollback()
+			if rollbackErr != nil {
+				logrus.Warnf("error rolling back transaction: %s", rollbackErr.Error())
+			}
+			return false, err
+		}
+	}
+
+	return true, tx.Commit()
+}
+
+// Creates a view under the legacy name which selects from the renamed table,
+// so that consumers using the default `<mode>_<address>.<name>_<type>` names keep working
+func createCompatibilityView(db *storage.DB, legacy, table naming.TableName) error {
+	err := db.Driver.CreateSchema(db.DB, legacy.Schema)
+	if err != nil {
+		return err
+	}
+
+	return db.Driver.CreateView(db.DB, legacy, "SELECT * FROM "+db.Driver.Table(table))
+}
@@ -18,14 +18,13 @@ package retriever
 
 import (
 	"fmt"
-	"strings"
 
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/common"
 
-	"github.com/vulcanize/eth-header-sync/pkg/postgres"
-
 	"github.com/vulcanize/eth-contract-watcher/pkg/contract"
+	"github.com/vulcanize/eth-contract-watcher/pkg/naming"
+	"github.com/vulcanize/eth-contract-watcher/pkg/storage"
 	"github.com/vulcanize/eth-contract-watcher/pkg/types"
 )
 
@@ -35,15 +34,17 @@ type AddressRetriever interface {
 }
 
 type addressRetriever struct {
-	db   *postgres.DB
-	mode types.Mode
+	db    *storage.DB
+	mode  types.Mode
+	namer *naming.Namer
 }
 
 // NewAddressRetriever returns a new AddressRetriever
-func NewAddressRetriever(db *postgres.DB, mode types.Mode) AddressRetriever {
+func NewAddressRetriever(db *storage.DB, mode types.Mode, namer *naming.Namer) AddressRetriever {
 	return &addressRetriever{
-		db:   db,
-		mode: mode,
+		db:    db,
+		mode:  mode,
+		namer: namer,
 	}
 }
 
@@ -87,7 +88,7 @@ func (r *addressRetriever) retrieveTransferAddresses(con contract.Contract) ([]s
 
 		if field.Type.T == abi.AddressTy { // If they have address type, retrieve those addresses
 			addrs := make([]string, 0)
-			pgStr := fmt.Sprintf("SELECT %s_ FROM %s_%s.%s_event", strings.ToLower(field.Name), r.mode.String(), strings.ToLower(con.Address), strings.ToLower(event.Name))
+			pgStr := fmt.Sprintf("SELECT %s FROM %s", field.ColumnName, r.db.Driver.Table(r.namer.EventTable(con.Address, event.Identifier)))
 			err := r.db.Select(&addrs, pgStr)
 			if err != nil {
 				return []string{}, err
@@ -108,7 +109,7 @@ func (r *addressRetriever) retrieveTokenMintees(con contract.Contract) ([]string
 
 		if field.Type.T == abi.AddressTy { // If they have address type, retrieve those addresses
 			addrs := make([]string, 0)
-			pgStr := fmt.Sprintf("SELECT %s_ FROM %s_%s.%s_event", strings.ToLower(field.Name), r.mode.String(), strings.ToLower(con.Address), strings.ToLower(event.Name))
+			pgStr := fmt.Sprintf("SELECT %s FROM %s", field.ColumnName, r.db.Driver.Table(r.namer.EventTable(con.Address, event.Identifier)))
 			err := r.db.Select(&addrs, pgStr)
 			if err != nil {
 				return []string{}, err
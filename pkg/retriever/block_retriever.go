@@ -17,7 +17,7 @@
 package retriever
 
 import (
-	"github.com/vulcanize/eth-header-sync/pkg/postgres"
+	"github.com/vulcanize/eth-contract-watcher/pkg/storage"
 )
 
 // BlockRetriever is used to retrieve the first block for a given contract and the most recent block
@@ -28,11 +28,11 @@ type BlockRetriever interface {
 }
 
 type blockRetriever struct {
-	db *postgres.DB
+	db *storage.DB
 }
 
 // NewBlockRetriever returns a new BlockRetriever
-func NewBlockRetriever(db *postgres.DB) BlockRetriever {
+func NewBlockRetriever(db *storage.DB) BlockRetriever {
 	return &blockRetriever{
 		db: db,
 	}
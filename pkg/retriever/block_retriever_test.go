@@ -20,22 +20,20 @@ import (
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
-	"github.com/vulcanize/eth-header-sync/pkg/postgres"
-	"github.com/vulcanize/eth-header-sync/pkg/repository"
-
 	"github.com/vulcanize/eth-contract-watcher/pkg/helpers/test_helpers"
 	"github.com/vulcanize/eth-contract-watcher/pkg/helpers/test_helpers/mocks"
 	"github.com/vulcanize/eth-contract-watcher/pkg/retriever"
+	"github.com/vulcanize/eth-contract-watcher/pkg/storage"
 )
 
 var _ = Describe("Block Retriever", func() {
-	var db *postgres.DB
+	var db *storage.DB
 	var r retriever.BlockRetriever
-	var headerRepository repository.HeaderRepository
+	var headerRepository storage.HeaderRepository
 
 	BeforeEach(func() {
 		db, _ = test_helpers.SetupDBandClient()
-		headerRepository = repository.NewHeaderRepository(db)
+		headerRepository = storage.NewHeaderRepository(db)
 		r = retriever.NewBlockRetriever(db)
 	})
 
@@ -0,0 +1,114 @@
+// VulcanizeDB
+// Copyright © 2019 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package storage
+
+import (
+	"database/sql"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/vulcanize/eth-header-sync/pkg/core"
+	"github.com/vulcanize/eth-header-sync/pkg/repository"
+
+	"github.com/vulcanize/eth-contract-watcher/pkg/naming"
+)
+
+var headers = naming.TableName{Schema: "public", Table: "headers"}
+
+// HeaderRepository satisfies the core.HeaderRepository interface for any storage driver
+// It is used to sync headers into databases which the eth-header-sync service cannot write to
+type HeaderRepository struct {
+	db *DB
+}
+
+// NewHeaderRepository returns a new HeaderRepository
+func NewHeaderRepository(db *DB) HeaderRepository {
+	return HeaderRepository{db: db}
+}
+
+// CreateOrUpdateHeader inserts a header model into the db
+// If there is already a header at the height, it is replaced if the hash is not the expected value
+func (r HeaderRepository) CreateOrUpdateHeader(header core.Header) (int64, error) {
+	var hash string
+	err := r.db.Get(&hash, `SELECT hash FROM `+r.db.Driver.Table(headers)+` WHERE block_number = $1 AND eth_node_fingerprint = $2`,
+		header.BlockNumber, r.db.Node.ID)
+	if err == sql.ErrNoRows {
+		return r.InsertHeader(header)
+	}
+	if err != nil {
+		log.Error("CreateOrUpdateHeader: error getting header hash: ", err)
+		return 0, err
+	}
+	if hash == header.Hash {
+		return 0, repository.ErrValidHeaderExists
+	}
+	_, err = r.db.Exec(`DELETE FROM `+r.db.Driver.Table(headers)+` WHERE block_number = $1 AND eth_node_fingerprint = $2`,
+		header.BlockNumber, r.db.Node.ID)
+	if err != nil {
+		log.Error("CreateOrUpdateHeader: error deleting headers: ", err)
+		return 0, err
+	}
+
+	return r.InsertHeader(header)
+}
+
+// GetHeader returns the header at the given height
+func (r HeaderRepository) GetHeader(blockNumber int64) (core.Header, error) {
+	var header core.Header
+	err := r.db.Get(&header, `SELECT id, block_number, hash, raw, block_timestamp FROM `+r.db.Driver.Table(headers)+`
+			WHERE block_number = $1 AND eth_node_fingerprint = $2`,
+		blockNumber, r.db.Node.ID)
+
+	return header, err
+}
+
+// InsertHeader inserts the provided header and returns its row id
+func (r HeaderRepository) InsertHeader(header core.Header) (int64, error) {
+	var headerID int64
+	err := r.db.QueryRowx(`INSERT INTO `+r.db.Driver.Table(headers)+`
+			(block_number, hash, block_timestamp, raw, node_id, eth_node_fingerprint)
+			VALUES ($1, $2, $3, $4, $5, $6) ON CONFLICT DO NOTHING RETURNING id`,
+		header.BlockNumber, header.Hash, header.Timestamp, header.Raw, r.db.NodeID, r.db.Node.ID).Scan(&headerID)
+	if err == sql.ErrNoRows {
+		return 0, repository.ErrValidHeaderExists
+	}
+
+	return headerID, err
+}
+
+// MissingBlockNumbers returns the block numbers in the range which have no header for the node
+func (r HeaderRepository) MissingBlockNumbers(startingBlockNumber, endingBlockNumber int64, nodeID string) ([]int64, error) {
+	synced := make([]int64, 0)
+	err := r.db.Select(&synced, `SELECT DISTINCT block_number FROM `+r.db.Driver.Table(headers)+`
+			WHERE block_number BETWEEN $1 AND $2 AND eth_node_fingerprint = $3`,
+		startingBlockNumber, endingBlockNumber, nodeID)
+	if err != nil {
+		return []int64{}, err
+	}
+	have := make(map[int64]bool, len(synced))
+	for _, number := range synced {
+		have[number] = true
+	}
+	numbers := make([]int64, 0)
+	for number := startingBlockNumber; number <= endingBlockNumber; number++ {
+		if !have[number] {
+			numbers = append(numbers, number)
+		}
+	}
+
+	return numbers, nil
+}
@@ -0,0 +1,121 @@
+// VulcanizeDB
+// Copyright © 2019 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package storage
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+
+	"github.com/vulcanize/eth-header-sync/pkg/postgres"
+
+	"github.com/vulcanize/eth-contract-watcher/pkg/naming"
+)
+
+// Postgres is the default storage driver
+var Postgres Driver = postgresDriver{}
+
+type postgresDriver struct{}
+
+// NewPostgresDB returns a DB using the Postgres driver for the given connection
+func NewPostgresDB(db *postgres.DB) *DB {
+	return &DB{
+		DB:     db.DB,
+		Driver: Postgres,
+		Node:   db.Node,
+		NodeID: db.NodeID,
+	}
+}
+
+func (postgresDriver) Name() string {
+	return "postgres"
+}
+
+func (postgresDriver) Supports(feature Feature) bool {
+	return true
+}
+
+func (postgresDriver) Table(table naming.TableName) string {
+	return table.String()
+}
+
+func (postgresDriver) ColumnType(pgType string) string {
+	return pgType
+}
+
+func (postgresDriver) CreateSchema(db *sqlx.DB, schema string) error {
+	_, err := db.Exec("CREATE SCHEMA IF NOT EXISTS " + schema)
+
+	return err
+}
+
+func (postgresDriver) SchemaExists(db *sqlx.DB, schema string) (bool, error) {
+	var exists bool
+	err := db.Get(&exists, `SELECT EXISTS (SELECT schema_name FROM information_schema.schemata WHERE schema_name = $1)`, schema)
+
+	return exists, err
+}
+
+func (postgresDriver) TableExists(db *sqlx.DB, table naming.TableName) (bool, error) {
+	var exists bool
+	err := db.Get(&exists, `SELECT EXISTS (SELECT 1 FROM information_schema.tables
+			WHERE table_schema = $1 AND table_name = $2 AND table_type = 'BASE TABLE')`, table.Schema, table.Table)
+
+	return exists, err
+}
+
+func (postgresDriver) AddColumns(db *sqlx.DB, table naming.TableName, columns []string, definition string) error {
+	if len(columns) == 0 {
+		return nil
+	}
+	pgStr := "ALTER TABLE " + table.String()
+	for _, column := range columns {
+		pgStr += " ADD COLUMN IF NOT EXISTS " + column + " " + definition + ","
+	}
+	_, err := db.Exec(pgStr[:len(pgStr)-1])
+
+	return err
+}
+
+func (postgresDriver) CreateView(db *sqlx.DB, view naming.TableName, query string) error {
+	_, err := db.Exec("CREATE OR REPLACE VIEW " + view.String() + " AS " + query)
+
+	return err
+}
+
+// Unique violations are reported with SQLSTATE 23505
+func (postgresDriver) IsUniqueViolation(err error) bool {
+	pqErr, ok := err.(*pq.Error)
+
+	return ok && pqErr.Code == "23505"
+}
+
+func (postgresDriver) CreateDeleteTrigger(db *sqlx.DB, trigger, table naming.TableName, statements []string) error {
+	_, err := db.Exec(fmt.Sprintf(`CREATE OR REPLACE FUNCTION %s() RETURNS TRIGGER AS $$
+			BEGIN
+				%s;
+				RETURN OLD;
+			END
+			$$ LANGUAGE plpgsql;
+			DROP TRIGGER IF EXISTS %s ON %s;
+			CREATE TRIGGER %s AFTER DELETE ON %s FOR EACH ROW EXECUTE PROCEDURE %s()`,
+		trigger.String(), strings.Join(statements, ";\n\t\t\t\t"), trigger.Table, table.String(), trigger.Table, table.String(), trigger.String()))
+
+	return err
+}
@@ -0,0 +1,267 @@
+// VulcanizeDB
+// Copyright © 2019 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package storage
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/mattn/go-sqlite3"
+
+	"github.com/vulcanize/eth-header-sync/pkg/core"
+
+	"github.com/vulcanize/eth-contract-watcher/pkg/naming"
+)
+
+// SQLite is an embedded storage driver, for deployments which do not warrant a Postgres server
+// SQLite has no schemas, so tables are named `"<schema>.<table>"`; tables in the public schema keep their plain names
+// SQLite numbers `$n` parameters in the order they first appear, so statements must introduce them in ascending order
+var SQLite Driver = sqliteDriver{}
+
+type sqliteDriver struct{}
+
+// The tables created by the Postgres migrations, for SQLite databases
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS nodes (
+  id            INTEGER PRIMARY KEY AUTOINCREMENT,
+  client_name   TEXT,
+  genesis_block TEXT,
+  network_id    TEXT,
+  node_id       TEXT,
+  chain_id      INTEGER,
+  CONSTRAINT node_uc UNIQUE (genesis_block, network_id, node_id, chain_id)
+);
+
+CREATE TABLE IF NOT EXISTS headers (
+  id                   INTEGER PRIMARY KEY AUTOINCREMENT,
+  hash                 TEXT,
+  block_number         INTEGER,
+  raw                  TEXT,
+  block_timestamp      TEXT,
+  check_count          INTEGER NOT NULL DEFAULT 0,
+  node_id              INTEGER NOT NULL REFERENCES nodes (id) ON DELETE CASCADE,
+  eth_node_fingerprint TEXT,
+  UNIQUE (block_number, hash, eth_node_fingerprint)
+);
+CREATE INDEX IF NOT EXISTS headers_block_number ON headers (block_number);
+
+CREATE TABLE IF NOT EXISTS checked_headers (
+  id        INTEGER PRIMARY KEY AUTOINCREMENT,
+  header_id INTEGER UNIQUE NOT NULL REFERENCES headers (id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS "contract_watcher.contracts" (
+  id             INTEGER PRIMARY KEY AUTOINCREMENT,
+  address        TEXT NOT NULL UNIQUE,
+  name           TEXT,
+  alias          TEXT NOT NULL,
+  abi            TEXT NOT NULL,
+  starting_block INTEGER NOT NULL,
+  event_schema   TEXT NOT NULL,
+  method_schema  TEXT NOT NULL,
+  config         TEXT,
+  updated_at     TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS "contract_watcher.events" (
+  id             INTEGER PRIMARY KEY AUTOINCREMENT,
+  contract_id    INTEGER NOT NULL REFERENCES "contract_watcher.contracts" (id) ON DELETE CASCADE,
+  name           TEXT NOT NULL,
+  identifier     TEXT NOT NULL,
+  signature      TEXT NOT NULL,
+  signature_hash TEXT NOT NULL,
+  anonymous      BOOLEAN NOT NULL,
+  table_schema   TEXT NOT NULL,
+  table_name     TEXT NOT NULL,
+  check_column   TEXT NOT NULL,
+  UNIQUE (contract_id, name)
+);
+
+CREATE TABLE IF NOT EXISTS "contract_watcher.event_fields" (
+  id          INTEGER PRIMARY KEY AUTOINCREMENT,
+  event_id    INTEGER NOT NULL REFERENCES "contract_watcher.events" (id) ON DELETE CASCADE,
+  position    INTEGER NOT NULL,
+  name        TEXT NOT NULL,
+  abi_type    TEXT NOT NULL,
+  pg_type     TEXT NOT NULL,
+  column_name TEXT NOT NULL,
+  indexed     BOOLEAN NOT NULL,
+  UNIQUE (event_id, position)
+);
+
+CREATE TABLE IF NOT EXISTS "contract_watcher.methods" (
+  id             INTEGER PRIMARY KEY AUTOINCREMENT,
+  contract_id    INTEGER NOT NULL REFERENCES "contract_watcher.contracts" (id) ON DELETE CASCADE,
+  name           TEXT NOT NULL,
+  identifier     TEXT NOT NULL,
+  signature      TEXT NOT NULL,
+  selector       TEXT NOT NULL,
+  return_type    TEXT NOT NULL,
+  return_pg_type TEXT NOT NULL,
+  table_schema   TEXT NOT NULL,
+  table_name     TEXT NOT NULL,
+  check_column   TEXT NOT NULL,
+  UNIQUE (contract_id, name)
+);
+
+CREATE TABLE IF NOT EXISTS "contract_watcher.method_args" (
+  id          INTEGER PRIMARY KEY AUTOINCREMENT,
+  method_id   INTEGER NOT NULL REFERENCES "contract_watcher.methods" (id) ON DELETE CASCADE,
+  position    INTEGER NOT NULL,
+  name        TEXT NOT NULL,
+  abi_type    TEXT NOT NULL,
+  pg_type     TEXT NOT NULL,
+  column_name TEXT NOT NULL,
+  UNIQUE (method_id, position)
+);
+
+CREATE TABLE IF NOT EXISTS "contract_watcher.method_outputs" (
+  id          INTEGER PRIMARY KEY AUTOINCREMENT,
+  method_id   INTEGER NOT NULL REFERENCES "contract_watcher.methods" (id) ON DELETE CASCADE,
+  position    INTEGER NOT NULL,
+  name        TEXT NOT NULL,
+  abi_type    TEXT NOT NULL,
+  pg_type     TEXT NOT NULL,
+  column_name TEXT NOT NULL,
+  UNIQUE (method_id, position)
+);
+
+CREATE TABLE IF NOT EXISTS "contract_watcher.implementations" (
+  id           INTEGER PRIMARY KEY AUTOINCREMENT,
+  contract_id  INTEGER NOT NULL REFERENCES "contract_watcher.contracts" (id) ON DELETE CASCADE,
+  address      TEXT NOT NULL,
+  block_number INTEGER NOT NULL,
+  abi          TEXT NOT NULL,
+  UNIQUE (contract_id, block_number)
+);
+`
+
+// NewSQLiteDB opens the SQLite database at the given path, creating it and its tables if needed, and records the node info
+// Use a path of `file::memory:?cache=shared` for a database held in memory
+func NewSQLiteDB(path string, node core.Node) (*DB, error) {
+	separator := "?"
+	if strings.Contains(path, "?") {
+		separator = "&"
+	}
+	db, err := sqlx.Connect("sqlite3", path+separator+"_foreign_keys=1&_busy_timeout=5000&_journal_mode=WAL")
+	if err != nil {
+		return nil, fmt.Errorf("error opening sqlite database: %s", err.Error())
+	}
+	_, err = db.Exec(sqliteSchema)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("error creating sqlite tables: %s", err.Error())
+	}
+	sqlite := &DB{DB: db, Driver: SQLite}
+	err = sqlite.CreateNode(&node)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("error recording node: %s", err.Error())
+	}
+
+	return sqlite, nil
+}
+
+func (sqliteDriver) Name() string {
+	return "sqlite3"
+}
+
+func (sqliteDriver) Supports(feature Feature) bool {
+	return false
+}
+
+func (sqliteDriver) Table(table naming.TableName) string {
+	return `"` + sqliteTableName(table) + `"`
+}
+
+func (sqliteDriver) ColumnType(pgType string) string {
+	switch {
+	case pgType == "SERIAL":
+		return "INTEGER PRIMARY KEY AUTOINCREMENT"
+	case pgType == "INTEGER", pgType == "BIGINT":
+		return "INTEGER"
+	case pgType == "BOOLEAN":
+		return "BOOLEAN"
+	case pgType == "BYTEA":
+		return "BLOB"
+	// Numeric values are stored as text as they can exceed the precision of SQLite's numeric types
+	default:
+		return "TEXT"
+	}
+}
+
+func (sqliteDriver) CreateSchema(db *sqlx.DB, schema string) error {
+	return nil
+}
+
+func (sqliteDriver) SchemaExists(db *sqlx.DB, schema string) (bool, error) {
+	return true, nil
+}
+
+func (sqliteDriver) TableExists(db *sqlx.DB, table naming.TableName) (bool, error) {
+	var exists bool
+	err := db.Get(&exists, `SELECT EXISTS (SELECT 1 FROM sqlite_master WHERE type = 'table' AND name = $1)`, sqliteTableName(table))
+
+	return exists, err
+}
+
+func (d sqliteDriver) AddColumns(db *sqlx.DB, table naming.TableName, columns []string, definition string) error {
+	for _, column := range columns {
+		var exists bool
+		err := db.Get(&exists, `SELECT EXISTS (SELECT 1 FROM pragma_table_info($1) WHERE name = $2)`, sqliteTableName(table), column)
+		if err != nil {
+			return err
+		}
+		if exists {
+			continue
+		}
+		_, err = db.Exec("ALTER TABLE " + d.Table(table) + " ADD COLUMN " + column + " " + definition)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (d sqliteDriver) CreateView(db *sqlx.DB, view naming.TableName, query string) error {
+	_, err := db.Exec("CREATE VIEW IF NOT EXISTS " + d.Table(view) + " AS " + query)
+
+	return err
+}
+
+func (d sqliteDriver) CreateDeleteTrigger(db *sqlx.DB, trigger, table naming.TableName, statements []string) error {
+	_, err := db.Exec(fmt.Sprintf("CREATE TRIGGER IF NOT EXISTS %s AFTER DELETE ON %s FOR EACH ROW BEGIN %s; END",
+		d.Table(trigger), d.Table(table), strings.Join(statements, "; ")))
+
+	return err
+}
+
+func (sqliteDriver) IsUniqueViolation(err error) bool {
+	sqliteErr, ok := err.(sqlite3.Error)
+
+	return ok && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique
+}
+
+func sqliteTableName(table naming.TableName) string {
+	if table.Schema == "" || table.Schema == "public" {
+		return table.Table
+	}
+
+	return table.Schema + "." + table.Table
+}
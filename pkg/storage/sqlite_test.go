@@ -0,0 +1,618 @@
+// VulcanizeDB
+// Copyright © 2019 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package storage_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/vulcanize/eth-header-sync/pkg/core"
+	hr "github.com/vulcanize/eth-header-sync/pkg/repository"
+
+	a "github.com/vulcanize/eth-contract-watcher/pkg/abi"
+	"github.com/vulcanize/eth-contract-watcher/pkg/constants"
+	"github.com/vulcanize/eth-contract-watcher/pkg/contract"
+	"github.com/vulcanize/eth-contract-watcher/pkg/helpers/test_helpers/mocks"
+	"github.com/vulcanize/eth-contract-watcher/pkg/naming"
+	"github.com/vulcanize/eth-contract-watcher/pkg/repository"
+	"github.com/vulcanize/eth-contract-watcher/pkg/storage"
+	"github.com/vulcanize/eth-contract-watcher/pkg/types"
+)
+
+var _ = Describe("SQLite", func() {
+	var dir string
+	var db *storage.DB
+	var headerRepository storage.HeaderRepository
+	var node = core.Node{GenesisBlock: "GENESIS", NetworkID: "1", ID: "b6f90c0fdd8ec9607aed8ee45c69322e47b7063f0bfb7a29c8ecafab24d0a22d24dd2329b5ee6ed4125a03cb14e57fd584e67f9e53e6c631055cbbd82f080845", ClientName: "Geth/v1.7.2-stable-1db4ecdc/darwin-amd64/go1.9"}
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "contract-watcher")
+		Expect(err).ToNot(HaveOccurred())
+		db, err = storage.NewSQLiteDB(filepath.Join(dir, "watcher.db"), node)
+		Expect(err).ToNot(HaveOccurred())
+		headerRepository = storage.NewHeaderRepository(db)
+	})
+
+	AfterEach(func() {
+		Expect(db.Close()).To(Succeed())
+		Expect(os.RemoveAll(dir)).To(Succeed())
+	})
+
+	It("Records the node", func() {
+		Expect(db.NodeID).ToNot(BeZero())
+		Expect(db.Node).To(Equal(node))
+
+		reopened, err := storage.NewSQLiteDB(filepath.Join(dir, "watcher.db"), node)
+		Expect(err).ToNot(HaveOccurred())
+		defer reopened.Close()
+		Expect(reopened.NodeID).To(Equal(db.NodeID))
+	})
+
+	It("Tells unique violations apart from other errors", func() {
+		_, err := db.Exec(`CREATE TABLE dupes (id INTEGER PRIMARY KEY, value TEXT)`)
+		Expect(err).ToNot(HaveOccurred())
+		_, err = db.Exec(`INSERT INTO dupes (value) VALUES ('a'), ('a')`)
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = db.Exec(`CREATE UNIQUE INDEX dupes_key ON dupes (value)`)
+		Expect(err).To(HaveOccurred())
+		Expect(db.Driver.IsUniqueViolation(err)).To(BeTrue())
+		_, err = db.Exec(`CREATE UNIQUE INDEX dupes_missing_key ON dupes (missing)`)
+		Expect(err).To(HaveOccurred())
+		Expect(db.Driver.IsUniqueViolation(err)).To(BeFalse())
+	})
+
+	Describe("HeaderRepository", func() {
+		It("Inserts, replaces and retrieves headers", func() {
+			id, err := headerRepository.CreateOrUpdateHeader(mocks.MockHeader1)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(id).ToNot(BeZero())
+
+			_, err = headerRepository.CreateOrUpdateHeader(mocks.MockHeader1)
+			Expect(err).To(Equal(hr.ErrValidHeaderExists))
+
+			replacement := mocks.MockHeader1
+			replacement.Hash = mocks.MockHeader2.Hash
+			_, err = headerRepository.CreateOrUpdateHeader(replacement)
+			Expect(err).ToNot(HaveOccurred())
+
+			header, err := headerRepository.GetHeader(mocks.MockHeader1.BlockNumber)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(header.Hash).To(Equal(replacement.Hash))
+			Expect(header.Timestamp).To(Equal(replacement.Timestamp))
+		})
+
+		It("Returns the block numbers missing headers", func() {
+			_, err := headerRepository.CreateOrUpdateHeader(mocks.MockHeader2)
+			Expect(err).ToNot(HaveOccurred())
+
+			missing, err := headerRepository.MissingBlockNumbers(mocks.MockHeader1.BlockNumber, mocks.MockHeader3.BlockNumber, node.ID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(missing).To(Equal([]int64{mocks.MockHeader1.BlockNumber, mocks.MockHeader3.BlockNumber}))
+		})
+	})
+
+	Describe("Repositories", func() {
+		var namer *naming.Namer
+		var headerID int64
+
+		BeforeEach(func() {
+			var err error
+			namer = naming.DefaultNamer(types.HeaderSync)
+			headerID, err = headerRepository.CreateOrUpdateHeader(mocks.MockHeader1)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("Tracks checked headers", func() {
+			checked := repository.NewHeaderRepository(db)
+			err := checked.AddCheckColumns([]string{"transfer_0x8dd5fbce2f6a956c3022ba3663759011dd51e73e"})
+			Expect(err).ToNot(HaveOccurred())
+
+			missing, err := checked.MissingHeaders(mocks.MockHeader1.BlockNumber, -1, "transfer_0x8dd5fbce2f6a956c3022ba3663759011dd51e73e")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(len(missing)).To(Equal(1))
+
+			// Block times are needed to poll methods on time based schedules
+			missing, err = checked.MissingHeadersForAll(mocks.MockHeader1.BlockNumber, -1, []string{"transfer_0x8dd5fbce2f6a956c3022ba3663759011dd51e73e"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(len(missing)).To(Equal(1))
+			Expect(missing[0].Timestamp).To(Equal(mocks.MockHeader1.Timestamp))
+
+			err = checked.MarkHeaderChecked(headerID, "transfer_0x8dd5fbce2f6a956c3022ba3663759011dd51e73e")
+			Expect(err).ToNot(HaveOccurred())
+
+			// Methods are polled apart from the events at the headers checked for the events
+			err = checked.AddCheckColumn("balanceof_0x8dd5fbce2f6a956c3022ba3663759011dd51e73e")
+			Expect(err).ToNot(HaveOccurred())
+			missing, err = checked.MissingMethodsCheckedEventsIntersection(mocks.MockHeader1.BlockNumber, -1,
+				[]string{"balanceof_0x8dd5fbce2f6a956c3022ba3663759011dd51e73e"}, []string{"transfer_0x8dd5fbce2f6a956c3022ba3663759011dd51e73e"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(len(missing)).To(Equal(1))
+			Expect(missing[0].Timestamp).To(Equal(mocks.MockHeader1.Timestamp))
+
+			err = checked.MarkHeaderChecked(headerID, "transfer_0x8dd5fbce2f6a956c3022ba3663759011dd51e73e")
+			Expect(err).ToNot(HaveOccurred())
+
+			missing, err = checked.MissingHeaders(mocks.MockHeader1.BlockNumber, -1, "transfer_0x8dd5fbce2f6a956c3022ba3663759011dd51e73e")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(len(missing)).To(Equal(0))
+		})
+
+		It("Creates event tables and persists logs to them", func() {
+			con := tusdContract([]string{"Transfer"}, []string{})
+			event := con.Events["Transfer"]
+			events := repository.NewEventRepository(db, types.HeaderSync, namer)
+
+			_, err := events.CreateContractSchema(con.Address)
+			Expect(err).ToNot(HaveOccurred())
+			created, err := events.CreateEventTable(con.Address, event)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(created).To(Equal(true))
+
+			values := make(map[string]string)
+			for _, field := range event.Fields {
+				values[field.Name] = "1"
+			}
+			log := types.Log{ID: headerID, Values: values, LogIndex: 1, TransactionIndex: 2, Raw: []byte("{}")}
+			err = events.PersistLogs([]types.Log{log, log}, event, con.Address, con.Name)
+			Expect(err).ToNot(HaveOccurred())
+
+			var count int
+			err = db.Get(&count, "SELECT COUNT(*) FROM "+db.Driver.Table(namer.EventTable(con.Address, event.Identifier)))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(count).To(Equal(1))
+		})
+
+		It("Creates method tables and persists results to them", func() {
+			con := tusdContract([]string{}, []string{"balanceOf"})
+			method := con.Methods[0]
+			methods := repository.NewMethodRepository(db, types.HeaderSync, namer)
+
+			created, err := methods.CreateMethodTable(con.Address, method)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(created).To(Equal(true))
+
+			result := types.Result{
+				Method: method,
+				Inputs: []interface{}{"0xfE9e8709d3215310075d67E3ed32A380CCf451C8"},
+				Output: "66386309548896882859581786",
+				Block:  6707323,
+			}
+			err = methods.PersistResults([]types.Result{result}, method, con.Address, con.Name)
+			Expect(err).ToNot(HaveOccurred())
+
+			var returned string
+			err = db.Get(&returned, "SELECT returned FROM "+db.Driver.Table(namer.MethodTable(con.Address, method.Identifier)))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(returned).To(Equal("66386309548896882859581786"))
+		})
+
+		It("Replaces re-polled method results and removes them with their header", func() {
+			con := tusdContract([]string{}, []string{"balanceOf"})
+			method := con.Methods[0]
+			methods := repository.NewMethodRepository(db, types.HeaderSync, namer)
+			table := db.Driver.Table(namer.MethodTable(con.Address, method.Identifier))
+
+			result := types.Result{
+				Method: method,
+				Inputs: []interface{}{"0xfE9e8709d3215310075d67E3ed32A380CCf451C8"},
+				Output: "1",
+				Block:  mocks.MockHeader1.BlockNumber,
+			}
+			err := methods.PersistResults([]types.Result{result}, method, con.Address, con.Name)
+			Expect(err).ToNot(HaveOccurred())
+			result.Output = "2"
+			err = methods.PersistResults([]types.Result{result}, method, con.Address, con.Name)
+			Expect(err).ToNot(HaveOccurred())
+
+			var rows []struct {
+				HeaderID int64  `db:"header_id"`
+				Returned string `db:"returned"`
+			}
+			err = db.Select(&rows, "SELECT header_id, returned FROM "+table)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(len(rows)).To(Equal(1))
+			Expect(rows[0].HeaderID).To(Equal(headerID))
+			Expect(rows[0].Returned).To(Equal("2"))
+
+			_, err = db.Exec("DELETE FROM headers WHERE id = $1", headerID)
+			Expect(err).ToNot(HaveOccurred())
+			var count int
+			err = db.Get(&count, "SELECT COUNT(*) FROM "+table)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(count).To(Equal(0))
+		})
+
+		It("Records reverted calls in place of their results", func() {
+			con := tusdContract([]string{}, []string{"balanceOf"})
+			method := con.Methods[0]
+			methods := repository.NewMethodRepository(db, types.HeaderSync, namer)
+			table := db.Driver.Table(namer.MethodTable(con.Address, method.Identifier))
+			reverts := db.Driver.Table(namer.MethodVariantTable(con.Address, method.Identifier, "reverts"))
+
+			holder := "0xfE9e8709d3215310075d67E3ed32A380CCf451C8"
+			reverted := types.Result{Method: method, Inputs: []interface{}{holder}, Block: mocks.MockHeader1.BlockNumber,
+				Reverted: true, RevertReason: "not allowed"}
+			err := methods.PersistResults([]types.Result{reverted}, method, con.Address, con.Name)
+			Expect(err).ToNot(HaveOccurred())
+
+			var count int
+			err = db.Get(&count, "SELECT COUNT(*) FROM "+table)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(count).To(Equal(0))
+			var rows []struct {
+				HeaderID int64  `db:"header_id"`
+				Reason   string `db:"reason"`
+			}
+			err = db.Select(&rows, "SELECT header_id, reason FROM "+reverts+" WHERE who_ = $1", holder)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(len(rows)).To(Equal(1))
+			Expect(rows[0].HeaderID).To(Equal(headerID))
+			Expect(rows[0].Reason).To(Equal("not allowed"))
+
+			// A successful re-poll of the block replaces the revert
+			result := types.Result{Method: method, Inputs: []interface{}{holder}, Output: "1", Block: mocks.MockHeader1.BlockNumber}
+			err = methods.PersistResults([]types.Result{result}, method, con.Address, con.Name)
+			Expect(err).ToNot(HaveOccurred())
+			err = db.Get(&count, "SELECT COUNT(*) FROM "+reverts)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(count).To(Equal(0))
+			err = db.Get(&count, "SELECT COUNT(*) FROM "+table)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(count).To(Equal(1))
+		})
+
+		It("Stores only changes to method values when configured to", func() {
+			_, err := headerRepository.CreateOrUpdateHeader(mocks.MockHeader2)
+			Expect(err).ToNot(HaveOccurred())
+			_, err = headerRepository.CreateOrUpdateHeader(mocks.MockHeader3)
+			Expect(err).ToNot(HaveOccurred())
+			con := tusdContract([]string{}, []string{"balanceOf"})
+			method := con.Methods[0]
+			method.ChangesOnly = true
+			methods := repository.NewMethodRepository(db, types.HeaderSync, namer)
+
+			holder := "0xfE9e8709d3215310075d67E3ed32A380CCf451C8"
+			for _, poll := range []struct {
+				header core.Header
+				value  string
+			}{{mocks.MockHeader2, "1"}, {mocks.MockHeader3, "2"}, {mocks.MockHeader1, "1"}, {mocks.MockHeader2, "1"}} {
+				result := types.Result{Method: method, Inputs: []interface{}{holder}, Output: poll.value, Block: poll.header.BlockNumber}
+				err = methods.PersistResults([]types.Result{result}, method, con.Address, con.Name)
+				Expect(err).ToNot(HaveOccurred())
+			}
+
+			var rows []struct {
+				ValidFrom int64  `db:"valid_from_block"`
+				ValidTo   *int64 `db:"valid_to_block"`
+				Returned  string `db:"returned"`
+			}
+			err = db.Select(&rows, "SELECT valid_from_block, valid_to_block, returned FROM "+
+				db.Driver.Table(namer.MethodTable(con.Address, method.Identifier))+" ORDER BY valid_from_block")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(len(rows)).To(Equal(2))
+			Expect(rows[0].ValidFrom).To(Equal(mocks.MockHeader1.BlockNumber))
+			Expect(*rows[0].ValidTo).To(Equal(mocks.MockHeader3.BlockNumber))
+			Expect(rows[1].ValidFrom).To(Equal(mocks.MockHeader3.BlockNumber))
+			Expect(rows[1].ValidTo).To(BeNil())
+
+			var values []string
+			err = db.Select(&values, "SELECT returned FROM "+
+				db.Driver.Table(namer.MethodVariantTable(con.Address, method.Identifier, "at_block"))+" WHERE who_ = $1 ORDER BY block", holder)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(values).To(Equal([]string{"1", "1", "2"}))
+		})
+
+		It("Reopens the previous value when a change is removed in a reorg", func() {
+			header2ID, err := headerRepository.CreateOrUpdateHeader(mocks.MockHeader2)
+			Expect(err).ToNot(HaveOccurred())
+			header3ID, err := headerRepository.CreateOrUpdateHeader(mocks.MockHeader3)
+			Expect(err).ToNot(HaveOccurred())
+			con := tusdContract([]string{}, []string{"balanceOf"})
+			method := con.Methods[0]
+			method.ChangesOnly = true
+			methods := repository.NewMethodRepository(db, types.HeaderSync, namer)
+			table := db.Driver.Table(namer.MethodTable(con.Address, method.Identifier))
+
+			holder := "0xfE9e8709d3215310075d67E3ed32A380CCf451C8"
+			persist := func(header core.Header, value string) {
+				result := types.Result{Method: method, Inputs: []interface{}{holder}, Output: value, Block: header.BlockNumber}
+				Expect(methods.PersistResults([]types.Result{result}, method, con.Address, con.Name)).To(Succeed())
+			}
+			persist(mocks.MockHeader1, "1")
+			persist(mocks.MockHeader2, "2")
+			persist(mocks.MockHeader3, "3")
+
+			var rows []struct {
+				ValidFrom int64  `db:"valid_from_block"`
+				ValidTo   *int64 `db:"valid_to_block"`
+				Returned  string `db:"returned"`
+			}
+			selectRows := "SELECT valid_from_block, valid_to_block, returned FROM " + table + " ORDER BY valid_from_block"
+			_, err = db.Exec("DELETE FROM headers WHERE id = $1", header2ID)
+			Expect(err).ToNot(HaveOccurred())
+			err = db.Select(&rows, selectRows)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(len(rows)).To(Equal(2))
+			Expect(*rows[0].ValidTo).To(Equal(mocks.MockHeader3.BlockNumber))
+
+			_, err = db.Exec("DELETE FROM headers WHERE id = $1", header3ID)
+			Expect(err).ToNot(HaveOccurred())
+			rows = nil
+			err = db.Select(&rows, selectRows)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(len(rows)).To(Equal(1))
+			Expect(rows[0].ValidTo).To(BeNil())
+
+			// Re-polling the same value as before the reorg extends the reopened range
+			_, err = headerRepository.CreateOrUpdateHeader(mocks.MockHeader2)
+			Expect(err).ToNot(HaveOccurred())
+			persist(mocks.MockHeader2, "1")
+			rows = nil
+			err = db.Select(&rows, selectRows)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(len(rows)).To(Equal(1))
+			Expect(rows[0].ValidFrom).To(Equal(mocks.MockHeader1.BlockNumber))
+			Expect(rows[0].ValidTo).To(BeNil())
+		})
+
+		It("Maintains the latest result for each set of arguments", func() {
+			header2ID, err := headerRepository.CreateOrUpdateHeader(mocks.MockHeader2)
+			Expect(err).ToNot(HaveOccurred())
+			con := tusdContract([]string{}, []string{"balanceOf"})
+			method := con.Methods[0]
+			methods := repository.NewMethodRepository(db, types.HeaderSync, namer)
+			_, err = methods.CreateMethodTable(con.Address, method)
+			Expect(err).ToNot(HaveOccurred())
+
+			holder := "0xfE9e8709d3215310075d67E3ed32A380CCf451C8"
+			for _, poll := range []struct {
+				header core.Header
+				value  string
+			}{{mocks.MockHeader1, "1"}, {mocks.MockHeader2, "2"}, {mocks.MockHeader1, "3"}} {
+				result := types.Result{Method: method, Inputs: []interface{}{holder}, Output: poll.value, Block: poll.header.BlockNumber}
+				err = methods.PersistResults([]types.Result{result}, method, con.Address, con.Name)
+				Expect(err).ToNot(HaveOccurred())
+			}
+
+			latest := db.Driver.Table(namer.MethodVariantTable(con.Address, method.Identifier, "latest"))
+			var rows []struct {
+				Block    int64  `db:"block"`
+				Returned string `db:"returned"`
+			}
+			err = db.Select(&rows, "SELECT block, returned FROM "+latest+" WHERE who_ = $1", holder)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(len(rows)).To(Equal(1))
+			Expect(rows[0].Block).To(Equal(mocks.MockHeader2.BlockNumber))
+			Expect(rows[0].Returned).To(Equal("2"))
+
+			_, err = db.Exec("DELETE FROM headers WHERE id = $1", header2ID)
+			Expect(err).ToNot(HaveOccurred())
+			rows = nil
+			err = db.Select(&rows, "SELECT block, returned FROM "+latest+" WHERE who_ = $1", holder)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(len(rows)).To(Equal(1))
+			Expect(rows[0].Block).To(Equal(mocks.MockHeader1.BlockNumber))
+			Expect(rows[0].Returned).To(Equal("3"))
+		})
+
+		It("Persists each of several return values to its own column", func() {
+			_, err := headerRepository.CreateOrUpdateHeader(mocks.MockHeader2)
+			Expect(err).ToNot(HaveOccurred())
+			parsed, err := abi.JSON(strings.NewReader(`[{"constant":true,"inputs":[],"name":"getReserves","outputs":[{"name":"_reserve0","type":"uint112"},{"name":"_reserve1","type":"uint112"},{"name":"_blockTimestampLast","type":"uint32"}],"type":"function"}]`))
+			Expect(err).ToNot(HaveOccurred())
+			method := types.NewMethod(parsed.Methods["getReserves"])
+			method.ChangesOnly = true
+			methods := repository.NewMethodRepository(db, types.HeaderSync, namer)
+			_, err = methods.CreateMethodTable(constants.TusdContractAddress, method)
+			Expect(err).ToNot(HaveOccurred())
+
+			for _, poll := range []struct {
+				header core.Header
+				values []interface{}
+			}{{mocks.MockHeader1, []interface{}{"10", "20", "1"}}, {mocks.MockHeader2, []interface{}{"10", "20", "1"}}, {mocks.MockHeader2, []interface{}{"10", "30", "2"}}} {
+				result := types.Result{Method: method, Returned: poll.values, Block: poll.header.BlockNumber}
+				err = methods.PersistResults([]types.Result{result}, method, constants.TusdContractAddress, "TrueUSD")
+				Expect(err).ToNot(HaveOccurred())
+			}
+
+			var rows []struct {
+				ValidFrom int64  `db:"valid_from_block"`
+				Reserve1  string `db:"_reserve1_"`
+				Timestamp string `db:"_blocktimestamplast_"`
+			}
+			err = db.Select(&rows, "SELECT valid_from_block, _reserve1_, _blocktimestamplast_ FROM "+
+				db.Driver.Table(namer.MethodTable(constants.TusdContractAddress, method.Identifier))+" ORDER BY valid_from_block")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(len(rows)).To(Equal(2))
+			Expect(rows[0].Reserve1).To(Equal("20"))
+			Expect(rows[1].ValidFrom).To(Equal(mocks.MockHeader2.BlockNumber))
+			Expect(rows[1].Reserve1).To(Equal("30"))
+			Expect(rows[1].Timestamp).To(Equal("2"))
+
+			var latest string
+			err = db.Get(&latest, "SELECT _reserve1_ FROM "+db.Driver.Table(namer.MethodVariantTable(constants.TusdContractAddress, method.Identifier, "latest")))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(latest).To(Equal("30"))
+		})
+
+		It("Persists collected method arguments and removes them with their header", func() {
+			args := repository.NewArgRepository(db, namer)
+			address := constants.TusdContractAddress
+			err := args.CreateArgTable(address)
+			Expect(err).ToNot(HaveOccurred())
+			secondID, err := headerRepository.CreateOrUpdateHeader(mocks.MockHeader2)
+			Expect(err).ToNot(HaveOccurred())
+
+			holder := "0xfE9e8709d3215310075d67E3ed32A380CCf451C8"
+			err = args.PersistArgs(address, headerID, mocks.MockHeader1.BlockNumber, map[contract.TouchedArg]bool{{Type: "address", Value: holder}: true, {Type: "uint256", Value: "1"}: true, {Type: "uint8", Value: "1"}: true})
+			Expect(err).ToNot(HaveOccurred())
+			err = args.PersistArgs(address, secondID, mocks.MockHeader2.BlockNumber, map[contract.TouchedArg]bool{{Type: "address", Value: holder}: true})
+			Expect(err).ToNot(HaveOccurred())
+
+			known, err := args.LoadArgs(address)
+			Expect(err).ToNot(HaveOccurred())
+			// A value emitted as two types at the same header is recorded as each
+			Expect(known).To(ConsistOf(repository.KnownArg{Type: "address", Value: holder}, repository.KnownArg{Type: "uint256", Value: "1"},
+				repository.KnownArg{Type: "uint8", Value: "1"}))
+			var holders []struct {
+				Value      string `db:"value"`
+				FirstBlock int64  `db:"first_block"`
+				LastBlock  int64  `db:"last_block"`
+			}
+			err = db.Select(&holders, "SELECT value, first_block, last_block FROM "+db.Driver.Table(namer.ContractTable(address, "known_args"))+" WHERE arg_type = 'address'")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(len(holders)).To(Equal(1))
+			Expect(holders[0].FirstBlock).To(Equal(mocks.MockHeader1.BlockNumber))
+			Expect(holders[0].LastBlock).To(Equal(mocks.MockHeader2.BlockNumber))
+
+			// Values are loaded by the range of blocks they were emitted over
+			emitted, err := args.LoadArgsBetween(address, mocks.MockHeader1.BlockNumber, mocks.MockHeader2.BlockNumber)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(emitted).To(Equal([]repository.KnownArg{{Type: "address", Value: holder, BlockNumber: mocks.MockHeader2.BlockNumber}}))
+
+			_, err = db.Exec("DELETE FROM headers WHERE id = $1", headerID)
+			Expect(err).ToNot(HaveOccurred())
+			known, err = args.LoadArgs(address)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(known).To(Equal([]repository.KnownArg{{Type: "address", Value: holder}}))
+		})
+
+		It("Queries sets of method arguments", func() {
+			args := repository.NewArgRepository(db, namer)
+			err := args.CreateArgTable(constants.TusdContractAddress)
+			Expect(err).ToNot(HaveOccurred())
+			err = args.PersistArgs(constants.TusdContractAddress, headerID, mocks.MockHeader1.BlockNumber, map[contract.TouchedArg]bool{{Type: "uint256", Value: "7"}: true})
+			Expect(err).ToNot(HaveOccurred())
+
+			sets, err := args.QueryArgs("SELECT value, block_number FROM " + db.Driver.Table(namer.ContractTable(constants.TusdContractAddress, "emitted_args")))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(sets).To(Equal([][]string{{"7", strconv.FormatInt(mocks.MockHeader1.BlockNumber, 10)}}))
+		})
+
+		It("Records contracts in the catalog", func() {
+			con := tusdContract([]string{"Transfer"}, []string{"balanceOf"})
+			catalog := repository.NewCatalogRepository(db, namer)
+			Expect(catalog.RecordContract(*con)).To(Succeed())
+
+			con.Methods = nil
+			Expect(catalog.RecordContract(*con)).To(Succeed())
+
+			var events, methods int
+			err := db.Get(&events, `SELECT COUNT(*) FROM "contract_watcher.events"`)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(events).To(Equal(1))
+			err = db.Get(&methods, `SELECT COUNT(*) FROM "contract_watcher.methods"`)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(methods).To(Equal(0))
+		})
+
+		It("Provides the abis of catalogued contracts", func() {
+			con := tusdContract([]string{"Transfer"}, nil)
+			Expect(repository.NewCatalogRepository(db, namer).RecordContract(*con)).To(Succeed())
+			provider := repository.NewCatalogAbiProvider(db)
+
+			_, err := provider.GetAbi("0x0000000000000000000000000000000000000001")
+			Expect(err).To(Equal(a.ErrAbiNotFound))
+			abiStr, err := provider.GetAbi(strings.ToLower(constants.TusdContractAddress))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(abiStr).To(Equal(con.Abi))
+		})
+
+		It("Records the implementations of proxies in the catalog", func() {
+			con := tusdContract([]string{"Transfer"}, nil)
+			catalog := repository.NewCatalogRepository(db, namer)
+			Expect(catalog.RecordContract(*con)).To(Succeed())
+			first := contract.Implementation{Address: "0x0000000000000000000000000000000000000001", Block: 6194634, Abi: con.Abi}
+			second := contract.Implementation{Address: "0x0000000000000000000000000000000000000002", Block: 6194650, Abi: con.Abi}
+
+			Expect(catalog.RecordImplementation(con.Address, second)).To(Succeed())
+			Expect(catalog.RecordImplementation(con.Address, first)).To(Succeed())
+			Expect(catalog.RecordImplementation(con.Address, first)).To(Succeed())
+
+			implementations, err := catalog.LoadImplementations(con.Address)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(implementations).To(HaveLen(2))
+			Expect(implementations[0].Address).To(Equal(first.Address))
+			Expect(implementations[0].Block).To(Equal(first.Block))
+			Expect(implementations[1].Address).To(Equal(second.Address))
+			Expect(implementations[1].ParsedAbi).To(Equal(con.ParsedAbi))
+		})
+
+		It("Provides the own abi of a catalogued proxy, so its implementations' methods stay gated after a restart", func() {
+			const (
+				proxyAbi = `[{"inputs":[],"name":"admin","outputs":[{"name":"","type":"address"}],"stateMutability":"view","type":"function"}]`
+				v1Abi    = `[{"inputs":[],"name":"totalSupply","outputs":[{"name":"","type":"uint256"}],"stateMutability":"view","type":"function"}]`
+				v2Abi    = `[{"inputs":[],"name":"paused","outputs":[{"name":"","type":"bool"}],"stateMutability":"view","type":"function"}]`
+			)
+			parse := func(abiStr string) abi.ABI {
+				parsed, err := a.ParseAbi(abiStr)
+				Expect(err).ToNot(HaveOccurred())
+				return parsed
+			}
+			merged, err := a.MergeAbis(proxyAbi, v1Abi, v2Abi)
+			Expect(err).ToNot(HaveOccurred())
+			v1 := contract.Implementation{Address: "0x0000000000000000000000000000000000000001", Block: 6194634, Abi: v1Abi, ParsedAbi: parse(v1Abi)}
+			v2 := contract.Implementation{Address: "0x0000000000000000000000000000000000000002", Block: 6194650, Abi: v2Abi, ParsedAbi: parse(v2Abi)}
+			con := tusdContract(nil, nil)
+			con.Abi, con.ParsedAbi = merged, parse(merged)
+			con.Proxy = contract.NewProxy(proxyAbi, parse(proxyAbi), v1, v2)
+			catalog := repository.NewCatalogRepository(db, namer)
+			Expect(catalog.RecordContract(*con)).To(Succeed())
+			Expect(catalog.RecordImplementation(con.Address, v1)).To(Succeed())
+			Expect(catalog.RecordImplementation(con.Address, v2)).To(Succeed())
+
+			// Restarting, the proxy is rebuilt from the abi provided by the catalog and the implementations recorded in it
+			abiStr, err := repository.NewCatalogAbiProvider(db).GetAbi(con.Address)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(abiStr).To(Equal(proxyAbi))
+			implementations, err := catalog.LoadImplementations(con.Address)
+			Expect(err).ToNot(HaveOccurred())
+			restarted := contract.NewProxy(abiStr, parse(abiStr), implementations...)
+
+			Expect(restarted.HasMethodAt("admin()", 6194634)).To(BeTrue())
+			Expect(restarted.HasMethodAt("paused()", 6194649)).To(BeFalse())
+			Expect(restarted.HasMethodAt("paused()", 6194650)).To(BeTrue())
+		})
+	})
+})
+
+func tusdContract(wantedEvents, wantedMethods []string) *contract.Contract {
+	p := mocks.NewParser(constants.TusdAbiString)
+	err := p.Parse(constants.TusdContractAddress)
+	Expect(err).ToNot(HaveOccurred())
+
+	return contract.Contract{
+		Name:          "TrueUSD",
+		Address:       constants.TusdContractAddress,
+		Abi:           p.Abi(),
+		ParsedAbi:     p.ParsedAbi(),
+		StartingBlock: 6194634,
+		Events:        p.GetEvents(wantedEvents),
+		Methods:       p.GetSelectMethods(wantedMethods),
+		MethodArgs:    map[string]bool{},
+		FilterArgs:    map[string]bool{},
+	}.Init()
+}
@@ -0,0 +1,85 @@
+// VulcanizeDB
+// Copyright © 2019 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package storage
+
+import (
+	"github.com/jmoiron/sqlx"
+
+	"github.com/vulcanize/eth-header-sync/pkg/core"
+
+	"github.com/vulcanize/eth-contract-watcher/pkg/naming"
+)
+
+// Feature is a database capability which is not available from every driver
+type Feature int
+
+const (
+	// Schemas are namespaces for tables; drivers without them fold the schema into the table name
+	Schemas Feature = iota
+	// Comments can be attached to tables and columns
+	Comments
+	// ConcurrentIndexes can be built without blocking writes to their table
+	ConcurrentIndexes
+	// Partitions split tables into block ranges
+	Partitions
+)
+
+// Driver generates the database specific statements issued by the repositories
+// Column types are given as their Postgres types and translated by the driver
+// Delete triggers run their statements for each deleted row, which is available to them as OLD
+// Unique violations are told apart from other errors, so that duplicate rows can be handled without hiding the rest
+type Driver interface {
+	Name() string
+	Supports(feature Feature) bool
+	Table(table naming.TableName) string
+	ColumnType(pgType string) string
+	CreateSchema(db *sqlx.DB, schema string) error
+	SchemaExists(db *sqlx.DB, schema string) (bool, error)
+	TableExists(db *sqlx.DB, table naming.TableName) (bool, error)
+	AddColumns(db *sqlx.DB, table naming.TableName, columns []string, definition string) error
+	CreateView(db *sqlx.DB, view naming.TableName, query string) error
+	CreateDeleteTrigger(db *sqlx.DB, trigger, table naming.TableName, statements []string) error
+	IsUniqueViolation(err error) bool
+}
+
+// DB is a wrapper around the sqlx.DB which associates the storage driver and node information with the connection pool
+type DB struct {
+	*sqlx.DB
+	Driver Driver
+	Node   core.Node
+	NodeID int64
+}
+
+// CreateNode inserts the node info into the database
+func (db *DB) CreateNode(node *core.Node) error {
+	var nodeID int64
+	err := db.QueryRow(
+		`INSERT INTO `+db.Driver.Table(naming.TableName{Schema: "public", Table: "nodes"})+`
+				(genesis_block, network_id, node_id, client_name, chain_id)
+				VALUES ($1, $2, $3, $4, $5)
+				ON CONFLICT (genesis_block, network_id, node_id, chain_id)
+				DO UPDATE SET client_name = $4
+				RETURNING id`,
+		node.GenesisBlock, node.NetworkID, node.ID, node.ClientName, node.ChainID).Scan(&nodeID)
+	if err != nil {
+		return err
+	}
+	db.Node = *node
+	db.NodeID = nodeID
+
+	return nil
+}
@@ -0,0 +1,185 @@
+// VulcanizeDB
+// Copyright © 2019 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package transformer
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/vulcanize/eth-header-sync/pkg/core"
+
+	"github.com/vulcanize/eth-contract-watcher/pkg/contract"
+	"github.com/vulcanize/eth-contract-watcher/pkg/types"
+)
+
+// methodStage polls a contract's methods apart from its events, using a copy of the contract whose argument values
+// are rebuilt from those recorded by the events, so that each block is polled with the values known at it
+type methodStage struct {
+	con      *contract.Contract
+	loadedTo int64 // Block the recorded argument values have been loaded up to
+	version  int   // Version of the contract's proxy the copy's abi and methods were taken at
+}
+
+func (tr *Transformer) initMethodStages() {
+	tr.methodStages = make(map[string]*methodStage, len(tr.Contracts))
+	for addr, con := range tr.Contracts {
+		if len(con.Methods) > 0 {
+			tr.methodStages[addr] = &methodStage{con: freshContract(con), loadedTo: -1, version: proxyVersion(con)}
+		}
+	}
+}
+
+// Takes up the abi and methods of the contract into the stage's copy once its proxy reports a new implementation,
+// keeping the argument values the copy has collected
+func (tr *Transformer) refreshStage(addr string, stage *methodStage) {
+	con := tr.Contracts[addr]
+	version := proxyVersion(con)
+	if version == stage.version {
+		return
+	}
+	refreshed := *stage.con
+	refreshed.Abi, refreshed.ParsedAbi = con.Abi, con.ParsedAbi
+	refreshed.Events = con.Events
+	refreshed.Methods = append([]types.Method{}, con.Methods...)
+	refreshed.Pipes, refreshed.ArgSources, refreshed.StaticArgSets = con.Pipes, con.ArgSources, con.StaticArgSets
+	refreshed.InitArgs()
+	stage.con = &refreshed
+	stage.version = version
+}
+
+func proxyVersion(con *contract.Contract) int {
+	if con.Proxy == nil {
+		return 0
+	}
+
+	return con.Proxy.Version()
+}
+
+// PollMethods polls the contracts' methods at the headers which have been checked for events but not yet for the methods,
+// when methods are polled as a stage of their own
+// Each method catches up on its own, so that a method added to a contract is backfilled while the rest stay at the head
+func (tr *Transformer) PollMethods() error {
+	if len(tr.Contracts) == 0 {
+		return errors.New("error: transformer has no initialized contracts")
+	}
+	// Contracts and their stages are only changed under the lock, as proxies are upgraded while processing events
+	tr.mutex.RLock()
+	stages := make(map[string]*methodStage, len(tr.methodStages))
+	for addr, stage := range tr.methodStages {
+		stages[addr] = stage
+	}
+	tr.mutex.RUnlock()
+	for addr, stage := range stages {
+		tr.mutex.RLock()
+		tr.refreshStage(addr, stage)
+		methodIds, eventIds := tr.sortedMethodIds[addr], tr.eventIds
+		tr.mutex.RUnlock()
+		pollingErr := tr.pollStage(stage, methodIds, eventIds)
+		if pollingErr != nil {
+			return fmt.Errorf("error polling methods: %s", pollingErr.Error())
+		}
+	}
+
+	return nil
+}
+
+func (tr *Transformer) pollStage(stage *methodStage, methodIds, eventIds []string) error {
+	con := stage.con
+	// Find the headers each method is missing, and the methods missing at each header
+	headers := make(map[int64]core.Header)
+	missing := make(map[int64][]int)
+	for i, methodID := range methodIds {
+		var methodHeaders []core.Header
+		var missingErr error
+		if len(eventIds) > 0 {
+			methodHeaders, missingErr = tr.HeaderRepository.MissingMethodsCheckedEventsIntersection(con.StartingBlock, -1, []string{methodID}, eventIds)
+		} else {
+			// Without any events there is nothing for the methods to wait on
+			methodHeaders, missingErr = tr.HeaderRepository.MissingHeaders(con.StartingBlock, -1, methodID)
+		}
+		if missingErr != nil {
+			return fmt.Errorf("error getting missing headers: %s", missingErr.Error())
+		}
+		for _, header := range methodHeaders {
+			headers[header.BlockNumber] = header
+			missing[header.BlockNumber] = append(missing[header.BlockNumber], i)
+		}
+	}
+	blockNumbers := make([]int64, 0, len(headers))
+	for blockNumber := range headers {
+		blockNumbers = append(blockNumbers, blockNumber)
+	}
+	sort.Slice(blockNumbers, func(i, j int) bool { return blockNumbers[i] < blockNumbers[j] })
+
+	for i, blockNumber := range blockNumbers {
+		header := headers[blockNumber]
+		loadErr := tr.loadStageArgs(stage, blockNumber)
+		if loadErr != nil {
+			return loadErr
+		}
+		methods := make([]types.Method, 0, len(missing[blockNumber]))
+		ids := make([]string, 0, len(missing[blockNumber]))
+		for _, j := range missing[blockNumber] {
+			methods = append(methods, stage.con.Methods[j])
+			ids = append(ids, methodIds[j])
+		}
+		// Methods scheduled to be polled only at the head are polled at the most recent header
+		pollingErr := tr.pollMethods(stage.con, methods, ids, header, i == len(blockNumbers)-1)
+		if pollingErr != nil {
+			return pollingErr
+		}
+	}
+
+	return nil
+}
+
+// Brings the stage's argument values up to the block, with those emitted at it touched
+// Going back to an earlier block, such as to backfill a new method, starts again from a fresh copy of the contract
+func (tr *Transformer) loadStageArgs(stage *methodStage, blockNumber int64) error {
+	if blockNumber <= stage.loadedTo {
+		stage.con = freshContract(stage.con)
+		stage.loadedTo = -1
+	}
+	args, loadErr := tr.ArgRepository.LoadArgsBetween(stage.con.Address, stage.loadedTo, blockNumber)
+	if loadErr != nil {
+		return fmt.Errorf("error loading method arguments: %s", loadErr.Error())
+	}
+	for _, arg := range args {
+		if arg.BlockNumber < blockNumber {
+			stage.con.AddKnownArg(arg.Type, arg.Value)
+		}
+	}
+	stage.con.ResetTouched()
+	for _, arg := range args {
+		if arg.BlockNumber == blockNumber {
+			stage.con.AddKnownArg(arg.Type, arg.Value)
+		}
+	}
+	stage.loadedTo = blockNumber
+
+	return nil
+}
+
+// Returns a copy of the contract without any of the argument values collected for it
+func freshContract(con *contract.Contract) *contract.Contract {
+	fresh := *con
+	fresh.EmittedAddrs, fresh.EmittedHashes, fresh.EmittedValues, fresh.EmittedTuples = nil, nil, nil, nil
+	fresh.PipedValues = nil
+
+	return fresh.Init()
+}
@@ -0,0 +1,349 @@
+// VulcanizeDB
+// Copyright © 2019 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package transformer
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	gethTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/sirupsen/logrus"
+
+	"github.com/vulcanize/eth-header-sync/pkg/core"
+
+	a "github.com/vulcanize/eth-contract-watcher/pkg/abi"
+	"github.com/vulcanize/eth-contract-watcher/pkg/contract"
+	"github.com/vulcanize/eth-contract-watcher/pkg/fetcher"
+	"github.com/vulcanize/eth-contract-watcher/pkg/parser"
+	"github.com/vulcanize/eth-contract-watcher/pkg/types"
+)
+
+// Detects whether the contract is a proxy, and if so returns its implementations and leaves the parser
+// with the proxy's abi merged with theirs, so that the events and methods of each are watched in the same tables
+// The implementations are those recorded in the catalog, or else the one delegated to at the starting block;
+// the one delegated to at the head is merged too, but only takes effect from the upgrade to it
+func (tr *Transformer) resolveProxy(contractAddr string, firstBlock int64) (*contract.Proxy, error) {
+	head, detectErr := tr.Proxies.FetchImplementation(contractAddr, -1)
+	if detectErr != nil {
+		logrus.Warnf("error detecting whether contract %s is a proxy: %s", contractAddr, detectErr.Error())
+		return nil, nil
+	}
+	implementations, loadErr := tr.Catalog.LoadImplementations(contractAddr)
+	if loadErr != nil {
+		return nil, loadErr
+	}
+	if head == "" && len(implementations) == 0 {
+		return nil, nil
+	}
+	proxyAbi, proxyParsed := tr.Parser.Abi(), tr.Parser.ParsedAbi()
+
+	if len(implementations) == 0 {
+		start, startErr := tr.Proxies.FetchImplementation(contractAddr, firstBlock)
+		if startErr != nil || start == "" {
+			logrus.Warnf("could not read the implementation of proxy %s at block %d, using its current implementation %s", contractAddr, firstBlock, head)
+			start = head
+		}
+		impl, implErr := tr.implementation(start, firstBlock)
+		if implErr != nil {
+			logrus.Warnf("watching proxy %s with its own abi: %s", contractAddr, implErr.Error())
+			return nil, tr.Parser.ParseAbiStr(proxyAbi)
+		}
+		implementations = append(implementations, impl)
+	}
+	abis := []string{proxyAbi}
+	for _, impl := range implementations {
+		abis = append(abis, impl.Abi)
+	}
+	if head != "" && !knownImplementation(implementations, head) {
+		impl, implErr := tr.implementation(head, -1)
+		if implErr != nil {
+			logrus.Warnf("not watching the abi of the current implementation of proxy %s: %s", contractAddr, implErr.Error())
+		} else {
+			abis = append(abis, impl.Abi)
+		}
+	}
+
+	merged, mergeErr := a.MergeAbis(abis...)
+	if mergeErr != nil {
+		return nil, fmt.Errorf("error merging the abis of proxy %s: %s", contractAddr, mergeErr.Error())
+	}
+	logrus.Infof("watching proxy %s with the abis of its implementations", contractAddr)
+
+	return contract.NewProxy(proxyAbi, proxyParsed, implementations...), tr.Parser.ParseAbiStr(merged)
+}
+
+// Fetches the abi of the implementation, which is delegated to from the block
+// It is parsed apart from the contract's own abi, which the Parser still holds
+func (tr *Transformer) implementation(implAddr string, block int64) (contract.Implementation, error) {
+	parseErr := tr.ImplementationParser.Parse(implAddr)
+	if parseErr != nil {
+		return contract.Implementation{}, fmt.Errorf("error fetching abi of implementation %s: %s", implAddr, parseErr.Error())
+	}
+
+	return contract.Implementation{
+		Address:   implAddr,
+		Block:     block,
+		Abi:       tr.ImplementationParser.Abi(),
+		ParsedAbi: tr.ImplementationParser.ParsedAbi(),
+	}, nil
+}
+
+func knownImplementation(implementations []contract.Implementation, implAddr string) bool {
+	for _, impl := range implementations {
+		if common.HexToAddress(impl.Address) == common.HexToAddress(implAddr) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Adds the topics of the events proxies emit when upgraded to the log filters
+func (tr *Transformer) watchUpgrades() {
+	for _, topic := range []common.Hash{fetcher.UpgradedTopic, fetcher.BeaconUpgradedTopic} {
+		watched := false
+		for _, filter := range tr.eventFilters {
+			watched = watched || filter == topic
+		}
+		if !watched {
+			tr.eventFilters = append(tr.eventFilters, topic)
+		}
+	}
+}
+
+// Switches the proxies which emitted upgrade events at the header to their new implementations
+// The logs of any events the new implementations add are fetched at the header too, as they were not yet filtered for
+func (tr *Transformer) upgradeProxies(sortedLogs map[string][]gethTypes.Log, header core.Header) error {
+	for conAddr, logs := range sortedLogs {
+		con := tr.Contracts[conAddr]
+		if con == nil || con.Proxy == nil {
+			continue
+		}
+		for _, log := range logs {
+			if len(log.Topics) < 2 {
+				continue
+			}
+			var implAddr string
+			switch log.Topics[0] {
+			case fetcher.UpgradedTopic:
+				implAddr = common.BytesToAddress(log.Topics[1].Bytes()).Hex()
+			case fetcher.BeaconUpgradedTopic:
+				beacon := common.BytesToAddress(log.Topics[1].Bytes()).Hex()
+				var fetchErr error
+				implAddr, fetchErr = tr.Proxies.FetchBeaconImplementation(beacon, header.BlockNumber)
+				if fetchErr != nil {
+					return fetchErr
+				}
+			default:
+				continue
+			}
+			if implAddr == "" {
+				continue
+			}
+			added, upgradeErr := tr.upgrade(con, implAddr, header.BlockNumber)
+			if upgradeErr != nil {
+				return upgradeErr
+			}
+			if len(added) == 0 {
+				continue
+			}
+			addedLogs, fetchErr := tr.Fetcher.FetchLogs([]string{con.Address}, added, header)
+			if fetchErr != nil {
+				return fmt.Errorf("error fetching logs: %s", fetchErr.Error())
+			}
+			sortedLogs[conAddr] = append(sortedLogs[conAddr], addedLogs...)
+		}
+	}
+
+	return nil
+}
+
+// Records the proxy delegating to the implementation from the block on, watching any events and methods it adds
+// The topics of the events added are returned
+func (tr *Transformer) upgrade(con *contract.Contract, implAddr string, block int64) ([]common.Hash, error) {
+	if current, ok := con.Proxy.ImplementationAt(block); ok && current.Block <= block &&
+		common.HexToAddress(current.Address) == common.HexToAddress(implAddr) {
+		return nil, nil
+	}
+	var added []common.Hash
+	impl, implErr := tr.implementation(implAddr, block)
+	// Methods polled concurrently take up the new abi and methods along with the upgrade, under the lock
+	tr.mutex.Lock()
+	defer tr.mutex.Unlock()
+	if implErr != nil {
+		// Without the implementation's abi every method is still polled, and any which it lacks recorded as reverted
+		logrus.Warnf("polling every method of proxy %s from its upgrade at block %d: %s", con.Address, block, implErr.Error())
+		impl = contract.Implementation{Address: implAddr, Block: block, Abi: con.Abi, ParsedAbi: con.ParsedAbi}
+	} else {
+		var watchErr error
+		added, watchErr = tr.watchImplementation(con, impl, block)
+		if watchErr != nil {
+			return nil, fmt.Errorf("error watching implementation %s of proxy %s: %s", implAddr, con.Address, watchErr.Error())
+		}
+	}
+	if !con.Proxy.Upgrade(impl) {
+		return added, nil
+	}
+	logrus.Infof("proxy %s upgraded to implementation %s at block %d", con.Address, implAddr, block)
+
+	return added, tr.Catalog.RecordImplementation(con.Address, impl)
+}
+
+// Adds the events and methods of the implementation which the contract does not have yet to those watched,
+// merging its abi into the contract's; the topics of the events added are returned
+// Headers before the upgrade are marked checked for them, as the proxy could not have emitted or answered them there
+// It is called holding the lock on the contracts
+func (tr *Transformer) watchImplementation(con *contract.Contract, impl contract.Implementation, block int64) ([]common.Hash, error) {
+	// Entries are told apart by signature, so that one the implementation changes is watched as a new overload
+	events, methods, addedErr := parser.AddedByImplementation(tr.ImplementationParser, con.Abi, impl.Abi, con.Events, con.Methods,
+		tr.Config.Events[con.Address], tr.Config.Methods[con.Address])
+	if addedErr != nil {
+		return nil, addedErr
+	}
+	merged := tr.ImplementationParser.Abi()
+
+	// Configure the new events and methods as they would have been had the contract started with them
+	configErr := tr.configureEvents(con.Address, events)
+	if configErr != nil {
+		return nil, configErr
+	}
+	tr.configureMethods(con.Address, methods)
+	namesErr := tr.checkTableNames(con.Address, events, methods)
+	if namesErr != nil {
+		return nil, namesErr
+	}
+	allEvents := make(map[string]types.Event, len(con.Events)+len(events))
+	for name, event := range con.Events {
+		allEvents[name] = event
+	}
+	for name, event := range events {
+		for _, existing := range con.Events {
+			if existing.Identifier == event.Identifier {
+				return nil, fmt.Errorf("event %s would share the table of event %s", name, existing.Name)
+			}
+		}
+		allEvents[name] = event
+	}
+	for _, m := range methods {
+		for _, existing := range con.Methods {
+			if existing.Identifier == m.Identifier {
+				return nil, fmt.Errorf("method %s would share the table of method %s", m.Name, existing.Name)
+			}
+		}
+	}
+	argSources, sourcesErr := resolveArgSources(allEvents, methods, tr.Config.ArgSources[con.Address])
+	if sourcesErr != nil {
+		return nil, fmt.Errorf("error resolving method argument sources: %s", sourcesErr.Error())
+	}
+	staticArgSets, staticErr := tr.loadStaticArgSets(methods, tr.Config.StaticArgs[con.Address])
+	if staticErr != nil {
+		return nil, fmt.Errorf("error loading static method arguments: %s", staticErr.Error())
+	}
+	for _, event := range events {
+		_, tableErr := tr.EventRepository.CreateEventTable(con.Address, event)
+		if tableErr != nil {
+			return nil, fmt.Errorf("error creating event table: %s", tableErr.Error())
+		}
+	}
+
+	// The columns are added and marked before the ids are kept, so that a failure leaves the upgrade to be retried whole
+	ids := make([]string, 0, len(events)+len(methods))
+	for _, event := range events {
+		ids = append(ids, tr.Namer.EventCheckID(con.Address, event.Identifier))
+	}
+	for _, m := range methods {
+		ids = append(ids, tr.Namer.MethodCheckID(con.Address, m.Identifier))
+	}
+	if len(ids) > 0 {
+		addColumnsErr := tr.HeaderRepository.AddCheckColumns(ids)
+		if addColumnsErr != nil {
+			return nil, fmt.Errorf("error adding check columns: %s", addColumnsErr.Error())
+		}
+		before, missingErr := tr.HeaderRepository.MissingHeadersForAll(con.StartingBlock, block-1, ids)
+		if missingErr != nil {
+			return nil, fmt.Errorf("error getting missing headers: %s", missingErr.Error())
+		}
+		markErr := tr.HeaderRepository.MarkHeadersCheckedForAll(before, ids)
+		if markErr != nil {
+			return nil, fmt.Errorf("error marking headers checked: %s", markErr.Error())
+		}
+	}
+	idsErr := tr.addEventIds(con.Address, events)
+	if idsErr != nil {
+		return nil, idsErr
+	}
+	idsErr = tr.addMethodIds(con.Address, methods)
+	if idsErr != nil {
+		return nil, idsErr
+	}
+	// The contract's headers are looked for from its start on the next pass, in case any were left unchecked for the new ids
+	if con.StartingBlock < tr.rewindFrom {
+		tr.rewindFrom = con.StartingBlock
+	}
+	polled := len(con.Methods) > 0
+	con.Abi, con.ParsedAbi = merged, tr.ImplementationParser.ParsedAbi()
+	con.Events = allEvents
+	con.Methods = append(append([]types.Method{}, con.Methods...), methods...)
+	con.ArgSources = mergeArgSources(con.ArgSources, argSources)
+	con.StaticArgSets = mergeArgSets(con.StaticArgSets, staticArgSets)
+	con.InitArgs()
+	// A contract which had no methods to poll starts collecting argument values for them
+	if !polled && len(con.Methods) > 0 {
+		argsErr := tr.loadKnownArgs(con)
+		if argsErr != nil {
+			return nil, argsErr
+		}
+		if tr.Config.DecoupledMethods {
+			tr.methodStages[con.Address] = &methodStage{con: freshContract(con), loadedTo: -1, version: proxyVersion(con)}
+		}
+	}
+
+	added := make([]common.Hash, 0, len(events))
+	for name, event := range events {
+		logrus.Infof("watching event %s of proxy %s from its upgrade at block %d", name, con.Address, block)
+		added = append(added, event.Sig())
+	}
+	for _, m := range methods {
+		logrus.Infof("polling method %s of proxy %s from its upgrade at block %d", m.Name, con.Address, block)
+	}
+
+	return added, nil
+}
+
+func mergeArgSources(current, added map[string][]contract.ArgSource) map[string][]contract.ArgSource {
+	merged := make(map[string][]contract.ArgSource, len(current)+len(added))
+	for name, sources := range current {
+		merged[name] = sources
+	}
+	for name, sources := range added {
+		merged[name] = sources
+	}
+
+	return merged
+}
+
+func mergeArgSets(current, added map[string][][]interface{}) map[string][][]interface{} {
+	merged := make(map[string][][]interface{}, len(current)+len(added))
+	for name, sets := range current {
+		merged[name] = sets
+	}
+	for name, sets := range added {
+		merged[name] = sets
+	}
+
+	return merged
+}
@@ -21,24 +21,31 @@ import (
 	"errors"
 	"fmt"
 	"math"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/common"
 	gethTypes "github.com/ethereum/go-ethereum/core/types"
 	"github.com/sirupsen/logrus"
 
 	"github.com/vulcanize/eth-header-sync/pkg/core"
-	"github.com/vulcanize/eth-header-sync/pkg/postgres"
 
+	a "github.com/vulcanize/eth-contract-watcher/pkg/abi"
 	"github.com/vulcanize/eth-contract-watcher/pkg/config"
+	"github.com/vulcanize/eth-contract-watcher/pkg/constants"
 	"github.com/vulcanize/eth-contract-watcher/pkg/contract"
 	"github.com/vulcanize/eth-contract-watcher/pkg/converter"
+	cwCore "github.com/vulcanize/eth-contract-watcher/pkg/core"
 	"github.com/vulcanize/eth-contract-watcher/pkg/fetcher"
+	"github.com/vulcanize/eth-contract-watcher/pkg/naming"
 	"github.com/vulcanize/eth-contract-watcher/pkg/parser"
 	"github.com/vulcanize/eth-contract-watcher/pkg/poller"
 	"github.com/vulcanize/eth-contract-watcher/pkg/repository"
 	"github.com/vulcanize/eth-contract-watcher/pkg/retriever"
+	"github.com/vulcanize/eth-contract-watcher/pkg/storage"
 	"github.com/vulcanize/eth-contract-watcher/pkg/types"
 )
 
@@ -46,21 +53,34 @@ import (
 // Requires a header synced vDB (headers) and a running eth node (or infura)
 type Transformer struct {
 	// Database interfaces
-	EventRepository  repository.EventRepository  // Holds transformed watched event log data
-	HeaderRepository repository.HeaderRepository // Interface for interaction with header repositories
+	EventRepository  repository.EventRepository   // Holds transformed watched event log data
+	HeaderRepository repository.HeaderRepository  // Interface for interaction with header repositories
+	Catalog          repository.CatalogRepository // Records watched contracts and their generated tables in the catalog
+	ArgRepository    repository.ArgRepository     // Persists the method argument values collected from events across restarts
 
 	// Pre-processing interfaces
-	Parser    parser.Parser            // Parses events and methods out of contract abi fetched using contract address
-	Retriever retriever.BlockRetriever // Retrieves first block for contract
+	Parser               parser.Parser            // Parses events and methods out of contract abi fetched using contract address
+	ImplementationParser parser.Parser            // Parses the abis of the implementations proxies delegate to, apart from Parser; required with Proxies
+	Retriever            retriever.BlockRetriever // Retrieves first block for contract
 
 	// Processing interfaces
 	Fetcher   fetcher.LogFetcher     // Fetches event logs, using header hashes
+	Proxies   fetcher.ProxyFetcher   // Reads the implementations proxy contracts delegate to; proxies are not detected without it
 	Converter converter.LogConverter // Converts watched event logs into custom log
 	Poller    poller.Poller          // Polls methods using arguments collected from events and persists them using a method datastore
 
 	// Store contract configuration information
 	Config config.ContractConfig
 
+	// Generates schema, table, and check column names; shared with the repositories
+	Namer *naming.Namer
+
+	// Block time each method was last polled at, by check column id, for time based schedules
+	lastPolled map[string]int64
+
+	// Contracts' method polling stages, when methods are polled apart from the events
+	methodStages map[string]*methodStage
+
 	// Store contract info as mapping to contract address
 	Contracts map[string]*contract.Contract
 
@@ -71,27 +91,78 @@ type Transformer struct {
 	eventIds          []string            // Holds event column ids across all contract, for batch fetching of headers
 	eventFilters      []common.Hash       // Holds topic0 hashes across all contracts, for batch fetching of logs
 	Start             int64               // Hold the lowest starting block and the highest ending block
+	startingBlock     int64               // The lowest starting block, below which headers are never looked for
+	rewindFrom        int64               // Lowest starting block of the contracts upgraded since the last pass; math.MaxInt64 when there are none
+	mutex             sync.RWMutex        // Guards the contracts and their ids against methods polled concurrently with the events
 }
 
+// Number of blocks behind the head the header sync revalidates, and so replaces in a reorg
+const validationWindow = 15
+
 // Order-of-operations:
 // 1. Create new transformer
 // 2. Load contract addresses and their parameters
 // 3. Init
 // 4. Execute
 
-// NewTransformer takes in a contract config, fetcher, and database, and returns a new Transformer
-func NewTransformer(con config.ContractConfig, client core.EthClient, db *postgres.DB, timeout time.Duration) *Transformer {
+// NewTransformer takes in a contract config, eth and rpc clients, and database, and returns a new Transformer
+func NewTransformer(con config.ContractConfig, client core.EthClient, rpcClient cwCore.BatchCaller, db *storage.DB, timeout time.Duration) *Transformer {
+	namer := naming.NewNamer(types.HeaderSync, con.EventTableTemplate, con.MethodTableTemplate, con.Aliases)
+	interfaces := fetcher.NewBatchFetcher(client, rpcClient, "", timeout)
+	provider := newAbiProvider(con, db, interfaces)
 	return &Transformer{
-		Poller:           poller.NewPoller(client, db, types.HeaderSync, timeout),
-		Fetcher:          fetcher.NewFetcher(client, timeout),
-		Parser:           parser.NewParser(con.Network),
-		HeaderRepository: repository.NewHeaderRepository(db),
-		Retriever:        retriever.NewBlockRetriever(db),
-		Converter:        &converter.Converter{},
-		Contracts:        map[string]*contract.Contract{},
-		EventRepository:  repository.NewEventRepository(db, types.HeaderSync),
-		Config:           con,
+		Poller:               poller.NewPoller(client, rpcClient, con.Multicall, db, types.HeaderSync, namer, timeout),
+		Fetcher:              fetcher.NewFetcher(client, timeout),
+		Proxies:              interfaces,
+		Parser:               parser.NewParser(provider),
+		ImplementationParser: parser.NewParser(provider),
+		HeaderRepository:     repository.NewHeaderRepository(db),
+		Catalog:              repository.NewCatalogRepository(db, namer),
+		ArgRepository:        repository.NewArgRepository(db, namer),
+		Retriever:            retriever.NewBlockRetriever(db),
+		Converter:            &converter.Converter{},
+		Contracts:            map[string]*contract.Contract{},
+		EventRepository:      repository.NewEventRepository(db, types.HeaderSync, namer),
+		Config:               con,
+		Namer:                namer,
+	}
+}
+
+// Chains the configured abi providers
+func newAbiProvider(con config.ContractConfig, db *storage.DB, interfaces fetcher.InterfaceFetcher) a.AbiProvider {
+	chainID := con.ChainID
+	if chainID == 0 {
+		chainID = a.ChainID(con.Network)
+	}
+	chain := make(a.ProviderChain, 0, len(con.AbiProviders))
+	for _, provider := range con.AbiProviders {
+		switch provider.Kind {
+		case config.BuiltinAbis:
+			chain = append(chain, a.MapProvider(constants.ABIs))
+		case config.DirectoryAbis:
+			chain = append(chain, a.NewDirectoryProvider(provider.Location))
+		case config.ArtifactAbis:
+			chain = append(chain, a.NewArtifactProvider(provider.Location, con.Artifacts))
+		case config.SourcifyAbis:
+			chain = append(chain, a.NewSourcifyProvider(provider.Location, chainID))
+		case config.EtherscanAbis:
+			explorer, ok := con.ExplorerURL, true
+			if explorer == "" {
+				explorer, ok = a.ExplorerURL(chainID)
+			}
+			if !ok {
+				logrus.Warnf("no explorer known for chain %d, set the contract `explorerURL` to fetch abis from one", chainID)
+				continue
+			}
+			chain = append(chain, a.NewEtherScanClientWithOptions(explorer, con.Etherscan))
+		case config.DatabaseAbis:
+			chain = append(chain, repository.NewCatalogAbiProvider(db))
+		case config.StandardAbis:
+			chain = append(chain, fetcher.NewStandardsProvider(interfaces))
+		}
 	}
+
+	return chain
 }
 
 // Init initialized the Transformer
@@ -107,12 +178,16 @@ func (tr *Transformer) Init() error {
 	tr.eventIds = make([]string, 0)                // Holds event column ids across all contract, for batch fetching of headers
 	tr.eventFilters = make([]common.Hash, 0)       // Holds topic0 hashes across all contracts, for batch fetching of logs
 	tr.Start = math.MaxInt64
+	tr.rewindFrom = math.MaxInt64
+	if tr.Namer == nil {
+		tr.Namer = naming.DefaultNamer(types.HeaderSync)
+	}
 
 	// Iterate through all internal contract addresses
 	for contractAddr := range tr.Config.Addresses {
 		// Configure Abi
 		if tr.Config.Abis[contractAddr] == "" {
-			// If no abi is given in the config, this method will try fetching from internal look-up table and etherscan
+			// If no abi is given in the config, this method will try fetching from the configured abi providers
 			parseErr := tr.Parser.Parse(contractAddr)
 			if parseErr != nil {
 				return fmt.Errorf("error parsing contract by address: %s", parseErr.Error())
@@ -141,6 +216,16 @@ func (tr *Transformer) Init() error {
 			firstBlock = tr.Config.StartingBlocks[contractAddr]
 		}
 
+		// Contracts behind proxies are watched with the abis of the implementations they delegate to
+		var proxy *contract.Proxy
+		if tr.Config.Abis[contractAddr] == "" && tr.Proxies != nil {
+			var proxyErr error
+			proxy, proxyErr = tr.resolveProxy(contractAddr, firstBlock)
+			if proxyErr != nil {
+				return fmt.Errorf("error resolving proxy implementations: %s", proxyErr.Error())
+			}
+		}
+
 		// Get contract name if it has one
 		var name = new(string)
 		pollingErr := tr.Poller.FetchContractData(tr.Parser.Abi(), contractAddr, "name", nil, name, -1)
@@ -158,6 +243,27 @@ func (tr *Transformer) Init() error {
 		for _, arg := range tr.Config.MethodArgs[contractAddr] {
 			methodArgs[arg] = true
 		}
+		staticArgs, argsErr := parseArgValues(tr.Config.ArgValues[contractAddr])
+		if argsErr != nil {
+			return fmt.Errorf("error parsing method argument values: %s", argsErr.Error())
+		}
+
+		events := tr.Parser.GetEvents(tr.Config.Events[contractAddr])
+		methods := tr.Parser.GetSelectMethods(tr.Config.Methods[contractAddr])
+		pipes, pipesErr := resolvePipes(methods, tr.Config.Pipes[contractAddr])
+		if pipesErr != nil {
+			return fmt.Errorf("error resolving method pipes: %s", pipesErr.Error())
+		}
+		// Methods are polled after those piped to them, so that they are polled with the values piped at the same block
+		methods = orderMethods(methods, pipes)
+		argSources, sourcesErr := resolveArgSources(events, methods, tr.Config.ArgSources[contractAddr])
+		if sourcesErr != nil {
+			return fmt.Errorf("error resolving method argument sources: %s", sourcesErr.Error())
+		}
+		staticArgSets, staticErr := tr.loadStaticArgSets(methods, tr.Config.StaticArgs[contractAddr])
+		if staticErr != nil {
+			return fmt.Errorf("error loading static method arguments: %s", staticErr.Error())
+		}
 
 		// Aggregate info into contract object and store for execution
 		con := contract.Contract{
@@ -167,39 +273,62 @@ func (tr *Transformer) Init() error {
 			Abi:           tr.Parser.Abi(),
 			ParsedAbi:     tr.Parser.ParsedAbi(),
 			StartingBlock: firstBlock,
-			Events:        tr.Parser.GetEvents(tr.Config.Events[contractAddr]),
-			Methods:       tr.Parser.GetSelectMethods(tr.Config.Methods[contractAddr]),
+			Events:        events,
+			Methods:       methods,
 			FilterArgs:    eventArgs,
 			MethodArgs:    methodArgs,
+			StaticArgs:    staticArgs,
+			StaticArgSets: staticArgSets,
+			ArgSources:    argSources,
 			Piping:        tr.Config.Piping[contractAddr],
+			Pipes:         pipes,
+			TouchedOnly:   tr.Config.TouchedOnly[contractAddr],
+			FullPollEvery: tr.Config.FullPollIntervals[contractAddr],
+			Proxy:         proxy,
 		}.Init()
+		// Apply any configured index overrides, partitioning and storage modes to the events and methods
+		configErr := tr.configureEvents(contractAddr, con.Events)
+		if configErr != nil {
+			return configErr
+		}
+		tr.configureMethods(contractAddr, con.Methods)
+		namesErr := tr.checkTableNames(contractAddr, con.Events, con.Methods)
+		if namesErr != nil {
+			return namesErr
+		}
+		// Restore the method argument values collected before any restart
+		if len(con.Methods) > 0 {
+			argsErr = tr.loadKnownArgs(con)
+			if argsErr != nil {
+				return argsErr
+			}
+		}
 		tr.Contracts[contractAddr] = con
+		catalogErr := tr.Catalog.RecordContract(*con)
+		if catalogErr != nil {
+			return fmt.Errorf("error recording contract in catalog: %s", catalogErr.Error())
+		}
+		if con.Proxy != nil {
+			for _, impl := range con.Proxy.Implementations() {
+				catalogErr = tr.Catalog.RecordImplementation(con.Address, impl)
+				if catalogErr != nil {
+					return catalogErr
+				}
+			}
+			tr.watchUpgrades()
+		}
 		tr.contractAddresses = append(tr.contractAddresses, con.Address)
 
-		// Create checked_headers columns for each event id and append to list of all event ids
+		// Create checked_headers columns for each event and method id and append them to the lists of ids
 		tr.sortedEventIds[con.Address] = make([]string, 0, len(con.Events))
-		for _, event := range con.Events {
-			eventID := strings.ToLower(event.Name + "_" + con.Address)
-			addColumnErr := tr.HeaderRepository.AddCheckColumn(eventID)
-			if addColumnErr != nil {
-				return fmt.Errorf("error adding check column: %s", addColumnErr.Error())
-			}
-			// Keep track of this event id; sorted and unsorted
-			tr.sortedEventIds[con.Address] = append(tr.sortedEventIds[con.Address], eventID)
-			tr.eventIds = append(tr.eventIds, eventID)
-			// Append this event sig to the filters
-			tr.eventFilters = append(tr.eventFilters, event.Sig())
+		idsErr := tr.addEventIds(con.Address, con.Events)
+		if idsErr != nil {
+			return idsErr
 		}
-
-		// Create checked_headers columns for each method id and append list of all method ids
 		tr.sortedMethodIds[con.Address] = make([]string, 0, len(con.Methods))
-		for _, m := range con.Methods {
-			methodID := strings.ToLower(m.Name + "_" + con.Address)
-			addColumnErr := tr.HeaderRepository.AddCheckColumn(methodID)
-			if addColumnErr != nil {
-				return fmt.Errorf("error adding check column: %s", addColumnErr.Error())
-			}
-			tr.sortedMethodIds[con.Address] = append(tr.sortedMethodIds[con.Address], methodID)
+		idsErr = tr.addMethodIds(con.Address, con.Methods)
+		if idsErr != nil {
+			return idsErr
 		}
 
 		// Update start to the lowest block
@@ -207,6 +336,10 @@ func (tr *Transformer) Init() error {
 			tr.Start = con.StartingBlock
 		}
 	}
+	tr.startingBlock = tr.Start
+	if tr.Config.DecoupledMethods {
+		tr.initMethodStages()
+	}
 
 	return nil
 }
@@ -218,17 +351,39 @@ func (tr *Transformer) Execute() error {
 	}
 
 	// Find unchecked headers for all events across all contracts; these are returned in asc order
-	missingHeaders, missingHeadersErr := tr.HeaderRepository.MissingHeadersForAll(tr.Start, -1, tr.eventIds)
+	// Headers replaced in a reorg are unchecked again, so they are looked for within the window the header sync revalidates,
+	// and once from the start of any contract upgraded since the last pass
+	from := tr.Start - validationWindow
+	if tr.rewindFrom < from {
+		from = tr.rewindFrom
+	}
+	if from < tr.startingBlock {
+		from = tr.startingBlock
+	}
+	missingHeaders, missingHeadersErr := tr.HeaderRepository.MissingHeadersForAll(from, -1, tr.eventIds)
 	if missingHeadersErr != nil {
 		return fmt.Errorf("error getting missing headers: %s", missingHeadersErr.Error())
 	}
+	if len(missingHeaders) > 0 && missingHeaders[0].BlockNumber < tr.Start {
+		rewindErr := tr.rewind(missingHeaders[0].BlockNumber)
+		if rewindErr != nil {
+			return rewindErr
+		}
+	}
+	tr.rewindFrom = math.MaxInt64
 
 	// Iterate over headers
-	for _, header := range missingHeaders {
+	for i, header := range missingHeaders {
+		// Methods scheduled to be polled only at the head are polled at the most recent header
+		head := i == len(missingHeaders)-1
 		// Set `start` to this header
 		// This way if we throw an error but don't bring the execution cycle down (how it is currently handled)
 		// we restart the cycle at this header
 		tr.Start = header.BlockNumber
+		// Only the argument values emitted at this header count as touched by it
+		for _, con := range tr.Contracts {
+			con.ResetTouched()
+		}
 		// Map to sort batch fetched logs by which contract they belong to, for post fetch processing
 		sortedLogs := make(map[string][]gethTypes.Log)
 		// And fetch all event logs across contracts at this header
@@ -240,13 +395,9 @@ func (tr *Transformer) Execute() error {
 		// If no logs are found mark the header checked for all of these eventIDs
 		// and continue to method polling and onto the next iteration
 		if len(allLogs) < 1 {
-			markCheckedErr := tr.HeaderRepository.MarkHeaderCheckedForAll(header.ID, tr.eventIds)
-			if markCheckedErr != nil {
-				return fmt.Errorf("error marking header checked: %s", markCheckedErr.Error())
-			}
-			pollingErr := tr.methodPolling(header, tr.sortedMethodIds)
-			if pollingErr != nil {
-				return fmt.Errorf("error polling methods: %s", pollingErr.Error())
+			finishErr := tr.finishHeader(header, head)
+			if finishErr != nil {
+				return finishErr
 			}
 			tr.Start = header.BlockNumber + 1 // Empty header; setup to start at the next header
 			logrus.Tracef("no logs found for block %d, continuing", header.BlockNumber)
@@ -257,6 +408,11 @@ func (tr *Transformer) Execute() error {
 			addr := strings.ToLower(log.Address.Hex())
 			sortedLogs[addr] = append(sortedLogs[addr], log)
 		}
+		// Proxies upgraded at this header switch implementation before their logs are converted and their methods polled
+		upgradeErr := tr.upgradeProxies(sortedLogs, header)
+		if upgradeErr != nil {
+			return fmt.Errorf("error upgrading proxies: %s", upgradeErr.Error())
+		}
 
 		// Process logs for each contract
 		for conAddr, logs := range sortedLogs {
@@ -288,25 +444,56 @@ func (tr *Transformer) Execute() error {
 			}
 		}
 
-		markCheckedErr := tr.HeaderRepository.MarkHeaderCheckedForAll(header.ID, tr.eventIds)
-		if markCheckedErr != nil {
-			return fmt.Errorf("error marking header checked: %s", markCheckedErr.Error())
-		}
-
-		// Poll contracts at this block height
-		pollingErr := tr.methodPolling(header, tr.sortedMethodIds)
-		if pollingErr != nil {
-			return fmt.Errorf("error polling methods: %s", pollingErr.Error())
+		finishErr := tr.finishHeader(header, head)
+		if finishErr != nil {
+			return finishErr
 		}
 		// Success; setup to start at the next header
 		tr.Start = header.BlockNumber + 1
 	}
 
+	// Methods polled as a stage of their own catch up to the events, unless they are run concurrently
+	if tr.Config.DecoupledMethods && !tr.Config.ConcurrentMethods {
+		return tr.PollMethods()
+	}
+
+	return nil
+}
+
+// Marks the header checked for the events and polls the methods at it, or when methods are polled as a stage of their own,
+// records the argument values collected at the header for that stage before marking it checked
+func (tr *Transformer) finishHeader(header core.Header, head bool) error {
+	if tr.Config.DecoupledMethods {
+		for _, con := range tr.Contracts {
+			if len(con.Methods) == 0 {
+				continue
+			}
+			persistErr := tr.ArgRepository.PersistArgs(con.Address, header.ID, header.BlockNumber, con.Touched)
+			if persistErr != nil {
+				return fmt.Errorf("error persisting method arguments for contract %s: %s", con.Address, persistErr.Error())
+			}
+		}
+	}
+	markCheckedErr := tr.HeaderRepository.MarkHeaderCheckedForAll(header.ID, tr.eventIds)
+	if markCheckedErr != nil {
+		return fmt.Errorf("error marking header checked: %s", markCheckedErr.Error())
+	}
+	if tr.Config.DecoupledMethods {
+		return nil
+	}
+
+	// Poll contracts at this block height
+	pollingErr := tr.methodPolling(header, tr.sortedMethodIds, head)
+	if pollingErr != nil {
+		return fmt.Errorf("error polling methods: %s", pollingErr.Error())
+	}
+
 	return nil
 }
 
 // Used to poll contract methods at a given header
-func (tr *Transformer) methodPolling(header core.Header, sortedMethodIds map[string][]string) error {
+// Only the methods whose schedules are due are polled, but the header is marked checked for every method
+func (tr *Transformer) methodPolling(header core.Header, sortedMethodIds map[string][]string, head bool) error {
 	for _, con := range tr.Contracts {
 		// Skip method polling processes if no methods are specified
 		// Also don't try to poll methods below this contract's specified starting block
@@ -314,17 +501,336 @@ func (tr *Transformer) methodPolling(header core.Header, sortedMethodIds map[str
 			logrus.Tracef("not polling contract: %s", con.Address)
 			continue
 		}
+		pollingErr := tr.pollMethods(con, con.Methods, sortedMethodIds[con.Address], header, head)
+		if pollingErr != nil {
+			return pollingErr
+		}
+	}
+
+	return nil
+}
 
-		// Poll all methods for this contract at this header
-		pollingErr := tr.Poller.PollContractAt(*con, header.BlockNumber)
+// Polls those of the contract's given methods which are due at the header, persists the argument values collected at it,
+// and marks it checked for the given methods
+func (tr *Transformer) pollMethods(con *contract.Contract, methods []types.Method, methodIds []string, header core.Header, head bool) error {
+	if tr.lastPolled == nil {
+		tr.lastPolled = map[string]int64{}
+	}
+	blockTime, _ := strconv.ParseInt(header.Timestamp, 10, 64)
+
+	// Poll the methods which are due for this contract at this header
+	due := *con
+	due.Methods = make([]types.Method, 0, len(methods))
+	dueIds := make([]string, 0, len(methods))
+	for i, m := range methods {
+		if con.PolledAt(m, header.BlockNumber) && m.Schedule.Due(con.StartingBlock, header.BlockNumber, blockTime, tr.lastPolled[methodIds[i]], head) {
+			due.Methods = append(due.Methods, m)
+			dueIds = append(dueIds, methodIds[i])
+		}
+	}
+	if len(due.Methods) > 0 {
+		pollingErr := tr.Poller.PollContractAt(due, header.BlockNumber)
 		if pollingErr != nil {
 			return fmt.Errorf("error polling contract %s: %s", con.Address, pollingErr.Error())
 		}
+	}
+	for _, methodID := range dueIds {
+		tr.lastPolled[methodID] = blockTime
+	}
+
+	// Persist the argument values collected at this header, including any piped from method results
+	persistErr := tr.ArgRepository.PersistArgs(con.Address, header.ID, header.BlockNumber, con.Touched)
+	if persistErr != nil {
+		return fmt.Errorf("error persisting method arguments for contract %s: %s", con.Address, persistErr.Error())
+	}
+
+	// Mark this header checked for the methods, including those not due, so that skipped blocks are not polled later
+	markCheckedErr := tr.HeaderRepository.MarkHeaderCheckedForAll(header.ID, methodIds)
+	if markCheckedErr != nil {
+		return fmt.Errorf("error marking header checked: %s", markCheckedErr.Error())
+	}
+
+	return nil
+}
+
+// Reads the sets of argument values configured for each method, from the config or by querying the database,
+// and converts them into the go types they are packed from
+func (tr *Transformer) loadStaticArgSets(methods []types.Method, configured map[string]config.StaticArgSource) (map[string][][]interface{}, error) {
+	staticArgSets := make(map[string][][]interface{}, len(configured))
+	for _, method := range methods {
+		source, ok := configured[strings.ToLower(method.Name)]
+		if !ok {
+			continue
+		}
+		rows := source.Rows
+		if source.Query != "" {
+			var queryErr error
+			rows, queryErr = tr.ArgRepository.QueryArgs(source.Query)
+			if queryErr != nil {
+				return nil, queryErr
+			}
+		}
+		for i, row := range rows {
+			// A header row naming the arguments is skipped
+			if i == 0 && isArgHeader(method, row) {
+				continue
+			}
+			if len(row) != len(method.Args) {
+				return nil, fmt.Errorf("method %s takes %d arguments, but %d values are given: %v", method.Name, len(method.Args), len(row), row)
+			}
+			set := make([]interface{}, len(row))
+			for j, value := range row {
+				arg, parseErr := types.ParseArg(method.Args[j].Type, strings.TrimSpace(value))
+				if parseErr != nil {
+					return nil, fmt.Errorf("method %s argument %s: %s", method.Name, method.Args[j].Name, parseErr.Error())
+				}
+				set[j] = arg
+			}
+			staticArgSets[method.Name] = append(staticArgSets[method.Name], set)
+		}
+	}
+
+	return staticArgSets, nil
+}
 
-		// Mark this header checked for the methods
-		markCheckedErr := tr.HeaderRepository.MarkHeaderCheckedForAll(header.ID, sortedMethodIds[con.Address])
-		if markCheckedErr != nil {
-			return fmt.Errorf("error marking header checked: %s", markCheckedErr.Error())
+func isArgHeader(method types.Method, row []string) bool {
+	if len(row) != len(method.Args) {
+		return false
+	}
+	for i, arg := range method.Args {
+		if !strings.EqualFold(strings.TrimSpace(row[i]), arg.Name) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Matches each configured method's `Event.field` argument sources to the watched events,
+// checking that there is one of the right type for each argument
+func resolveArgSources(events map[string]types.Event, methods []types.Method, configured map[string][]string) (map[string][]contract.ArgSource, error) {
+	argSources := make(map[string][]contract.ArgSource, len(configured))
+	for _, method := range methods {
+		sources, ok := configured[strings.ToLower(method.Name)]
+		if !ok {
+			continue
+		}
+		if len(sources) != len(method.Args) {
+			return nil, fmt.Errorf("method %s takes %d arguments, but %d sources are given", method.Name, len(method.Args), len(sources))
+		}
+		for i, source := range sources {
+			parts := strings.Split(source, ".")
+			field, found := findEventField(events, parts[0], parts[len(parts)-1])
+			if !found {
+				return nil, fmt.Errorf("source %s of method %s is not a field of a watched event", source, method.Name)
+			}
+			if field.Type.String() != method.Args[i].Type.String() {
+				return nil, fmt.Errorf("source %s of type %s can not be used as argument %d of method %s, of type %s",
+					source, field.Type.String(), i, method.Name, method.Args[i].Type.String())
+			}
+			argSources[method.Name] = append(argSources[method.Name], contract.ArgSource{Event: field.Event, Field: field.Name})
+		}
+	}
+
+	return argSources, nil
+}
+
+// Matches the configured pipes to the polled methods by name, checking that each method piped to
+// takes an argument of a type returned by the method piped from
+func resolvePipes(methods []types.Method, configured types.Pipes) (types.Pipes, error) {
+	byName := make(map[string]types.Method, len(methods))
+	for _, method := range methods {
+		byName[strings.ToLower(method.Name)] = method
+	}
+	pipes := make(types.Pipes, len(configured))
+	for source, targets := range configured {
+		from, ok := byName[source]
+		if !ok {
+			return nil, fmt.Errorf("method %s is piped from but is not polled", source)
+		}
+		for _, target := range targets {
+			to, ok := byName[target]
+			if !ok {
+				return nil, fmt.Errorf("method %s is piped to but is not polled", target)
+			}
+			if !pipeable(from, to) {
+				return nil, fmt.Errorf("method %s returns no value which can be used as an argument of method %s", from.Name, to.Name)
+			}
+			pipes[from.Name] = append(pipes[from.Name], to.Name)
+		}
+	}
+
+	return pipes, nil
+}
+
+func pipeable(from, to types.Method) bool {
+	for _, output := range from.Outputs {
+		for _, arg := range to.Args {
+			if output.Type.String() == arg.Type.String() {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// Sorts the methods so that each follows the methods piped to it
+func orderMethods(methods []types.Method, pipes types.Pipes) []types.Method {
+	if len(pipes) == 0 {
+		return methods
+	}
+	names := make([]string, 0, len(methods))
+	byName := make(map[string][]types.Method, len(methods))
+	for _, method := range methods {
+		if _, ok := byName[method.Name]; !ok {
+			names = append(names, method.Name)
+		}
+		byName[method.Name] = append(byName[method.Name], method)
+	}
+	ordered := make([]types.Method, 0, len(methods))
+	for _, name := range pipes.Order(names) {
+		ordered = append(ordered, byName[name]...)
+	}
+
+	return ordered
+}
+
+// The event field, matched case-insensitively, along with the name of its event as given in the abi
+type sourceField struct {
+	types.Field
+	Event string
+}
+
+func findEventField(events map[string]types.Event, eventName, fieldName string) (sourceField, bool) {
+	for _, event := range events {
+		if !strings.EqualFold(event.Name, eventName) {
+			continue
+		}
+		for _, field := range event.Fields {
+			if strings.EqualFold(field.Name, fieldName) {
+				return sourceField{Field: field, Event: event.Name}, true
+			}
+		}
+	}
+
+	return sourceField{}, false
+}
+
+// Creates the contract's table of collected method argument values and adds those already in it to the contract
+func (tr *Transformer) loadKnownArgs(con *contract.Contract) error {
+	createErr := tr.ArgRepository.CreateArgTable(con.Address)
+	if createErr != nil {
+		return fmt.Errorf("error creating method arguments table: %s", createErr.Error())
+	}
+	args, loadErr := tr.ArgRepository.LoadArgs(con.Address)
+	if loadErr != nil {
+		return fmt.Errorf("error loading method arguments: %s", loadErr.Error())
+	}
+	for _, arg := range args {
+		con.AddKnownArg(arg.Type, arg.Value)
+	}
+	// Values loaded from earlier blocks were not touched by the next block
+	con.ResetTouched()
+
+	return nil
+}
+
+// Applies the configured index overrides and partitioning to the contract's events
+func (tr *Transformer) configureEvents(contractAddr string, events map[string]types.Event) error {
+	for name, event := range events {
+		key := strings.ToLower(name)
+		indexes, unindexed := tr.Config.Indexes[contractAddr][key], tr.Config.Unindexed[contractAddr][key]
+		if indexes != nil || unindexed != nil {
+			fieldsErr := event.CheckFields(append(append([]string{}, indexes...), unindexed...))
+			if fieldsErr != nil {
+				return fmt.Errorf("error configuring indexes for contract %s: %s", contractAddr, fieldsErr.Error())
+			}
+			event.Indexes = event.IndexColumns(indexes, unindexed)
+		}
+		event.PartitionSize = tr.Config.PartitionSizes[contractAddr]
+		events[name] = event
+	}
+
+	return nil
+}
+
+// Applies the configured partitioning, storage mode and schedules to the contract's methods
+func (tr *Transformer) configureMethods(contractAddr string, methods []types.Method) {
+	for i := range methods {
+		methods[i].PartitionSize = tr.Config.PartitionSizes[contractAddr]
+		methods[i].ChangesOnly = tr.Config.ChangesOnly[contractAddr]
+		methods[i].Schedule = tr.Config.Schedules[contractAddr][strings.ToLower(methods[i].Name)]
+	}
+}
+
+// Creates checked_headers columns for the events, adds their ids to those of the contract and across contracts,
+// and their topics to the log filters
+func (tr *Transformer) addEventIds(contractAddr string, events map[string]types.Event) error {
+	for _, event := range events {
+		eventID := tr.Namer.EventCheckID(contractAddr, event.Identifier)
+		addColumnErr := tr.HeaderRepository.AddCheckColumn(eventID)
+		if addColumnErr != nil {
+			return fmt.Errorf("error adding check column: %s", addColumnErr.Error())
+		}
+		// Keep track of this event id; sorted and unsorted
+		tr.sortedEventIds[contractAddr] = append(tr.sortedEventIds[contractAddr], eventID)
+		tr.eventIds = append(tr.eventIds, eventID)
+		// Append this event sig to the filters
+		tr.eventFilters = append(tr.eventFilters, event.Sig())
+	}
+
+	return nil
+}
+
+// Creates checked_headers columns for the methods and adds their ids to those of the contract, in the same order
+func (tr *Transformer) addMethodIds(contractAddr string, methods []types.Method) error {
+	for _, m := range methods {
+		methodID := tr.Namer.MethodCheckID(contractAddr, m.Identifier)
+		addColumnErr := tr.HeaderRepository.AddCheckColumn(methodID)
+		if addColumnErr != nil {
+			return fmt.Errorf("error adding check column: %s", addColumnErr.Error())
+		}
+		tr.sortedMethodIds[contractAddr] = append(tr.sortedMethodIds[contractAddr], methodID)
+	}
+
+	return nil
+}
+
+// Rebuilds the method argument values collected for each contract from those still persisted, once a reorg
+// has removed the headers from the block onwards along with the values emitted at them
+func (tr *Transformer) rewind(blockNumber int64) error {
+	tr.mutex.Lock()
+	defer tr.mutex.Unlock()
+	logrus.Infof("headers from block %d were replaced, rewinding method arguments collected from %d", blockNumber, tr.Start)
+	for addr, con := range tr.Contracts {
+		if len(con.Methods) == 0 {
+			continue
+		}
+		fresh := freshContract(con)
+		loadErr := tr.loadKnownArgs(fresh)
+		if loadErr != nil {
+			return loadErr
+		}
+		tr.Contracts[addr] = fresh
+	}
+	tr.Start = blockNumber
+
+	return nil
+}
+
+// Returns an error if any of the contract's event or method tables would take the name of a table kept for the contract as a whole
+func (tr *Transformer) checkTableNames(contractAddr string, events map[string]types.Event, methods []types.Method) error {
+	for _, event := range events {
+		err := tr.Namer.CheckReserved(contractAddr, tr.Namer.EventTable(contractAddr, event.Identifier))
+		if err != nil {
+			return fmt.Errorf("error naming tables for contract %s: %s", contractAddr, err.Error())
+		}
+	}
+	for _, m := range methods {
+		err := tr.Namer.CheckReserved(contractAddr, tr.Namer.MethodTable(contractAddr, m.Identifier))
+		if err != nil {
+			return fmt.Errorf("error naming tables for contract %s: %s", contractAddr, err.Error())
 		}
 	}
 
@@ -335,3 +841,23 @@ func (tr *Transformer) methodPolling(header core.Header, sortedMethodIds map[str
 func (tr *Transformer) GetConfig() config.ContractConfig {
 	return tr.Config
 }
+
+// Converts the configured method argument values into the go types they are packed from, keyed by abi type
+func parseArgValues(argValues map[string][]string) (map[string][]interface{}, error) {
+	staticArgs := make(map[string][]interface{}, len(argValues))
+	for abiType, values := range argValues {
+		t, err := abi.NewType(abiType, "", nil)
+		if err != nil {
+			return nil, err
+		}
+		for _, value := range values {
+			arg, err := types.ParseArg(t, value)
+			if err != nil {
+				return nil, err
+			}
+			staticArgs[t.String()] = append(staticArgs[t.String()], arg)
+		}
+	}
+
+	return staticArgs, nil
+}
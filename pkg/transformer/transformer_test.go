@@ -27,10 +27,12 @@ import (
 	"github.com/vulcanize/eth-contract-watcher/pkg/contract"
 	"github.com/vulcanize/eth-contract-watcher/pkg/fakes"
 	"github.com/vulcanize/eth-contract-watcher/pkg/helpers/test_helpers/mocks"
+	"github.com/vulcanize/eth-contract-watcher/pkg/naming"
 	"github.com/vulcanize/eth-contract-watcher/pkg/parser"
 	"github.com/vulcanize/eth-contract-watcher/pkg/poller"
 	"github.com/vulcanize/eth-contract-watcher/pkg/retriever"
 	"github.com/vulcanize/eth-contract-watcher/pkg/transformer"
+	"github.com/vulcanize/eth-contract-watcher/pkg/types"
 )
 
 var _ = Describe("Transformer", func() {
@@ -64,6 +66,43 @@ var _ = Describe("Transformer", func() {
 			Expect(c.Address).To(Equal(fakeAddress))
 		})
 
+		It("Records initialized contracts in the catalog", func() {
+			blockRetriever := &fakes.MockHeaderSyncBlockRetriever{}
+			parsr := &fakes.MockParser{}
+			parsr.AbiToReturn = "fake_abi"
+			catalog := &fakes.MockCatalogRepository{}
+			t := getFakeTransformer(blockRetriever, parsr, &fakes.MockPoller{})
+			t.Catalog = catalog
+
+			err := t.Init()
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(len(catalog.RecordedContracts)).To(Equal(1))
+			Expect(catalog.RecordedContracts[0].Address).To(Equal(fakeAddress))
+			Expect(catalog.RecordedContracts[0].Abi).To(Equal("fake_abi"))
+		})
+
+		It("Fails to initialize if the contract cannot be recorded in the catalog", func() {
+			t := getFakeTransformer(&fakes.MockHeaderSyncBlockRetriever{}, &fakes.MockParser{}, &fakes.MockPoller{})
+			t.Catalog = &fakes.MockCatalogRepository{RecordContractErr: hf.FakeError}
+
+			err := t.Init()
+
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring(hf.FakeError.Error()))
+		})
+
+		It("Fails to initialize if an event table takes the name of a table kept for the contract", func() {
+			parsr := &fakes.MockParser{EventName: "Known_Args", Event: types.Event{Name: "Known_Args", Identifier: "Known_Args"}}
+			t := getFakeTransformer(&fakes.MockHeaderSyncBlockRetriever{}, parsr, &fakes.MockPoller{})
+			t.Namer = naming.NewNamer(types.HeaderSync, "{alias}.{event}", "{alias}.{method}", nil)
+
+			err := t.Init()
+
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("reserved"))
+		})
+
 		It("Fails to initialize if first block cannot be fetched from vDB headers table", func() {
 			blockRetriever := &fakes.MockHeaderSyncBlockRetriever{}
 			blockRetriever.FirstBlockErr = hf.FakeError
@@ -134,6 +173,8 @@ func getFakeTransformer(blockRetriever retriever.BlockRetriever, parsr parser.Pa
 		Retriever:        blockRetriever,
 		Poller:           pollr,
 		HeaderRepository: &fakes.MockHeaderSyncHeaderRepository{},
+		Catalog:          &fakes.MockCatalogRepository{},
+		ArgRepository:    &fakes.MockArgRepository{},
 		Contracts:        map[string]*contract.Contract{},
 		Config:           mocks.MockConfig,
 	}
@@ -0,0 +1,110 @@
+// VulcanizeDB
+// Copyright © 2019 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package types
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+	"strconv"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// PollableArg returns true if values of the abi type can be used as method arguments by the poller
+func PollableArg(t abi.Type) bool {
+	switch t.T {
+	case abi.IntTy, abi.UintTy, abi.BoolTy, abi.StringTy, abi.AddressTy, abi.HashTy, abi.FixedBytesTy, abi.BytesTy:
+		return true
+	default:
+		return false
+	}
+}
+
+// ParseArg converts the string form of a value into the go type used to pack it as a method argument of the abi type
+// Numbers are given in decimal, and byte arrays in 0x prefixed hex
+func ParseArg(t abi.Type, value string) (interface{}, error) {
+	switch t.T {
+	case abi.IntTy, abi.UintTy:
+		n, ok := new(big.Int).SetString(value, 10)
+		if !ok {
+			return nil, fmt.Errorf("%s is not a valid %s", value, t.String())
+		}
+		if !inRange(t, n) {
+			return nil, fmt.Errorf("%s is out of range for %s", value, t.String())
+		}
+		if t.Kind == reflect.Ptr {
+			return n, nil
+		}
+		// Sizes of 64 bits and less are packed from their native go types
+		v := reflect.New(t.Type).Elem()
+		if t.T == abi.UintTy {
+			v.SetUint(n.Uint64())
+		} else {
+			v.SetInt(n.Int64())
+		}
+		return v.Interface(), nil
+	case abi.BoolTy:
+		return strconv.ParseBool(value)
+	case abi.StringTy:
+		return value, nil
+	case abi.AddressTy:
+		if !common.IsHexAddress(value) {
+			return nil, fmt.Errorf("%s is not a valid address", value)
+		}
+		return common.HexToAddress(value), nil
+	case abi.HashTy:
+		return common.HexToHash(value), nil
+	case abi.FixedBytesTy:
+		b, err := hexutil.Decode(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s is not a valid %s: %s", value, t.String(), err.Error())
+		}
+		if len(b) != t.Size {
+			return nil, fmt.Errorf("%s is not a valid %s: length %d", value, t.String(), len(b))
+		}
+		if t.Size == common.HashLength {
+			return common.BytesToHash(b), nil
+		}
+		v := reflect.New(t.Type).Elem()
+		reflect.Copy(v, reflect.ValueOf(b))
+		return v.Interface(), nil
+	case abi.BytesTy:
+		b, err := hexutil.Decode(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s is not a valid %s: %s", value, t.String(), err.Error())
+		}
+		return b, nil
+	default:
+		return nil, fmt.Errorf("values of type %s can not be used as method arguments", t.String())
+	}
+}
+
+// Checks the number fits in the integer type
+func inRange(t abi.Type, n *big.Int) bool {
+	if t.T == abi.UintTy {
+		return n.Sign() >= 0 && n.BitLen() <= t.Size
+	}
+	// -n-1 has the same bit length as n does in two's complement, less its sign bit
+	if n.Sign() < 0 {
+		return new(big.Int).Not(n).BitLen() < t.Size
+	}
+
+	return n.BitLen() < t.Size
+}
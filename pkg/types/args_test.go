@@ -0,0 +1,89 @@
+// VulcanizeDB
+// Copyright © 2019 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package types_test
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/vulcanize/eth-contract-watcher/pkg/types"
+)
+
+var _ = Describe("ParseArg", func() {
+	newType := func(t string) abi.Type {
+		typ, err := abi.NewType(t, "", nil)
+		Expect(err).ToNot(HaveOccurred())
+		return typ
+	}
+
+	It("Parses numbers into the go types they are packed from", func() {
+		arg, err := types.ParseArg(newType("uint256"), "12345678901234567890123")
+		Expect(err).ToNot(HaveOccurred())
+		expected, _ := new(big.Int).SetString("12345678901234567890123", 10)
+		Expect(arg).To(Equal(expected))
+
+		arg, err = types.ParseArg(newType("uint8"), "18")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(arg).To(Equal(uint8(18)))
+
+		arg, err = types.ParseArg(newType("int64"), "-5")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(arg).To(Equal(int64(-5)))
+	})
+
+	It("Rejects numbers out of range for their type", func() {
+		_, err := types.ParseArg(newType("uint8"), "256")
+		Expect(err).To(HaveOccurred())
+		_, err = types.ParseArg(newType("uint256"), "-1")
+		Expect(err).To(HaveOccurred())
+		_, err = types.ParseArg(newType("int8"), "128")
+		Expect(err).To(HaveOccurred())
+
+		arg, err := types.ParseArg(newType("int8"), "-128")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(arg).To(Equal(int8(-128)))
+	})
+
+	It("Parses addresses, booleans and byte arrays", func() {
+		arg, err := types.ParseArg(newType("address"), "0xfE9e8709d3215310075d67E3ed32A380CCf451C8")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(arg).To(Equal(common.HexToAddress("0xfE9e8709d3215310075d67E3ed32A380CCf451C8")))
+
+		arg, err = types.ParseArg(newType("bool"), "true")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(arg).To(Equal(true))
+
+		arg, err = types.ParseArg(newType("bytes4"), "0x01ffc9a7")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(arg).To(Equal([4]byte{0x01, 0xff, 0xc9, 0xa7}))
+
+		_, err = types.ParseArg(newType("bytes4"), "0x01ff")
+		Expect(err).To(HaveOccurred())
+		_, err = types.ParseArg(newType("address"), "not an address")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("Only accepts elementary types as pollable arguments", func() {
+		Expect(types.PollableArg(newType("uint256"))).To(BeTrue())
+		Expect(types.PollableArg(newType("string"))).To(BeTrue())
+		Expect(types.PollableArg(newType("address[]"))).To(BeFalse())
+	})
+})
@@ -27,15 +27,21 @@ import (
 
 // Event is our custom event type
 type Event struct {
-	Name      string
-	Anonymous bool
-	Fields    []Field
+	Name       string
+	RawName    string // Name in the abi, which Name is suffixed from for overloads; empty when the same as Name
+	Identifier string // Postgres safe identifier used to name the event's table and check column
+	Anonymous  bool
+	Fields     []Field
+	Indexes    []string // Columns to build btree indexes on when the event's table is created
+	// Number of blocks held by each partition of the event's table; 0 leaves the table unpartitioned
+	PartitionSize int64
 }
 
 // Field is our custom event field type which associates a postgres type with the field
 type Field struct {
 	abi.Argument        // Name, Type, Indexed
 	PgType       string // Holds type used when committing data held in this field to postgres
+	ColumnName   string // Postgres safe column name generated from the field name
 }
 
 // Log is used to hold instance of an event log data
@@ -56,9 +62,11 @@ type Log struct {
 // NewEvent unpacks abi.Event into our custom Event struct
 func NewEvent(e abi.Event) Event {
 	fields := make([]Field, len(e.Inputs))
+	columns := NewColumnSet()
 	for i, input := range e.Inputs {
 		fields[i] = Field{}
 		fields[i].Name = input.Name
+		fields[i].ColumnName = columns.Add(input.Name, i)
 		fields[i].Type = input.Type
 		fields[i].Indexed = input.Indexed
 		// Fill in pg type based on abi type
@@ -80,20 +88,94 @@ func NewEvent(e abi.Event) Event {
 		}
 	}
 
-	return Event{
-		Name:      e.Name,
-		Anonymous: e.Anonymous,
-		Fields:    fields,
+	rawName := e.RawName
+	if rawName == e.Name {
+		rawName = ""
 	}
+	event := Event{
+		Name:       e.Name,
+		RawName:    rawName,
+		Identifier: NewIdentifierSet().Add(e.Name, 0),
+		Anonymous:  e.Anonymous,
+		Fields:     fields,
+	}
+	event.Indexes = event.IndexColumns(nil, nil)
+
+	return event
 }
 
-// Sig returns the hash signature for an event
-func (e Event) Sig() common.Hash {
+// IndexColumns returns the columns to index for this event
+// By default fields declared indexed in the abi and address fields are indexed
+// Fields named in add are indexed in addition and fields named in suppress are not indexed; "*" suppresses every default index
+// Field names are matched case-insensitively
+func (e Event) IndexColumns(add, suppress []string) []string {
+	contains := func(names []string, name string) bool {
+		for _, n := range names {
+			if strings.EqualFold(n, name) {
+				return true
+			}
+		}
+		return false
+	}
+	suppressDefaults := contains(suppress, "*")
+	columns := make([]string, 0)
+	for _, field := range e.Fields {
+		automatic := !suppressDefaults && (field.Indexed || field.Type.T == abi.AddressTy)
+		if (automatic || contains(add, field.Name)) && !contains(suppress, field.Name) {
+			columns = append(columns, field.ColumnName)
+		}
+	}
+
+	return columns
+}
+
+// CheckFields returns an error naming any of the field names, other than the "*" wildcard, which the event does not have
+// Field names are matched case-insensitively
+func (e Event) CheckFields(names []string) error {
+	unknown := make([]string, 0)
+	for _, name := range names {
+		found := name == "*"
+		for _, field := range e.Fields {
+			found = found || strings.EqualFold(field.Name, name)
+		}
+		if !found {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		return fmt.Errorf("event %s has no fields named %s", e.Name, strings.Join(unknown, ", "))
+	}
+
+	return nil
+}
+
+// Columns returns the map of event field names to their column names
+func (e Event) Columns() map[string]string {
+	columns := make(map[string]string, len(e.Fields))
+	for _, field := range e.Fields {
+		columns[field.Name] = field.ColumnName
+	}
+
+	return columns
+}
+
+// Signature returns the canonical text signature for an event, e.g. Transfer(address,address,uint256)
+func (e Event) Signature() string {
 	types := make([]string, len(e.Fields))
 
 	for i, input := range e.Fields {
 		types[i] = input.Type.String()
 	}
 
-	return crypto.Keccak256Hash([]byte(fmt.Sprintf("%v(%v)", e.Name, strings.Join(types, ","))))
+	name := e.Name
+	if e.RawName != "" {
+		name = e.RawName
+	}
+
+	return fmt.Sprintf("%v(%v)", name, strings.Join(types, ","))
+}
+
+// Sig returns the hash signature for an event
+func (e Event) Sig() common.Hash {
+	return crypto.Keccak256Hash([]byte(e.Signature()))
 }
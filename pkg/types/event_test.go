@@ -0,0 +1,64 @@
+// VulcanizeDB
+// Copyright © 2019 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package types_test
+
+import (
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/vulcanize/eth-contract-watcher/pkg/types"
+)
+
+var _ = Describe("Event", func() {
+	var event types.Event
+
+	BeforeEach(func() {
+		abiStr := `[{"anonymous":false,"inputs":[{"indexed":false,"name":"src","type":"address"},{"indexed":true,"name":"id","type":"uint256"},{"indexed":false,"name":"amount","type":"uint256"}],"name":"Deposit","type":"event"}]`
+		parsed, err := abi.JSON(strings.NewReader(abiStr))
+		Expect(err).ToNot(HaveOccurred())
+		event = types.NewEvent(parsed.Events["Deposit"])
+	})
+
+	Describe("IndexColumns", func() {
+		It("Indexes indexed and address fields by default", func() {
+			Expect(event.Indexes).To(Equal([]string{"src_", "id_"}))
+		})
+
+		It("Adds and suppresses configured fields", func() {
+			Expect(event.IndexColumns([]string{"Amount"}, []string{"src"})).To(Equal([]string{"id_", "amount_"}))
+		})
+
+		It("Suppresses every default index with a wildcard", func() {
+			Expect(event.IndexColumns([]string{"amount"}, []string{"*"})).To(Equal([]string{"amount_"}))
+			Expect(event.IndexColumns(nil, []string{"*"})).To(BeEmpty())
+		})
+
+		It("Rejects field names the event does not have", func() {
+			Expect(event.CheckFields([]string{"Amount", "*"})).To(Succeed())
+			Expect(event.CheckFields([]string{"amount", "amt"})).To(MatchError("event Deposit has no fields named amt"))
+		})
+	})
+
+	Describe("Signature", func() {
+		It("Returns the canonical event signature", func() {
+			Expect(event.Signature()).To(Equal("Deposit(address,uint256,uint256)"))
+		})
+	})
+})
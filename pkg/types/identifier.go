@@ -0,0 +1,128 @@
+// VulcanizeDB
+// Copyright © 2019 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package types
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// MaxIdentifierLength is the maximum number of bytes Postgres keeps in an identifier; longer identifiers are silently truncated
+const MaxIdentifierLength = 63
+
+// Number of hex characters of the name hash appended to truncated identifiers
+const identifierHashLength = 8
+
+// Postgres reserved key words which cannot be used as unquoted identifiers
+var reservedWords = map[string]bool{
+	"all": true, "analyse": true, "analyze": true, "and": true, "any": true, "array": true, "as": true, "asc": true,
+	"asymmetric": true, "both": true, "case": true, "cast": true, "check": true, "collate": true, "column": true,
+	"constraint": true, "create": true, "current_catalog": true, "current_date": true, "current_role": true,
+	"current_time": true, "current_timestamp": true, "current_user": true, "default": true, "deferrable": true,
+	"desc": true, "distinct": true, "do": true, "else": true, "end": true, "except": true, "false": true, "fetch": true,
+	"for": true, "foreign": true, "from": true, "grant": true, "group": true, "having": true, "in": true,
+	"initially": true, "intersect": true, "into": true, "lateral": true, "leading": true, "limit": true,
+	"localtime": true, "localtimestamp": true, "not": true, "null": true, "offset": true, "on": true, "only": true,
+	"or": true, "order": true, "placing": true, "primary": true, "references": true, "returning": true,
+	"select": true, "session_user": true, "some": true, "symmetric": true, "table": true, "then": true, "to": true,
+	"trailing": true, "true": true, "union": true, "unique": true, "user": true, "using": true, "variadic": true,
+	"when": true, "where": true, "window": true, "with": true,
+}
+
+// IdentifierSet deterministically generates unique, Postgres safe identifiers from ABI names
+// Names are lowercased and stripped of characters not allowed in unquoted identifiers,
+// empty names are replaced by their position (e.g. `arg0`), reserved words are suffixed with an underscore,
+// duplicates are suffixed with a counter, and overlong names are truncated and suffixed with a hash of the full name
+type IdentifierSet struct {
+	suffix  string            // Appended to every identifier, e.g. "_" for columns
+	used    map[string]bool   // Identifiers already handed out
+	mapping map[string]string // Map of ABI name to generated identifier
+}
+
+// NewIdentifierSet returns an IdentifierSet for naming events and methods
+func NewIdentifierSet() *IdentifierSet {
+	return &IdentifierSet{
+		used:    map[string]bool{},
+		mapping: map[string]string{},
+	}
+}
+
+// NewColumnSet returns an IdentifierSet for naming columns
+// Column identifiers end with an underscore to avoid collisions with reserved words and the fixed columns of generated tables
+func NewColumnSet() *IdentifierSet {
+	set := NewIdentifierSet()
+	set.suffix = "_"
+	return set
+}
+
+// Add generates the identifier for the name at the given position and reserves it
+func (s *IdentifierSet) Add(name string, position int) string {
+	base := sanitize(name)
+	if base == "" {
+		base = fmt.Sprintf("arg%d", position)
+	}
+	if s.suffix == "" && reservedWords[base] {
+		base = base + "_"
+	}
+	id := TruncateIdentifier(base+s.suffix, name)
+	for i := 1; s.used[id]; i++ {
+		id = TruncateIdentifier(fmt.Sprintf("%s_%d%s", base, i, s.suffix), name)
+	}
+	s.used[id] = true
+	if name != "" {
+		s.mapping[name] = id
+	}
+
+	return id
+}
+
+// Mapping returns the map of ABI names to generated identifiers
+func (s *IdentifierSet) Mapping() map[string]string {
+	return s.mapping
+}
+
+// TruncateIdentifier shortens identifiers longer than Postgres allows,
+// replacing the tail with a hash of the seed so that truncated identifiers remain distinct
+func TruncateIdentifier(id, seed string) string {
+	if len(id) <= MaxIdentifierLength {
+		return id
+	}
+	hash := crypto.Keccak256Hash([]byte(seed + id)).Hex()[2 : 2+identifierHashLength]
+
+	return id[:MaxIdentifierLength-identifierHashLength-1] + "_" + hash
+}
+
+// Lowercases the name and strips characters which are not allowed in unquoted identifiers
+func sanitize(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '_':
+			b.WriteRune(r)
+		case r == '$':
+			b.WriteRune('_')
+		}
+	}
+	id := b.String()
+	if id != "" && id[0] >= '0' && id[0] <= '9' {
+		id = "_" + id
+	}
+
+	return id
+}
@@ -0,0 +1,69 @@
+// VulcanizeDB
+// Copyright © 2019 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package types_test
+
+import (
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/vulcanize/eth-contract-watcher/pkg/types"
+)
+
+var _ = Describe("IdentifierSet", func() {
+	It("Lowercases names and suffixes columns with an underscore", func() {
+		columns := types.NewColumnSet()
+		Expect(columns.Add("_from", 0)).To(Equal("_from_"))
+		Expect(columns.Add("Value", 1)).To(Equal("value_"))
+	})
+
+	It("Names empty names by their position", func() {
+		columns := types.NewColumnSet()
+		Expect(columns.Add("", 0)).To(Equal("arg0_"))
+		Expect(columns.Add("", 1)).To(Equal("arg1_"))
+	})
+
+	It("Deduplicates names which collide once lowercased", func() {
+		ids := types.NewIdentifierSet()
+		Expect(ids.Add("Transfer", 0)).To(Equal("transfer"))
+		Expect(ids.Add("transfer", 1)).To(Equal("transfer_1"))
+		Expect(ids.Mapping()).To(Equal(map[string]string{"Transfer": "transfer", "transfer": "transfer_1"}))
+	})
+
+	It("Suffixes reserved words", func() {
+		ids := types.NewIdentifierSet()
+		Expect(ids.Add("Select", 0)).To(Equal("select_"))
+	})
+
+	It("Strips characters which are not allowed in identifiers", func() {
+		ids := types.NewIdentifierSet()
+		Expect(ids.Add("$weird$", 0)).To(Equal("_weird_"))
+		Expect(ids.Add("1st", 1)).To(Equal("_1st"))
+	})
+
+	It("Truncates overlong names with a hash so they remain distinct", func() {
+		long := strings.Repeat("a", 70)
+		ids := types.NewIdentifierSet()
+		first := ids.Add(long+"b", 0)
+		second := ids.Add(long+"c", 1)
+		Expect(len(first)).To(Equal(types.MaxIdentifierLength))
+		Expect(len(second)).To(Equal(types.MaxIdentifierLength))
+		Expect(first).ToNot(Equal(second))
+		Expect(types.NewIdentifierSet().Add(long+"b", 0)).To(Equal(first))
+	})
+})
@@ -27,81 +27,140 @@ import (
 
 // Method is our custom method struct
 type Method struct {
-	Name   string
-	Const  bool
-	Args   []Field
-	Return []Field
+	Name       string
+	RawName    string // Name in the abi, which Name is suffixed from for overloads; empty when the same as Name
+	Identifier string // Postgres safe identifier used to name the method's table and check column
+	Const      bool
+	Args       []Field
+	Return     []Field // The abi return values
+	Outputs    []Field // Columns the return values are persisted to, with any tuples flattened into their components
+	// Number of blocks held by each partition of the method's table; 0 leaves the table unpartitioned
+	PartitionSize int64
+	// Only persist results which change the method's value, as rows valid over a range of blocks
+	ChangesOnly bool
+	// When the method is polled; the zero Schedule polls it at every block
+	Schedule Schedule
 }
 
 // Result is used to hold instance of result from method call with given inputs and block
 type Result struct {
 	Method
-	Inputs []interface{} // Will only use addresses
-	Output interface{}
-	PgType string // Holds output pg type
-	Block  int64
+	Inputs   []interface{} // String forms of the argument values
+	Output   interface{}   // Returned value of methods persisting a single output column
+	Returned []interface{} // String forms of the values of each output column; takes precedence over Output when set
+	PgType   string        // Holds output pg type
+	Block    int64
+	// Set when the call reverted, in which case there are no returned values
+	Reverted     bool
+	RevertReason string
+	RevertData   string // Hex encoded revert data, if any
 }
 
 // NewMethod unpacks abi.Method into our custom Method struct
 func NewMethod(m abi.Method) Method {
 	inputs := make([]Field, len(m.Inputs))
+	columns := NewColumnSet()
 	for i, input := range m.Inputs {
 		inputs[i] = Field{}
 		inputs[i].Name = input.Name
+		inputs[i].ColumnName = columns.Add(input.Name, i)
 		inputs[i].Type = input.Type
 		inputs[i].Indexed = input.Indexed
-		switch inputs[i].Type.T {
-		case abi.HashTy, abi.AddressTy:
-			inputs[i].PgType = "CHARACTER VARYING(66)"
-		case abi.IntTy, abi.UintTy:
-			inputs[i].PgType = "NUMERIC"
-		case abi.BoolTy:
-			inputs[i].PgType = "BOOLEAN"
-		case abi.BytesTy, abi.FixedBytesTy:
-			inputs[i].PgType = "BYTEA"
-		case abi.ArrayTy:
-			inputs[i].PgType = "TEXT[]"
-		case abi.FixedPointTy:
-			inputs[i].PgType = "MONEY" // use shopspring/decimal for fixed point numbers in go and money type in postgres?
-		default:
-			inputs[i].PgType = "TEXT"
-		}
+		inputs[i].PgType = pgType(input.Type)
 	}
 
-	outputs := make([]Field, len(m.Outputs))
+	returns := make([]Field, len(m.Outputs))
 	for i, output := range m.Outputs {
+		returns[i] = Field{}
+		returns[i].Name = output.Name
+		returns[i].Type = output.Type
+		returns[i].Indexed = output.Indexed
+		returns[i].PgType = pgType(output.Type)
+	}
+
+	// A single value is persisted to the `returned` column; several values, or the components of
+	// a returned tuple, are persisted to columns named from the abi, following the input columns
+	flattened := FlattenOutputs(m.Outputs)
+	outputs := make([]Field, len(flattened))
+	for i, output := range flattened {
 		outputs[i] = Field{}
 		outputs[i].Name = output.Name
 		outputs[i].Type = output.Type
-		outputs[i].Indexed = output.Indexed
-		switch outputs[i].Type.T {
-		case abi.HashTy, abi.AddressTy:
-			outputs[i].PgType = "CHARACTER VARYING(66)"
-		case abi.IntTy, abi.UintTy:
-			outputs[i].PgType = "NUMERIC"
-		case abi.BoolTy:
-			outputs[i].PgType = "BOOLEAN"
-		case abi.BytesTy, abi.FixedBytesTy:
-			outputs[i].PgType = "BYTEA"
-		case abi.ArrayTy:
-			outputs[i].PgType = "TEXT[]"
-		case abi.FixedPointTy:
-			outputs[i].PgType = "MONEY" // use shopspring/decimal for fixed point numbers in go and money type in postgres?
-		default:
-			outputs[i].PgType = "TEXT"
+		outputs[i].PgType = pgType(output.Type)
+		if len(m.Outputs) == 1 && m.Outputs[0].Type.T != abi.TupleTy {
+			outputs[i].ColumnName = "returned"
+			continue
+		}
+		name := output.Name
+		if name == "" {
+			name = fmt.Sprintf("out%d", i)
 		}
+		outputs[i].ColumnName = columns.Add(name, len(m.Inputs)+i)
+	}
+
+	rawName := m.RawName
+	if rawName == m.Name {
+		rawName = ""
 	}
 
 	return Method{
-		Name:   m.Name,
-		Const:  m.Const,
-		Args:   inputs,
-		Return: outputs,
+		Name:       m.Name,
+		RawName:    rawName,
+		Identifier: NewIdentifierSet().Add(m.Name, 0),
+		Const:      m.Const,
+		Args:       inputs,
+		Return:     returns,
+		Outputs:    outputs,
 	}
 }
 
-// Sig returns the hash signature for the method
-func (m Method) Sig() common.Hash {
+// FlattenOutputs replaces any tuples among the outputs with their components
+func FlattenOutputs(outputs abi.Arguments) abi.Arguments {
+	flattened := make(abi.Arguments, 0, len(outputs))
+	for _, output := range outputs {
+		if output.Type.T != abi.TupleTy {
+			flattened = append(flattened, output)
+			continue
+		}
+		for i, elem := range output.Type.TupleElems {
+			flattened = append(flattened, abi.Argument{Name: output.Type.TupleRawNames[i], Type: *elem})
+		}
+	}
+
+	return flattened
+}
+
+// Returns the postgres type used to hold values of the abi type
+func pgType(t abi.Type) string {
+	switch t.T {
+	case abi.HashTy, abi.AddressTy:
+		return "CHARACTER VARYING(66)"
+	case abi.IntTy, abi.UintTy:
+		return "NUMERIC"
+	case abi.BoolTy:
+		return "BOOLEAN"
+	case abi.BytesTy, abi.FixedBytesTy:
+		return "BYTEA"
+	case abi.ArrayTy:
+		return "TEXT[]"
+	case abi.FixedPointTy:
+		return "MONEY" // use shopspring/decimal for fixed point numbers in go and money type in postgres?
+	default:
+		return "TEXT"
+	}
+}
+
+// Values returns the values of each of the result's output columns
+func (r Result) Values() []interface{} {
+	if r.Returned != nil {
+		return r.Returned
+	}
+
+	return []interface{}{r.Output}
+}
+
+// Signature returns the canonical text signature for the method, e.g. balanceOf(address)
+func (m Method) Signature() string {
 	types := make([]string, len(m.Args))
 	i := 0
 	for _, arg := range m.Args {
@@ -109,5 +168,15 @@ func (m Method) Sig() common.Hash {
 		i++
 	}
 
-	return crypto.Keccak256Hash([]byte(fmt.Sprintf("%v(%v)", m.Name, strings.Join(types, ","))))
+	name := m.Name
+	if m.RawName != "" {
+		name = m.RawName
+	}
+
+	return fmt.Sprintf("%v(%v)", name, strings.Join(types, ","))
+}
+
+// Sig returns the hash signature for the method
+func (m Method) Sig() common.Hash {
+	return crypto.Keccak256Hash([]byte(m.Signature()))
 }
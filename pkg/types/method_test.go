@@ -0,0 +1,68 @@
+// VulcanizeDB
+// Copyright © 2019 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package types_test
+
+import (
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/vulcanize/eth-contract-watcher/pkg/types"
+)
+
+var _ = Describe("Method", func() {
+	var parsed abi.ABI
+
+	BeforeEach(func() {
+		abiStr := `[
+			{"constant":true,"inputs":[{"name":"owner","type":"address"}],"name":"balanceOf","outputs":[{"name":"balance","type":"uint256"}],"type":"function"},
+			{"constant":true,"inputs":[],"name":"getReserves","outputs":[{"name":"_reserve0","type":"uint112"},{"name":"_reserve1","type":"uint112"},{"name":"","type":"uint32"}],"type":"function"},
+			{"constant":true,"inputs":[{"name":"owner","type":"address"}],"name":"snapshot","outputs":[{"components":[{"name":"owner","type":"address"},{"name":"balance","type":"uint256"}],"name":"","type":"tuple"}],"type":"function"}
+		]`
+		var err error
+		parsed, err = abi.JSON(strings.NewReader(abiStr))
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	outputColumns := func(method types.Method) []string {
+		columns := make([]string, 0, len(method.Outputs))
+		for _, output := range method.Outputs {
+			columns = append(columns, output.ColumnName)
+		}
+		return columns
+	}
+
+	It("Persists a single return value to the returned column", func() {
+		method := types.NewMethod(parsed.Methods["balanceOf"])
+		Expect(outputColumns(method)).To(Equal([]string{"returned"}))
+		Expect(method.Outputs[0].PgType).To(Equal("NUMERIC"))
+	})
+
+	It("Names a column for each of several return values", func() {
+		method := types.NewMethod(parsed.Methods["getReserves"])
+		Expect(outputColumns(method)).To(Equal([]string{"_reserve0_", "_reserve1_", "out2_"}))
+	})
+
+	It("Flattens returned tuples into their components without colliding with the inputs", func() {
+		method := types.NewMethod(parsed.Methods["snapshot"])
+		Expect(len(method.Return)).To(Equal(1))
+		Expect(outputColumns(method)).To(Equal([]string{"owner_1_", "balance_"}))
+		Expect(method.Outputs[0].PgType).To(Equal("CHARACTER VARYING(66)"))
+	})
+})
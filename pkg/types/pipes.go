@@ -0,0 +1,117 @@
+// VulcanizeDB
+// Copyright © 2019 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package types
+
+import "sort"
+
+// Pipes maps method names to the methods their returned values are piped to as arguments
+type Pipes map[string][]string
+
+// Cycle returns a chain of methods which pipes back to its first method, e.g. [a b a], or nil if there is none
+func (p Pipes) Cycle() []string {
+	sources := make([]string, 0, len(p))
+	for source := range p {
+		sources = append(sources, source)
+	}
+	sort.Strings(sources)
+
+	// Methods on the current path are visiting; those whose every path has been followed are done
+	const (
+		visiting = 1
+		done     = 2
+	)
+	state := make(map[string]int)
+	var path []string
+	var visit func(method string) []string
+	visit = func(method string) []string {
+		switch state[method] {
+		case visiting:
+			for i, m := range path {
+				if m == method {
+					return append(append([]string{}, path[i:]...), method)
+				}
+			}
+		case done:
+			return nil
+		}
+		state[method] = visiting
+		path = append(path, method)
+		for _, target := range p[method] {
+			if cycle := visit(target); cycle != nil {
+				return cycle
+			}
+		}
+		path = path[:len(path)-1]
+		state[method] = done
+		return nil
+	}
+	for _, source := range sources {
+		if cycle := visit(source); cycle != nil {
+			return cycle
+		}
+	}
+
+	return nil
+}
+
+// Order sorts the method names so that each follows every method piped to it, otherwise keeping their given order
+// Methods in a cycle are left in their given order after the rest
+func (p Pipes) Order(names []string) []string {
+	// Count the methods among the names piped to each
+	included := make(map[string]bool, len(names))
+	for _, name := range names {
+		included[name] = true
+	}
+	waiting := make(map[string]int, len(names))
+	for source, targets := range p {
+		if !included[source] {
+			continue
+		}
+		for _, target := range targets {
+			waiting[target]++
+		}
+	}
+
+	ordered := make([]string, 0, len(names))
+	placed := make(map[string]bool, len(names))
+	for len(ordered) < len(names) {
+		next := -1
+		for i, name := range names {
+			if !placed[name] && waiting[name] == 0 {
+				next = i
+				break
+			}
+		}
+		if next < 0 {
+			for _, name := range names {
+				if !placed[name] {
+					ordered = append(ordered, name)
+					placed[name] = true
+				}
+			}
+			break
+		}
+		name := names[next]
+		ordered = append(ordered, name)
+		placed[name] = true
+		for _, target := range p[name] {
+			waiting[target]--
+		}
+	}
+
+	return ordered
+}
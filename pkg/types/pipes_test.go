@@ -0,0 +1,47 @@
+// VulcanizeDB
+// Copyright © 2019 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package types_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/vulcanize/eth-contract-watcher/pkg/types"
+)
+
+var _ = Describe("Pipes", func() {
+	It("finds a cycle of methods piped back to themselves", func() {
+		pipes := types.Pipes{"owner": {"balanceOf"}, "balanceOf": {"tokenOf"}, "tokenOf": {"owner"}}
+
+		Expect(pipes.Cycle()).To(Equal([]string{"balanceOf", "tokenOf", "owner", "balanceOf"}))
+		Expect(types.Pipes{"owner": {"owner"}}.Cycle()).To(Equal([]string{"owner", "owner"}))
+	})
+
+	It("finds no cycle where there is none", func() {
+		pipes := types.Pipes{"owner": {"balanceOf", "allowance"}, "balanceOf": {"allowance"}}
+
+		Expect(pipes.Cycle()).To(BeNil())
+	})
+
+	It("orders methods after those piped to them", func() {
+		pipes := types.Pipes{"owner": {"balanceOf"}, "admin": {"owner"}}
+
+		ordered := pipes.Order([]string{"balanceOf", "totalSupply", "owner", "admin"})
+
+		Expect(ordered).To(Equal([]string{"totalSupply", "admin", "owner", "balanceOf"}))
+	})
+})
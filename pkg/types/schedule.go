@@ -0,0 +1,47 @@
+// VulcanizeDB
+// Copyright © 2019 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package types
+
+// Schedule is when a method is polled; at most one of its fields is set
+type Schedule struct {
+	Every   int64   // Poll every this many blocks, counting from the contract's starting block
+	Seconds int64   // Poll at the first block at least this many seconds of block time after the previous poll
+	At      []int64 // Poll only at these blocks
+	Head    bool    // Poll only at the most recent header, skipping blocks while catching up
+}
+
+// Due returns true if the method is to be polled at the block
+// lastPolled is the block time of the previous poll, or 0 if there has not been one
+func (s Schedule) Due(startingBlock, blockNumber, blockTime, lastPolled int64, head bool) bool {
+	switch {
+	case s.Every > 0:
+		return (blockNumber-startingBlock)%s.Every == 0
+	case s.Seconds > 0:
+		return lastPolled == 0 || blockTime-lastPolled >= s.Seconds
+	case len(s.At) > 0:
+		for _, at := range s.At {
+			if at == blockNumber {
+				return true
+			}
+		}
+		return false
+	case s.Head:
+		return head
+	default:
+		return true
+	}
+}
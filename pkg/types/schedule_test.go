@@ -0,0 +1,56 @@
+// VulcanizeDB
+// Copyright © 2019 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package types_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/vulcanize/eth-contract-watcher/pkg/types"
+)
+
+var _ = Describe("Schedule", func() {
+	It("Is due at every block by default", func() {
+		Expect(types.Schedule{}.Due(100, 101, 0, 0, false)).To(BeTrue())
+	})
+
+	It("Is due every N blocks from the starting block", func() {
+		schedule := types.Schedule{Every: 10}
+		Expect(schedule.Due(100, 100, 0, 0, false)).To(BeTrue())
+		Expect(schedule.Due(100, 105, 0, 0, false)).To(BeFalse())
+		Expect(schedule.Due(100, 110, 0, 0, false)).To(BeTrue())
+	})
+
+	It("Is due once enough block time has passed since the previous poll", func() {
+		schedule := types.Schedule{Seconds: 3600}
+		Expect(schedule.Due(100, 101, 50000000, 0, false)).To(BeTrue())
+		Expect(schedule.Due(100, 102, 50000015, 50000000, false)).To(BeFalse())
+		Expect(schedule.Due(100, 400, 50003600, 50000000, false)).To(BeTrue())
+	})
+
+	It("Is due at the listed blocks", func() {
+		schedule := types.Schedule{At: []int64{105, 110}}
+		Expect(schedule.Due(100, 105, 0, 0, false)).To(BeTrue())
+		Expect(schedule.Due(100, 106, 0, 0, false)).To(BeFalse())
+	})
+
+	It("Is due only at the head", func() {
+		schedule := types.Schedule{Head: true}
+		Expect(schedule.Due(100, 105, 0, 0, false)).To(BeFalse())
+		Expect(schedule.Due(100, 105, 0, 0, true)).To(BeTrue())
+	})
+})